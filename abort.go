@@ -0,0 +1,52 @@
+package eal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"syscall"
+)
+
+// StatusClientClosedRequest is logged as the status of requests that the client aborted before a response was sent.
+// It's the non-standard status used by nginx for the same purpose.
+const StatusClientClosedRequest = 499
+
+// ClientAbortLevel is the level of access log entries of requests that the client aborted, see DefaultAccessLevel.
+var ClientAbortLevel = InfoLevel
+
+// abortPanic is returned by callHandler when the handler panicked with http.ErrAbortHandler, so that the access log
+// entry can be written before the panic is resumed.
+type abortPanic struct {
+	value interface{}
+}
+
+func (ap *abortPanic) Error() string {
+	return http.ErrAbortHandler.Error()
+}
+
+func (ap *abortPanic) Unwrap() error {
+	return http.ErrAbortHandler
+}
+
+// isClientAbort report if the request failed because the client went away, i.e. the handler was aborted with
+// http.ErrAbortHandler, writing the response failed with a broken pipe or a connection reset, or the request context
+// was canceled by the server because the connection was closed.
+func isClientAbort(ctx context.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, http.ErrAbortHandler), errors.Is(err, syscall.EPIPE), errors.Is(err, syscall.ECONNRESET):
+		return true
+	}
+	// A cancel cause other than context.Canceled mean that the context was canceled by the application
+	return errors.Is(err, context.Canceled) && ctx.Err() != nil && context.Cause(ctx) == context.Canceled
+}
+
+// setClientAbortFields add the client_aborted field, and set the status to StatusClientClosedRequest if no response
+// was sent.
+func setClientAbortFields(fields Fields, committed bool) {
+	fields[FieldClientAborted] = true
+	if !committed {
+		fields[FieldStatus] = StatusClientClosedRequest
+	}
+}
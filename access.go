@@ -0,0 +1,157 @@
+package eal
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+const defaultAccessMessage = "access"
+
+// AccessLevelFunc decide the level of the access log entries written by the logger middlewares, from the response
+// status, the error returned by the handler (if any) and the log fields. It can be replaced to change the levels, and
+// is overridden by SetAccessLevel. The default is DefaultAccessLevel.
+var AccessLevelFunc func(status int, err error, fields Fields) Level = DefaultAccessLevel
+
+// LegacyControlFields control if fields with a "_" prefix are treated as control fields, that are never logged, and if
+// the control field "_msg" is used as the message of the access log entry. "_msg" was the only way to change the
+// access log message before SetAccessMessage was added. If LegacyControlFields is disabled, fields with a "_" prefix
+// are logged as any other field. LegacyControlFields will be disabled by default in a future version.
+var LegacyControlFields = true
+
+type (
+	accessContextKey struct{}
+
+	// accessOptions hold the per-request options for the access log entry.
+	accessOptions struct {
+		mu      sync.Mutex
+		message string
+		level   *Level
+	}
+)
+
+// SetAccessMessage set the message of the access log entry that is written by the logger middleware for the request.
+// The default message is "access".
+func SetAccessMessage(c echo.Context, msg string) {
+	if ao := contextAccessOptions(c); ao != nil {
+		ao.mu.Lock()
+		ao.message = msg
+		ao.mu.Unlock()
+	}
+}
+
+// SetAccessLevel set the level of the access log entry that is written by the logger middleware for the request. By
+// default, the access log entry is written with error level if the request failed with an error, and otherwise with
+// info level.
+func SetAccessLevel(c echo.Context, level Level) {
+	if ao := contextAccessOptions(c); ao != nil {
+		ao.mu.Lock()
+		ao.level = &level
+		ao.mu.Unlock()
+	}
+}
+
+func withAccessOptions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, accessContextKey{}, &accessOptions{})
+}
+
+func contextAccessOptions(c echo.Context) *accessOptions {
+	if c == nil || c.Request() == nil {
+		return nil
+	}
+	ao, _ := c.Request().Context().Value(accessContextKey{}).(*accessOptions)
+	return ao
+}
+
+// DefaultAccessLevel return info level for successful requests, and for requests that failed with an expected error,
+// i.e. an echo.HTTPError with a client error status (4xx), such as a 404 returned by the handler. Requests that failed
+// with an ErrorCode that have a Severity, or with an error that declare a level (see RegisterErrorLevel), are logged
+// with that level, and requests that the client aborted are logged with ClientAbortLevel. Other errors are logged with
+// error level.
+func DefaultAccessLevel(status int, err error, fields Fields) Level {
+	if err == nil {
+		return InfoLevel
+	}
+	if aborted, _ := fields[FieldClientAborted].(bool); aborted {
+		return ClientAbortLevel
+	}
+	if level, ok := codeSeverity(err); ok {
+		return level
+	}
+	if level, ok := errorLevel(err); ok {
+		return level
+	}
+	if he := GetInnerHTTPError(err); he != nil && he.Code < http.StatusInternalServerError && status < http.StatusInternalServerError {
+		return InfoLevel
+	}
+	return ErrorLevel
+}
+
+// renameFields return a copy of the fields, with the field names in names renamed.
+func renameFields(fields Fields, names map[string]string) Fields {
+	renamed := make(Fields, len(fields))
+	for k, v := range fields {
+		if name, ok := names[k]; ok && name != "" {
+			k = name
+		}
+		renamed[k] = v
+	}
+	return renamed
+}
+
+// releaseAccessEntry return the access log entry to the entry pool, if the Emitter is known to not retain the fields
+// of the record.
+func releaseAccessEntry(deps Deps, logEntry *Entry) {
+	if _, ok := deps.Emitter.(LogEmitter); ok {
+		logEntry.Release()
+	}
+}
+
+// writeAccessEntry write the access log entry, using the message and level set for the request. msg is used if no
+// message have been set for the request. The field names are mapped by config.FieldNames, and to ECS names if
+// config.ECS is set. The entry is written by the Emitter in deps.
+func writeAccessEntry(ctx context.Context, deps Deps, config LoggerConfig, logEntry *Entry, logFields Fields, err error, msg string) {
+	if m, ok := logFields["_msg"]; ok && LegacyControlFields {
+		if s, ok := m.(string); ok {
+			msg = s
+		}
+	}
+
+	var ro RouteOptions
+	if path, ok := logFields[FieldRouterPath].(string); ok {
+		ro, _ = routeOptionsFor(path)
+	}
+	for k, v := range ro.Fields {
+		if _, ok := logEntry.Data[k]; !ok {
+			logEntry.Data[k] = v
+		}
+	}
+
+	status, _ := logFields[FieldStatus].(int)
+	level := AccessLevelFunc(status, err, Fields(logEntry.Data))
+	if level == InfoLevel && ro.Level != nil {
+		level = *ro.Level
+	}
+
+	if ao, ok := ctx.Value(accessContextKey{}).(*accessOptions); ok {
+		ao.mu.Lock()
+		if ao.message != "" {
+			msg = ao.message
+		}
+		if ao.level != nil {
+			level = *ao.level
+		}
+		ao.mu.Unlock()
+	}
+
+	fields := Fields(logEntry.Data)
+	if len(config.FieldNames) > 0 {
+		fields = renameFields(fields, config.FieldNames)
+	}
+	if config.ECS {
+		fields = ecsFields(fields)
+	}
+	deps.Emitter.Emit(Record{Time: deps.Clock.Now(), Level: level, Message: msg, Fields: fields, Context: ctx})
+}
@@ -0,0 +1,61 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDefaultAccessLevel(t *testing.T) {
+	errDB := errors.New("connection refused")
+	for _, tt := range []struct {
+		name   string
+		status int
+		err    error
+		want   Level
+	}{
+		{name: "ok", status: http.StatusOK, want: InfoLevel},
+		{name: "client_error_without_error", status: http.StatusBadRequest, want: InfoLevel},
+		{name: "server_error_without_error", status: http.StatusServiceUnavailable, want: InfoLevel},
+		{name: "expected_not_found", status: http.StatusNotFound, err: echo.ErrNotFound, want: InfoLevel},
+		{name: "expected_wrapped", status: http.StatusNotFound, err: NewHTTPError(Trace(errDB), http.StatusNotFound, "Nope"), want: InfoLevel},
+		{name: "expected_wrapped_twice", status: http.StatusConflict, err: fmt.Errorf("save: %w", NewHTTPError(errDB, http.StatusConflict)), want: InfoLevel},
+		{name: "http_server_error", status: http.StatusInternalServerError, err: NewHTTPError(errDB, http.StatusInternalServerError), want: ErrorLevel},
+		{name: "unexpected_error", status: http.StatusInternalServerError, err: errDB, want: ErrorLevel},
+		{name: "error_after_commit", status: http.StatusOK, err: errDB, want: ErrorLevel},
+		{name: "client_error_after_server_status", status: http.StatusBadGateway, err: echo.ErrBadRequest, want: ErrorLevel},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultAccessLevel(tt.status, tt.err, Fields{}); got != tt.want {
+				t.Errorf("got level: %s, want: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	entries := captureLog(t)
+	req := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+	serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{
+		FieldNames:      map[string]string{FieldStatus: "http.status_code", FieldURI: "url.path"},
+		MessageTemplate: "{method} {uri} {status}",
+	}), req, func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	e := logged[0]
+	if e["http.status_code"] != float64(http.StatusNoContent) || e["url.path"] != "/users?id=1" || e[FieldStatus] != nil || e[FieldURI] != nil {
+		t.Errorf("got entry: %v, want renamed status and uri fields", e)
+	}
+	if e["msg"] != "GET /users?id=1 204" {
+		t.Errorf("got message: %v, want the eal field names to be rendered", e["msg"])
+	}
+}
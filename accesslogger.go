@@ -0,0 +1,104 @@
+package eal
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type (
+	// AccessLogger write access log entries for requests that aren't handled by echo, for example by the gRPC
+	// interceptors of the ealgrpc package and the Fiber middleware of the ealfiber package. The entries are written in
+	// the same way as by the logger middleware: the error fields are added by UnwrapError, the level is decided by
+	// AccessLevelFunc, and the latency fields, MessageTemplate, FieldNames, ECS and GeoIP of the LoggerConfig are
+	// applied. The ContextLogFuncs and ResultLogFuncs of the config take an echo.Context, and aren't used.
+	AccessLogger struct {
+		deps        Deps
+		config      LoggerConfig
+		buckets     *latencyBuckets
+		msgTemplate *messageTemplate
+	}
+
+	// accessRequest hold the fields and start time of a request that is logged by an AccessLogger.
+	accessRequest struct {
+		fields *requestFields
+		start  time.Time
+	}
+
+	accessRequestContextKey struct{}
+)
+
+// NewAccessLogger return an AccessLogger configured by the config, with the dependencies provided by deps, see
+// NewLoggerMiddleware.
+func NewAccessLogger(deps Deps, config LoggerConfig) *AccessLogger {
+	if deps.IDGenerator == nil {
+		deps.IDGenerator = config.RequestIDGenerator
+	}
+	for _, name := range config.FieldNames {
+		RegisterFieldNames(name)
+	}
+	return &AccessLogger{
+		deps:        deps.withDefaults(),
+		config:      config,
+		buckets:     newLatencyBuckets(config.LatencyBuckets),
+		msgTemplate: parseMessageTemplate(config.MessageTemplate),
+	}
+}
+
+// NewID return a new request ID, from the IDGenerator of the AccessLogger.
+func (l *AccessLogger) NewID() string {
+	return l.deps.IDGenerator.NewID()
+}
+
+// Start the access logging of a request, with the request fields, and return the context that the request should be
+// handled with. Fields added to the context (or contexts derived from it) with WithFields, and the stages marked with
+// Stage, are added to the access log entry, that is written by Finish.
+func (l *AccessLogger) Start(ctx context.Context, fields Fields) context.Context {
+	if fields == nil {
+		fields = Fields{}
+	}
+	if l.config.GeoIP != nil {
+		setGeoIPFields(l.config.GeoIP, fields)
+	}
+
+	rf := &requestFields{fields: fields, clock: l.deps.Clock}
+	ctx = context.WithValue(ctx, fieldsContextKey{}, rf)
+	ctx = context.WithValue(ctx, accessRequestContextKey{}, &accessRequest{fields: rf, start: l.deps.Clock.Now()})
+	return withAccessOptions(ctx)
+}
+
+// Finish write the access log entry of a request that was started with Start, with the response status and the error
+// that the request failed with, if any. Requests that failed with a 5xx status are reported to the registered
+// ErrorReporters. Finish do nothing if ctx isn't derived from a context returned by Start.
+func (l *AccessLogger) Finish(ctx context.Context, status int, err error) {
+	ar, ok := ctx.Value(accessRequestContextKey{}).(*accessRequest)
+	if !ok {
+		return
+	}
+	now := l.deps.Clock.Now()
+	latency := now.Sub(ar.start)
+
+	logFields := ar.fields.close()
+	setStageFields(ar.fields, logFields, err != nil, now)
+	setLatencyFields(logFields, latency, l.config.LatencyUnit, l.config.LatencyHuman)
+	if l.buckets != nil {
+		logFields[FieldLatencyBucket] = l.buckets.bucket(latency)
+	}
+	logFields[FieldStatus] = status
+	setCancelCauseField(ctx, logFields)
+	setSpanFields(ctx, logFields)
+
+	logEntry := NewEntry().WithFields(logFields)
+	if err != nil {
+		logEntry = logEntry.withError(err)
+	}
+	msg := defaultAccessMessage
+	if l.msgTemplate != nil {
+		msg = l.msgTemplate.render(logEntry.Data)
+	}
+	writeAccessEntry(ctx, l.deps, l.config, logEntry, logFields, err, msg)
+	if status >= http.StatusInternalServerError && isReportable(err) {
+		reportError(err, logEntry.Data)
+	}
+	releaseAccessEntry(l.deps, logEntry)
+}
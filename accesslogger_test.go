@@ -0,0 +1,50 @@
+package eal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAccessLogger(t *testing.T) {
+	var reported []Fields
+	RegisterErrorReporter(func(err error, fields Fields) {
+		reported = append(reported, fields)
+	})
+	t.Cleanup(func() {
+		reportersMu.Lock()
+		reporters = nil
+		reportersMu.Unlock()
+	})
+	clock := &fakeClock{now: time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)}
+	emitter := &recordingEmitter{}
+	l := NewAccessLogger(Deps{Clock: clock, Emitter: emitter, IDGenerator: fakeIDGenerator("id-1")}, LoggerConfig{
+		FieldNames: map[string]string{FieldMethod: "rpc.method"},
+	})
+
+	ctx := l.Start(context.Background(), Fields{FieldRequestID: l.NewID(), FieldMethod: "/users.Users/Get"})
+	WithFields(ctx, Fields{"user_id": 7})
+	l.Finish(ctx, http.StatusOK, nil)
+
+	ctx = l.Start(context.Background(), nil)
+	l.Finish(ctx, http.StatusServiceUnavailable, errors.New("db down"))
+	l.Finish(context.Background(), http.StatusOK, nil)
+
+	if len(emitter.records) != 2 {
+		t.Fatalf("got %d records, want 2", len(emitter.records))
+	}
+	r := emitter.records[0]
+	if r.Level != InfoLevel || r.Fields[FieldRequestID] != "id-1" || r.Fields["rpc.method"] != "/users.Users/Get" ||
+		r.Fields["user_id"] != 7 || r.Fields[FieldLatencyMs] != int64(1000) || r.Fields[FieldStatus] != http.StatusOK {
+		t.Errorf("got first record: %+v, want info level with the request fields", r)
+	}
+	r = emitter.records[1]
+	if r.Level != ErrorLevel || r.Fields[FieldErrorMessage] != "db down" {
+		t.Errorf("got second record: %+v, want error level with the error fields", r)
+	}
+	if len(reported) != 1 {
+		t.Errorf("got %d reported errors, want 1", len(reported))
+	}
+}
@@ -0,0 +1,221 @@
+package eal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// Alerter watch the error entries that are logged, grouped by their fingerprint (see Fingerprint), and post an
+	// alert to a webhook when the same error is logged Threshold times within the sliding Window. A new alert is posted
+	// for the error when it has been logged less than Threshold times within the window, and then pass the threshold
+	// again. The default payload is compatible with Slack incoming webhooks:
+	//
+	//	eal.SetAlerter(&eal.Alerter{WebhookURL: os.Getenv("SLACK_WEBHOOK_URL"), Threshold: 20, Window: 5 * time.Minute})
+	Alerter struct {
+		// WebhookURL is the URL that the alerts are posted to.
+		WebhookURL string
+
+		// Threshold is the number of times an error must be logged within the window to trigger an alert, 10 is used if
+		// Threshold isn't set.
+		Threshold int
+
+		// Window is the length of the sliding time window, one minute is used if Window isn't set.
+		Window time.Duration
+
+		// Payload return the JSON payload of an alert, SlackPayload is used if Payload isn't set.
+		Payload func(a Alert) interface{}
+
+		// Client is the HTTP client used to post alerts, http.DefaultClient is used if Client isn't set.
+		Client *http.Client
+
+		mu        sync.Mutex
+		groups    map[string]*alertGroup
+		lastSweep time.Time
+	}
+
+	// Alert describe an error that passed the threshold of an Alerter.
+	Alert struct {
+		Fingerprint  string
+		Message      string
+		ErrorType    string
+		ErrorMessage string
+		Count        int
+		Window       time.Duration
+
+		// Fields is the log fields of the entry that passed the threshold.
+		Fields Fields
+	}
+
+	// alertGroup hold the times of the latest occurrences of an error fingerprint, at most Threshold of them.
+	alertGroup struct {
+		times   []time.Time
+		alerted bool
+	}
+)
+
+var (
+	alerterMu sync.RWMutex
+	alerter   *Alerter
+)
+
+// SetAlerter start watching the logged error entries with the Alerter. Only one Alerter can be active, nil stop
+// alerting.
+func SetAlerter(a *Alerter) {
+	installHook()
+	alerterMu.Lock()
+	alerter = a
+	alerterMu.Unlock()
+}
+
+// SlackPayload return a Slack incoming webhook payload for the alert.
+func SlackPayload(a Alert) interface{} {
+	text := fmt.Sprintf("*%s* was logged %d times in %s\n>%s: %s\nfingerprint: `%s`",
+		a.Message, a.Count, a.Window, a.ErrorType, a.ErrorMessage, a.Fingerprint)
+	if route, ok := a.Fields[FieldRouterPath]; ok {
+		text += fmt.Sprintf(", route: `%v`", route)
+	}
+	if id, ok := a.Fields[FieldRequestID]; ok {
+		text += fmt.Sprintf(", latest request: `%v`", id)
+	}
+	return map[string]string{"text": text}
+}
+
+// observeAlert pass error entries to the active Alerter, if any.
+func observeAlert(entry *logrus.Entry) {
+	if entry.Level > logrus.ErrorLevel {
+		return
+	}
+	alerterMu.RLock()
+	a := alerter
+	alerterMu.RUnlock()
+	if a == nil {
+		return
+	}
+	if alert := a.observe(entry.Time, entry.Message, entry.Data); alert != nil {
+		go a.post(*alert)
+	}
+}
+
+// observe record an occurrence of the error in the fields, and return an alert if it passed the threshold.
+func (a *Alerter) observe(now time.Time, msg string, fields map[string]interface{}) *Alert {
+	fp := Fingerprint(fields)
+	if fp == "" {
+		return nil
+	}
+	threshold := a.Threshold
+	if threshold <= 0 {
+		threshold = 10
+	}
+	window := a.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.groups == nil {
+		a.groups = map[string]*alertGroup{}
+	}
+	a.sweep(now, window)
+
+	g, ok := a.groups[fp]
+	if !ok {
+		g = &alertGroup{}
+		a.groups[fp] = g
+	}
+	g.prune(now, window)
+	if len(g.times) < threshold {
+		g.alerted = false
+	} else {
+		g.times = g.times[1:]
+	}
+	g.times = append(g.times, now)
+	if g.alerted || len(g.times) < threshold {
+		return nil
+	}
+	g.alerted = true
+
+	alertFields := make(Fields, len(fields))
+	for k, v := range fields {
+		alertFields[k] = v
+	}
+	return &Alert{
+		Fingerprint:  fp,
+		Message:      msg,
+		ErrorType:    fmt.Sprint(fields[FieldErrorType]),
+		ErrorMessage: fmt.Sprint(fields[FieldErrorMessage]),
+		Count:        len(g.times),
+		Window:       window,
+		Fields:       alertFields,
+	}
+}
+
+// sweep remove the groups that have no occurrences within the window, at most once per window.
+func (a *Alerter) sweep(now time.Time, window time.Duration) {
+	if now.Sub(a.lastSweep) < window {
+		return
+	}
+	a.lastSweep = now
+	for fp, g := range a.groups {
+		if g.prune(now, window); len(g.times) == 0 {
+			delete(a.groups, fp)
+		}
+	}
+}
+
+// prune remove the occurrences that are older than the window.
+func (g *alertGroup) prune(now time.Time, window time.Duration) {
+	i := 0
+	for i < len(g.times) && now.Sub(g.times[i]) >= window {
+		i++
+	}
+	g.times = g.times[i:]
+}
+
+// post send the alert to the webhook. Failures are logged at warning level, since error entries could trigger new
+// alerts.
+func (a *Alerter) post(alert Alert) {
+	payload := a.Payload
+	if payload == nil {
+		payload = SlackPayload
+	}
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	err := func() error {
+		body, err := json.Marshal(payload(alert))
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+		}
+		return nil
+	}()
+	if err != nil {
+		NewEntry().WithFields(Fields{FieldErrorMessage: err.Error(), FieldAlertFingerprint: alert.Fingerprint}).Warn("alert_webhook_failed")
+	}
+}
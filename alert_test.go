@@ -0,0 +1,72 @@
+package eal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlerterObserve(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 37, 0, 0, time.UTC)
+	a := &Alerter{Threshold: 3, Window: time.Minute}
+	dbDown := Fields{FieldErrorType: "*errors.errorString", FieldErrorMessage: "db down: conn 1"}
+	other := Fields{FieldErrorType: "*errors.errorString", FieldErrorMessage: "timeout"}
+
+	for i, tt := range []struct {
+		after  time.Duration
+		fields Fields
+		alert  bool
+	}{
+		{fields: dbDown},
+		{fields: other},
+		{after: 10 * time.Second, fields: dbDown},
+		{after: 10 * time.Second, fields: Fields{FieldErrorType: "*errors.errorString", FieldErrorMessage: "db down: conn 2"}, alert: true},
+		{after: 10 * time.Second, fields: dbDown}, // still above the threshold, already alerted
+		{after: 2 * time.Minute, fields: dbDown},  // the window have passed
+		{fields: dbDown},                          // two occurrences in the window
+		{fields: dbDown, alert: true},             // passed the threshold again
+		{fields: Fields{"tenant": "acme"}},        // not an error
+		{fields: other},                           // only counted twice within the window
+	} {
+		now = now.Add(tt.after)
+		alert := a.observe(now, "query failed", tt.fields)
+		if (alert != nil) != tt.alert {
+			t.Fatalf("entry %d: got alert: %+v, want alert: %v", i, alert, tt.alert)
+		}
+		if alert != nil && (alert.Count != 3 || alert.Fingerprint != Fingerprint(dbDown) || alert.Message != "query failed") {
+			t.Errorf("entry %d: got alert: %+v", i, alert)
+		}
+	}
+}
+
+func TestAlerterWebhook(t *testing.T) {
+	captureLog(t)
+	received := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer srv.Close()
+
+	SetAlerter(&Alerter{WebhookURL: srv.URL, Threshold: 2})
+	defer SetAlerter(nil)
+
+	for i := 0; i < 2; i++ {
+		NewEntry().WithFields(Fields{FieldRequestID: "req-1"}).withError(errors.New("db down")).Error("query failed")
+	}
+	NewEntry().WithFields(Fields{FieldErrorMessage: "db down"}).Warn("not an error entry")
+
+	select {
+	case payload := <-received:
+		if text := payload["text"]; !strings.Contains(text, "*query failed* was logged 2 times") || !strings.Contains(text, "`req-1`") {
+			t.Errorf("got text: %q, want the alert", text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("got no webhook request")
+	}
+}
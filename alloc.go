@@ -0,0 +1,44 @@
+package eal
+
+import (
+	"math/rand"
+	"runtime/metrics"
+)
+
+const (
+	metricHeapAllocs = "/gc/heap/allocs:bytes"
+	metricGCCycles   = "/gc/cycles/total:gc-cycles"
+)
+
+// allocSample hold the allocation counters read when a sampled request started.
+type allocSample struct {
+	samples []metrics.Sample
+}
+
+// startAllocSample return a new allocSample with probability rate, otherwise nil.
+func startAllocSample(rate float64) *allocSample {
+	if rate <= 0 || (rate < 1 && rand.Float64() >= rate) {
+		return nil
+	}
+	as := &allocSample{samples: []metrics.Sample{{Name: metricHeapAllocs}, {Name: metricGCCycles}}}
+	metrics.Read(as.samples)
+	return as
+}
+
+// setFields add the difference between the counters when the sample was started, and now.
+func (as *allocSample) setFields(fields Fields) {
+	now := []metrics.Sample{{Name: metricHeapAllocs}, {Name: metricGCCycles}}
+	metrics.Read(now)
+	for i, s := range now {
+		if s.Value.Kind() != metrics.KindUint64 || as.samples[i].Value.Kind() != metrics.KindUint64 {
+			continue
+		}
+		delta := s.Value.Uint64() - as.samples[i].Value.Uint64()
+		switch s.Name {
+		case metricHeapAllocs:
+			fields[FieldAllocBytesDelta] = delta
+		case metricGCCycles:
+			fields[FieldGCCyclesDelta] = delta
+		}
+	}
+}
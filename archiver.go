@@ -0,0 +1,296 @@
+package eal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload is signed instead of the payload checksum when the payload can't be read before it's sent.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+type (
+	// ObjectStore is implemented by object storage clients that the Archiver can upload log segments to. The body
+	// passed by the Archiver is an io.ReadSeeker of size bytes, so that the store can read it more than once, for
+	// example to checksum it before it's uploaded.
+	ObjectStore interface {
+		PutObject(ctx context.Context, key string, body io.Reader, size int64, metadata map[string]string) error
+	}
+
+	// Archiver gzip rotated log segments and upload them to an ObjectStore. The Archiver don't rotate log files and
+	// isn't triggered by rotation, it's a helper that is called with the segments that have been rotated by something
+	// else, for example from a logrotate postrotate script or a periodic job that call ArchiveGlob.
+	//
+	// The object key is built from Prefix, the UTC date of the segment and the name of the segment, for example:
+	//  logs/2024/05/17/access.log.1.gz
+	//
+	// The date is the modification time of the file, i.e. the date of the last entry that was written to the segment,
+	// so entries from before midnight can be archived under the next date if the segment was rotated after midnight.
+	//
+	// The uploaded objects have the following metadata set:
+	//  sha256         - hex encoded SHA-256 checksum of the uploaded (compressed) object
+	//  source-sha256  - hex encoded SHA-256 checksum of the uncompressed log segment
+	//  source-name    - the file name of the log segment
+	//  source-size    - the size of the uncompressed log segment
+	Archiver struct {
+		Store ObjectStore
+
+		// Prefix is prepended to all object keys.
+		Prefix string
+
+		// RemoveLocal control if the local log segment should be removed after it has been uploaded.
+		RemoveLocal bool
+	}
+
+	// S3Store is an ObjectStore that upload objects using the S3 REST API, signed with AWS Signature Version 4.
+	// Other S3 compatible object stores (for example GCS with HMAC keys, or MinIO) can be used by setting Endpoint.
+	S3Store struct {
+		// Endpoint is the base URL of the object store, for example "https://s3.eu-north-1.amazonaws.com" or
+		// "https://storage.googleapis.com". Path-style addressing is used, i.e. <Endpoint>/<Bucket>/<key>.
+		Endpoint        string
+		Region          string
+		Bucket          string
+		AccessKeyID     string
+		SecretAccessKey string
+
+		// Client is the HTTP client used to upload objects, http.DefaultClient is used if Client is nil.
+		Client *http.Client
+	}
+)
+
+// Archive compress and upload a single log segment. The segment is compressed to a temporary file, and is hashed while
+// it's compressed, so that large segments aren't read into memory.
+func (a *Archiver) Archive(ctx context.Context, segment string) error {
+	f, err := os.Open(segment)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "eal-archive-*.gz")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	srcHash, hash := sha256.New(), sha256.New()
+	zw := gzip.NewWriter(io.MultiWriter(tmp, hash))
+	zw.Name = fi.Name()
+	zw.ModTime = fi.ModTime()
+	n, err := io.Copy(zw, io.TeeReader(f, srcHash))
+	if err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	metadata := map[string]string{
+		"sha256":        hex.EncodeToString(hash.Sum(nil)),
+		"source-sha256": hex.EncodeToString(srcHash.Sum(nil)),
+		"source-name":   fi.Name(),
+		"source-size":   fmt.Sprint(n),
+	}
+
+	key := path.Join(a.Prefix, fi.ModTime().UTC().Format("2006/01/02"), fi.Name()+".gz")
+	if err = a.Store.PutObject(ctx, key, tmp, size, metadata); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", segment, err)
+	}
+
+	if a.RemoveLocal {
+		_ = f.Close()
+		return os.Remove(segment)
+	}
+	return nil
+}
+
+// ArchiveGlob archive all log segments that match the provided pattern (see filepath.Glob). If a segment fail to be
+// archived, ArchiveGlob continue with the next segment, and the first error is returned.
+func (a *Archiver) ArchiveGlob(ctx context.Context, pattern string) error {
+	segments, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, segment := range segments {
+		if err = a.Archive(ctx, segment); err != nil {
+			NewEntry().withError(err).WithFields(Fields{FieldSegment: segment}).Error("failed to archive log segment")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// PutObject implements the ObjectStore interface. If body is an io.ReadSeeker, it's read twice, first to compute the
+// payload checksum that is signed, otherwise the payload is sent unsigned ("UNSIGNED-PAYLOAD"), which require HTTPS.
+func (s *S3Store) PutObject(ctx context.Context, key string, body io.Reader, size int64, metadata map[string]string) error {
+	u, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return err
+	}
+	u.Path = "/" + s.Bucket + "/" + strings.TrimPrefix(key, "/")
+
+	payloadHash := unsignedPayload
+	if rs, ok := body.(io.ReadSeeker); ok {
+		h := sha256.New()
+		if _, err = io.Copy(h, rs); err != nil {
+			return err
+		}
+		if _, err = rs.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		payloadHash = hex.EncodeToString(h.Sum(nil))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+	for k, v := range metadata {
+		req.Header.Set("X-Amz-Meta-"+k, v)
+	}
+	s.sign(req, payloadHash, time.Now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("object store returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// sign add AWS Signature Version 4 headers to the request, payloadHash is the hex encoded SHA-256 checksum of the
+// request body, or "UNSIGNED-PAYLOAD".
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	crSum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode percent-encode all bytes of s except the unreserved characters, as required by Signature Version 4. Slashes
+// are also encoded if encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '.' || ch == '_' || ch == '~' || (ch == '/' && !encodeSlash) {
+			sb.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", ch)
+		}
+	}
+	return sb.String()
+}
+
+// canonicalQuery return the query parameters encoded with uriEncode, and sorted by name and value.
+func canonicalQuery(query url.Values) string {
+	var params [][2]string
+	for k, values := range query {
+		for _, v := range values {
+			params = append(params, [2]string{uriEncode(k, true), uriEncode(v, true)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+
+	var sb strings.Builder
+	for i, p := range params {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(p[0] + "=" + p[1])
+	}
+	return sb.String()
+}
@@ -0,0 +1,119 @@
+package eal
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// BaggageHeader is the header used to propagate log fields between services, see PropagateFields.
+	BaggageHeader = "X-Eal-Baggage"
+
+	maxBaggageSize = 8192
+)
+
+var (
+	propagatedFieldsMu sync.RWMutex
+	propagatedFields   = map[string]struct{}{}
+)
+
+type baggageTransport struct {
+	next http.RoundTripper
+}
+
+// PropagateFields configure log fields that should follow a request across services. On outbound requests sent with a
+// transport created by NewTransport, the fields are read from the request context and serialized into the
+// X-Eal-Baggage header. The logger middlewares of the receiving service add the fields from the header to the log
+// fields of the request, which in turn make them propagate to the next service.
+//
+// Only fields that have been configured with PropagateFields are accepted from the header, so all services should
+// configure the same fields:
+//
+//	eal.PropagateFields("tenant", "session_id")
+func PropagateFields(names ...string) {
+	propagatedFieldsMu.Lock()
+	defer propagatedFieldsMu.Unlock()
+	for _, name := range names {
+		propagatedFields[name] = struct{}{}
+	}
+}
+
+// NewTransport wrap a http.RoundTripper, and add the X-Eal-Baggage header with the propagated fields (see
+// PropagateFields) from the request context to outbound requests. If next is nil, http.DefaultTransport is used.
+//
+//	client := &http.Client{Transport: eal.NewTransport(nil)}
+//	req, _ := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, url, nil)
+func NewTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &baggageTransport{next: next}
+}
+
+func (t *baggageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	baggage := encodeBaggage(contextFields(req.Context()))
+	if baggage == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set(BaggageHeader, baggage)
+	return t.next.RoundTrip(req)
+}
+
+// encodeBaggage return the propagated fields as a comma separated list of URL encoded key=value pairs.
+func encodeBaggage(fields Fields) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	propagatedFieldsMu.RLock()
+	defer propagatedFieldsMu.RUnlock()
+
+	pairs := make([]string, 0, len(propagatedFields))
+	for name := range propagatedFields {
+		if v, ok := fields[name]; ok {
+			pairs = append(pairs, url.QueryEscape(name)+"="+url.QueryEscape(fmt.Sprint(v)))
+		}
+	}
+	sort.Strings(pairs)
+
+	baggage := strings.Join(pairs, ",")
+	if len(baggage) > maxBaggageSize {
+		return ""
+	}
+	return baggage
+}
+
+// baggageFields add the propagated fields in the X-Eal-Baggage header to the log fields.
+func baggageFields(h http.Header, fields Fields) {
+	baggage := h.Get(BaggageHeader)
+	if baggage == "" || len(baggage) > maxBaggageSize {
+		return
+	}
+
+	propagatedFieldsMu.RLock()
+	defer propagatedFieldsMu.RUnlock()
+
+	for _, pair := range strings.Split(baggage, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			continue
+		}
+		if _, ok = propagatedFields[name]; !ok {
+			continue
+		}
+		if value, err := url.QueryUnescape(v); err == nil {
+			setField(fields, name, value)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package eal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestPropagateFields(t *testing.T) {
+	PropagateFields("tenant", "session_id")
+	t.Cleanup(func() {
+		propagatedFieldsMu.Lock()
+		propagatedFields = map[string]struct{}{}
+		propagatedFieldsMu.Unlock()
+	})
+	entries := captureLog(t)
+
+	// Downstream service, that log the propagated fields
+	downstream := httptest.NewServer(CreateHTTPLoggerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer downstream.Close()
+
+	// Upstream service, that call the downstream service
+	var sentBaggage string
+	client := &http.Client{Transport: NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sentBaggage = req.Header.Get(BaggageHeader)
+		return http.DefaultTransport.RoundTrip(req)
+	}))}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(BaggageHeader, "tenant=acme,injected=x")
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		AddContextFields(c, Fields{"session_id": "s 1", "user_id": "u1"})
+		out, _ := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, downstream.URL, nil)
+		res, err := client.Do(out)
+		if err != nil {
+			return err
+		}
+		_ = res.Body.Close()
+		return c.NoContent(http.StatusOK)
+	})
+
+	if want := "session_id=s+1,tenant=acme"; sentBaggage != want {
+		t.Errorf("got %s: %q, want: %q", BaggageHeader, sentBaggage, want)
+	}
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	for _, e := range logged {
+		if e["tenant"] != "acme" {
+			t.Errorf("got tenant: %v, want: acme", e["tenant"])
+		}
+		if _, ok := e["injected"]; ok {
+			t.Error("got field that isn't propagated from the baggage header")
+		}
+	}
+	// logged[0] is the downstream entry, since it's written before the upstream request complete
+	if logged[0]["session_id"] != "s 1" || logged[0]["user_id"] != nil {
+		t.Errorf("got downstream entry: %v, want session_id but no user_id", logged[0])
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,128 @@
+package eal
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultBodyContentTypes is the content types of the bodies that are logged if BodyLogConfig.ContentTypes is empty.
+var DefaultBodyContentTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"text/",
+}
+
+type (
+	// BodyLogConfig configure logging of request and response bodies, see LoggerConfig.BodyLog.
+	BodyLogConfig struct {
+		// Request enable the request_body field, that hold the part of the request body that the handler have read.
+		Request bool
+
+		// Response enable the response_body field.
+		Response bool
+
+		// MaxBytes is the maximum number of bytes that is logged per body, 4096 is used if not set. The
+		// request_body_truncated and response_body_truncated fields are set if a body is larger than MaxBytes.
+		MaxBytes int
+
+		// ContentTypes is the content types of the bodies that are logged. An entry that end with "/" match all
+		// subtypes, for example "text/". DefaultBodyContentTypes is used if not set.
+		ContentTypes []string
+
+		// Redact is called with the field name (request_body or response_body), the content type and the captured
+		// body, and return the body that is logged. It can be used to remove passwords, tokens and personal data.
+		Redact func(field, contentType string, body []byte) []byte
+	}
+
+	// bodyCapture capture the first bytes of a request or response body.
+	bodyCapture struct {
+		field     string
+		buf       []byte
+		max       int
+		truncated bool
+	}
+
+	// capturingReader capture the request body as it's read by the handler.
+	capturingReader struct {
+		io.ReadCloser
+		bodyCapture
+	}
+
+	// capturingWriter capture the response body as it's written by the handler.
+	capturingWriter struct {
+		http.ResponseWriter
+		bodyCapture
+	}
+)
+
+func (bc *bodyCapture) capture(p []byte) {
+	if room := bc.max - len(bc.buf); room < len(p) {
+		p = p[:room]
+		bc.truncated = true
+	}
+	bc.buf = append(bc.buf, p...)
+}
+
+func (cr *capturingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.capture(p[:n])
+	return n, err
+}
+
+func (cw *capturingWriter) Write(b []byte) (int, error) {
+	n, err := cw.ResponseWriter.Write(b)
+	cw.capture(b[:n])
+	return n, err
+}
+
+// Unwrap return the wrapped http.ResponseWriter, used by http.ResponseController.
+func (cw *capturingWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// newCapture return a bodyCapture for the field, limited to MaxBytes.
+func (bc BodyLogConfig) newCapture(field string) bodyCapture {
+	max := bc.MaxBytes
+	if max <= 0 {
+		max = 4096
+	}
+	return bodyCapture{field: field, max: max}
+}
+
+// loggable report if bodies of the content type should be logged.
+func (bc BodyLogConfig) loggable(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	allowed := bc.ContentTypes
+	if len(allowed) == 0 {
+		allowed = DefaultBodyContentTypes
+	}
+	for _, ct := range allowed {
+		ct = strings.ToLower(ct)
+		if mediaType == ct || strings.HasSuffix(ct, "/") && strings.HasPrefix(mediaType, ct) {
+			return true
+		}
+	}
+	return false
+}
+
+// setBodyField add the captured body to the log fields, if the content type is allowed.
+func (bc BodyLogConfig) setBodyField(fields Fields, contentType string, capture *bodyCapture) {
+	if len(capture.buf) == 0 || !bc.loggable(contentType) {
+		return
+	}
+	body := capture.buf
+	if bc.Redact != nil {
+		body = bc.Redact(capture.field, contentType, body)
+	}
+	fields[capture.field] = string(body)
+	if capture.truncated {
+		fields[capture.field+"_truncated"] = true
+	}
+}
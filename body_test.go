@@ -0,0 +1,80 @@
+package eal
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestBodyLog(t *testing.T) {
+	redact := func(field, contentType string, body []byte) []byte {
+		return bytes.ReplaceAll(body, []byte("secret"), []byte("***"))
+	}
+
+	for _, tt := range []struct {
+		name        string
+		config      BodyLogConfig
+		contentType string
+		reqBody     string
+		resBody     string
+		want        map[string]interface{}
+		wantMissing []string
+	}{
+		{
+			name:        "request_and_response",
+			config:      BodyLogConfig{Request: true, Response: true},
+			contentType: echo.MIMEApplicationJSON,
+			reqBody:     `{"name":"droid"}`,
+			resBody:     `{"id":1}`,
+			want:        map[string]interface{}{FieldRequestBody: `{"name":"droid"}`, FieldResponseBody: `{"id":1}`},
+		},
+		{
+			name:        "truncated_and_redacted",
+			config:      BodyLogConfig{Request: true, MaxBytes: 20, Redact: redact},
+			contentType: echo.MIMEApplicationJSON,
+			reqBody:     `{"password":"secret","name":"droid"}`,
+			want:        map[string]interface{}{FieldRequestBody: `{"password":"***"`, FieldRequestBodyTruncated: true},
+			wantMissing: []string{FieldResponseBody},
+		},
+		{
+			name:        "content_type_not_allowed",
+			config:      BodyLogConfig{Request: true, Response: true},
+			contentType: echo.MIMEOctetStream,
+			reqBody:     "binary",
+			resBody:     "binary",
+			wantMissing: []string{FieldRequestBody, FieldResponseBody},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLog(t)
+			req := httptest.NewRequest(http.MethodPost, "/droids", strings.NewReader(tt.reqBody))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{BodyLog: tt.config}), req, func(c echo.Context) error {
+				if b, _ := io.ReadAll(c.Request().Body); string(b) != tt.reqBody {
+					t.Errorf("handler got body: %q, want: %q", b, tt.reqBody)
+				}
+				return c.Blob(http.StatusOK, tt.contentType, []byte(tt.resBody))
+			})
+
+			logged := entries()
+			if len(logged) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(logged))
+			}
+			for k, want := range tt.want {
+				if logged[0][k] != want {
+					t.Errorf("got %s: %v, want: %v", k, logged[0][k], want)
+				}
+			}
+			for _, k := range tt.wantMissing {
+				if v, ok := logged[0][k]; ok {
+					t.Errorf("got %s: %v, want no field", k, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package eal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// LogBudget is a limit of the log volume, see SetLogBudget.
+	LogBudget struct {
+		// BytesPerMinute is the number of bytes that can be written per minute before entries are dropped.
+		BytesPerMinute int
+
+		// InfoSampleRate is the rate that info entries are sampled with when the budget is exceeded, i.e. every
+		// InfoSampleRate:th info entry is written. 10 is used if not set.
+		InfoSampleRate int
+	}
+
+	// budgetFormatter wrap the logrus formatter, and drop formatted entries when the budget is exceeded.
+	budgetFormatter struct {
+		next   logrus.Formatter
+		budget LogBudget
+
+		mu          sync.Mutex
+		windowStart time.Time
+		used        int
+		infoCount   int
+		dropped     map[string]int
+		droppedSize int
+		decisions   dropDecisions
+		pending     []byte
+	}
+)
+
+// SetLogBudget limit the number of bytes that the logrus logger write per minute, to protect against a bugged loop
+// exploding the log volume. When 80% of the budget have been used, debug and trace entries are dropped. When the whole
+// budget is used, info entries are also sampled (see LogBudget.InfoSampleRate). Warnings and errors are never dropped.
+//
+// A log_budget_exceeded warning, with the number of dropped entries per level, is written before the first entry of
+// the next minute.
+//
+// SetLogBudget wrap the current logrus formatter, so it must be called after Init (or InitMsgpack etc.). A
+// BytesPerMinute of zero remove the budget.
+func SetLogBudget(b LogBudget) {
+	f := logrus.StandardLogger().Formatter
+	if bf, ok := f.(*budgetFormatter); ok {
+		f = bf.next
+	}
+	installHook()
+	defer func() { updateEntryDroppers(logrus.StandardLogger().Formatter) }()
+	if b.BytesPerMinute <= 0 {
+		logrus.SetFormatter(f)
+		return
+	}
+	if b.InfoSampleRate <= 0 {
+		b.InfoSampleRate = 10
+	}
+	logrus.SetFormatter(&budgetFormatter{next: f, budget: b, dropped: map[string]int{}})
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *budgetFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b, err := f.next.Format(entry)
+	if err != nil {
+		return b, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	drop, ok := f.decisions.take(entry)
+	if !ok {
+		drop = f.decide(entry)
+	}
+	summary := f.pending
+	f.pending = nil
+
+	if drop {
+		f.dropped[entry.Level.String()]++
+		f.droppedSize += len(b)
+		return summary, nil
+	}
+	f.used += len(b)
+
+	if summary == nil {
+		return b, nil
+	}
+	return append(summary, b...), nil
+}
+
+// dropEntry implements the entryDropper interface.
+func (f *budgetFormatter) dropEntry(entry *logrus.Entry, dropped bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	drop := !dropped && f.decide(entry)
+	f.decisions.set(entry, drop)
+	return drop
+}
+
+// decide start a new budget window if the previous have passed, and report if the entry should be dropped. The
+// summary of the previous window is written before the next entry. f.mu must be held.
+func (f *budgetFormatter) decide(entry *logrus.Entry) bool {
+	if now := entry.Time; now.Sub(f.windowStart) >= time.Minute || now.Before(f.windowStart) {
+		f.pending = append(f.pending, f.summary(now)...)
+		f.windowStart = now.Truncate(time.Minute)
+		f.used = 0
+		f.infoCount = 0
+	}
+	return f.drop(entry.Level)
+}
+
+// drop report if an entry with the level should be dropped.
+func (f *budgetFormatter) drop(level logrus.Level) bool {
+	switch {
+	case level <= logrus.WarnLevel:
+		return false
+	case f.used >= f.budget.BytesPerMinute && level == logrus.InfoLevel:
+		f.infoCount++
+		return (f.infoCount-1)%f.budget.InfoSampleRate != 0
+	case f.used >= f.budget.BytesPerMinute*8/10:
+		return level > logrus.InfoLevel
+	default:
+		return false
+	}
+}
+
+// summary return the formatted log_budget_exceeded entry for the previous window, or nil if no entries were dropped.
+func (f *budgetFormatter) summary(now time.Time) []byte {
+	if len(f.dropped) == 0 {
+		return nil
+	}
+
+	dropped := make(map[string]interface{}, len(f.dropped))
+	for level, n := range f.dropped {
+		dropped[level] = n
+	}
+	entry := logrus.NewEntry(logrus.StandardLogger()).WithFields(logrus.Fields{
+		FieldBudgetBytesPerMinute: f.budget.BytesPerMinute,
+		FieldDroppedEntries:       dropped,
+		FieldDroppedBytes:         f.droppedSize,
+		FieldWindow:               f.windowStart.Format(time.RFC3339),
+	})
+	entry.Time = now
+	entry.Level = logrus.WarnLevel
+	entry.Message = "log_budget_exceeded"
+	sequenceEntry(entry.Data)
+
+	f.dropped = map[string]int{}
+	f.droppedSize = 0
+
+	b, err := f.next.Format(entry)
+	if err != nil {
+		return nil
+	}
+	// The formatter may reuse the buffer of the entry, so the summary is copied
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,52 @@
+package eal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetLogBudget(t *testing.T) {
+	entries := captureLog(t)
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	t.Cleanup(func() { logrus.SetLevel(level) })
+	SetLogBudget(LogBudget{BytesPerMinute: 1000, InfoSampleRate: 5})
+	defer SetLogBudget(LogBudget{})
+
+	start := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	log := func(l logrus.Level, at time.Time) {
+		logrus.NewEntry(logrus.StandardLogger()).WithTime(at).Log(l, "a message that is about a hundred bytes long when formatted")
+	}
+	for i := 0; i < 50; i++ {
+		log(logrus.DebugLevel, start)
+		log(logrus.InfoLevel, start)
+		log(logrus.ErrorLevel, start)
+	}
+	log(logrus.InfoLevel, start.Add(time.Minute))
+
+	counts := map[string]int{}
+	var summary map[string]interface{}
+	for _, e := range entries() {
+		if e["msg"] == "log_budget_exceeded" {
+			summary = e
+			continue
+		}
+		counts[e["level"].(string)]++
+	}
+
+	if counts["error"] != 50 {
+		t.Errorf("got %d error entries, want 50", counts["error"])
+	}
+	if counts["debug"] == 0 || counts["debug"] >= counts["info"] || counts["info"] >= 50 {
+		t.Errorf("got %d debug and %d info entries, want debug to be dropped before info is sampled", counts["debug"], counts["info"])
+	}
+	if summary == nil {
+		t.Fatal("got no log_budget_exceeded entry")
+	}
+	dropped, _ := summary[FieldDroppedEntries].(map[string]interface{})
+	if int(dropped["debug"].(float64)) != 50-counts["debug"] || int(dropped["info"].(float64)) != 51-counts["info"] {
+		t.Errorf("got %s: %v, want the number of dropped entries per level", FieldDroppedEntries, dropped)
+	}
+}
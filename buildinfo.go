@@ -0,0 +1,83 @@
+package eal
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// LogBuildInfo control if all log entries should have the build and runtime fields returned by BuildInfoFields, which
+// make it possible to tell replicas and deployed versions apart when many instances ship logs to the same backend.
+// Like global fields (see SetGlobalFields), the fields are only added to entries that don't already have them.
+var LogBuildInfo bool
+
+var (
+	buildInfoOnce   sync.Once
+	buildInfoFields Fields
+)
+
+// BuildInfoFields return the hostname, pid and go_version fields, and the module, module_version, vcs_revision,
+// vcs_time and vcs_modified fields from the build info of the binary (see runtime/debug.ReadBuildInfo), if they are
+// available. The fields are resolved the first time BuildInfoFields is called, and a copy is returned.
+func BuildInfoFields() Fields {
+	bi := cachedBuildInfo()
+	fields := make(Fields, len(bi))
+	for k, v := range bi {
+		fields[k] = v
+	}
+	return fields
+}
+
+// cachedBuildInfo return the build info fields, that are resolved on the first call.
+func cachedBuildInfo() Fields {
+	buildInfoOnce.Do(func() {
+		buildInfoFields = readBuildInfo()
+	})
+	return buildInfoFields
+}
+
+// readBuildInfo resolve the fields of BuildInfoFields.
+func readBuildInfo() Fields {
+	fields := Fields{
+		FieldPID:       os.Getpid(),
+		FieldGoVersion: runtime.Version(),
+	}
+	if host, err := os.Hostname(); err == nil {
+		fields[FieldHostname] = host
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fields
+	}
+	if bi.Main.Path != "" {
+		fields[FieldModule] = bi.Main.Path
+	}
+	if bi.Main.Version != "" {
+		fields[FieldModuleVersion] = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			fields[FieldVCSRevision] = s.Value
+		case "vcs.time":
+			fields[FieldVCSTime] = s.Value
+		case "vcs.modified":
+			fields[FieldVCSModified] = s.Value == "true"
+		}
+	}
+	return fields
+}
+
+// addBuildInfo add the build info fields that aren't already set in data, if LogBuildInfo is enabled.
+func addBuildInfo(data map[string]interface{}) {
+	if !LogBuildInfo {
+		return
+	}
+	for k, v := range cachedBuildInfo() {
+		if _, ok := data[k]; !ok {
+			data[k] = v
+		}
+	}
+}
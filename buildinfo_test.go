@@ -0,0 +1,38 @@
+package eal
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestLogBuildInfo(t *testing.T) {
+	entries := captureLog(t)
+	LogBuildInfo = true
+	SetGlobalFields(Fields{FieldHostname: "web-1"})
+	t.Cleanup(func() {
+		LogBuildInfo = false
+		SetGlobalFields(nil)
+	})
+
+	NewEntry().Info("hello")
+	LogBuildInfo = false
+	NewEntry().Info("without build info")
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0][FieldPID] != float64(os.Getpid()) || logged[0][FieldGoVersion] != runtime.Version() {
+		t.Errorf("got entry: %v, want pid and go_version", logged[0])
+	}
+	if logged[0][FieldHostname] != "web-1" {
+		t.Errorf("got hostname: %v, want the global field to take precedence", logged[0][FieldHostname])
+	}
+	if _, ok := logged[1][FieldPID]; ok {
+		t.Errorf("got entry: %v, want no build info", logged[1])
+	}
+	if fields := BuildInfoFields(); fields[FieldGoVersion] != runtime.Version() {
+		t.Errorf("got fields: %v, want go_version", fields)
+	}
+}
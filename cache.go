@@ -0,0 +1,34 @@
+package eal
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Cache statuses, see MarkCache.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheStale = "stale"
+)
+
+// MarkCache record if the response of the request was served from a cache, in the cache_status field, and the
+// remaining time to live of the cached entry, in whole seconds, in the cache_ttl field. status should be one of
+// CacheHit, CacheMiss or CacheStale. The ttl is only logged if it's positive.
+//
+//	if v, ttl, ok := cache.Get(key); ok {
+//	  eal.MarkCache(c, eal.CacheHit, ttl)
+//	  return c.JSON(http.StatusOK, v)
+//	}
+//	eal.MarkCache(c, eal.CacheMiss, 0)
+//
+// Reverse proxies and upstreams that can't call MarkCache can set the X-Eal-Field-Cache-Status response header, see
+// FieldHeaderPrefix.
+func MarkCache(c echo.Context, status string, ttl time.Duration) {
+	fields := Fields{FieldCacheStatus: status}
+	if ttl > 0 {
+		fields[FieldCacheTTL] = int64(ttl / time.Second)
+	}
+	AddContextFields(c, fields)
+}
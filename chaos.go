@@ -0,0 +1,122 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrChaos is the error returned by handlers when a synthetic failure is injected by the chaos middleware.
+var ErrChaos = errors.New("eal: chaos injected failure")
+
+type (
+	// ChaosConfig configure the chaos middleware, see CreateChaosMiddleware.
+	ChaosConfig struct {
+		// Enabled must be set for the middleware to inject any failures. It's intended to be set from test or staging
+		// environment configuration, and should never be set in production.
+		Enabled bool
+
+		// Rules is evaluated in order, and the first matching rule is applied to the request.
+		Rules []ChaosRule
+	}
+
+	// ChaosRule describe when, and what kind of failure to inject.
+	ChaosRule struct {
+		// Route is the echo route path (for example "/users/:id") that the rule apply to, all routes match if Route
+		// is empty.
+		Route string
+
+		// Fields is a set of log fields that must exist in the request log context with the same values for the
+		// rule to match, for example Fields{"user-id": "chaos-test-user"}.
+		Fields Fields
+
+		// Probability (0-1) that a matching request get a failure injected, 0 is treated as 1 (always inject).
+		Probability float64
+
+		// Delay is added before the request is handled.
+		Delay time.Duration
+
+		// Status, if set, make the middleware return a synthetic error with the status code instead of calling the
+		// handler.
+		Status int
+	}
+)
+
+// CreateChaosMiddleware return an echo middleware that inject delays and synthetic errors into requests, to be able to
+// test the resilience of a service and its callers. Requests that get a failure injected are logged with
+// chaos_injected=true, so that injected failures can be told apart from real failures in the access log.
+//
+// The middleware must be registered after the middleware returned by CreateLoggerMiddleware, and does nothing unless
+// ChaosConfig.Enabled is set.
+func CreateChaosMiddleware(config ChaosConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if !config.Enabled {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			rule := config.match(c)
+			if rule == nil {
+				return next(c)
+			}
+
+			fields := Fields{FieldChaosInjected: true}
+			if rule.Delay > 0 {
+				fields[FieldChaosDelayMs] = int64(rule.Delay / time.Millisecond)
+			}
+			if rule.Status != 0 {
+				fields[FieldChaosStatus] = rule.Status
+			}
+			AddContextFields(c, fields)
+
+			if rule.Delay > 0 {
+				t := time.NewTimer(rule.Delay)
+				select {
+				case <-t.C:
+				case <-c.Request().Context().Done():
+					t.Stop()
+					return c.Request().Context().Err()
+				}
+			}
+
+			if rule.Status != 0 {
+				return NewHTTPError(fmt.Errorf("%w: status %d", ErrChaos, rule.Status), rule.Status)
+			}
+			return next(c)
+		}
+	}
+}
+
+// match return the first rule that match the request, if the rule is selected by its probability.
+func (cc ChaosConfig) match(c echo.Context) *ChaosRule {
+	var logFields Fields
+	if rf, ok := c.Get(contextName).(*requestFields); ok {
+		logFields = rf.snapshot()
+	}
+	for i := range cc.Rules {
+		r := &cc.Rules[i]
+		if r.Route != "" && r.Route != c.Path() {
+			continue
+		}
+		if !fieldsMatch(logFields, r.Fields) {
+			continue
+		}
+		if r.Probability > 0 && rand.Float64() >= r.Probability {
+			return nil
+		}
+		return r
+	}
+	return nil
+}
+
+func fieldsMatch(fields, want Fields) bool {
+	for k, v := range want {
+		if fv, ok := fields[k]; !ok || fmt.Sprint(fv) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
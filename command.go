@@ -0,0 +1,150 @@
+package eal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// CommandSecretFlags match the names of command line flags with secret values, that are redacted when commands are
+	// logged by Command. Both "--password=secret" and "--password secret" are redacted.
+	CommandSecretFlags = regexp.MustCompile(`(?i)^--?[\w\-]*(pass|secret|token|key|credential)[\w\-]*$`)
+
+	// CommandStderrMaxBytes is the number of bytes at the end of stderr that are logged by Command.
+	CommandStderrMaxBytes = 4096
+)
+
+// CommandError is returned by Command when the command fail. It's logged with the command, exit_code, duration_ms and
+// stderr fields.
+type CommandError struct {
+	err    error
+	fields Fields
+}
+
+// Command run the command, and log it with the (redacted) command line, exit code, duration and the end of stderr as
+// structured fields. Failed commands are logged with error level, and the error is wrapped by Trace and returned as a
+// CommandError. Successful commands are logged with debug level. Log fields stored in ctx, see WithFields, are added
+// to the log entry.
+//
+//	err := eal.Command(ctx, exec.CommandContext(ctx, "pg_dump", "--dbname", dsn, "--file", path))
+//
+// Stderr is still written to cmd.Stderr, if it's set.
+func Command(ctx context.Context, cmd *exec.Cmd) error {
+	stderr := &tailBuffer{max: CommandStderrMaxBytes}
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	} else {
+		cmd.Stderr = stderr
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	fields := Fields{
+		FieldCommand:    strings.Join(redactArgs(cmd.Args), " "),
+		FieldExitCode:   -1,
+		FieldDurationMs: int64(time.Since(start) / time.Millisecond),
+	}
+	if cmd.ProcessState != nil {
+		fields[FieldExitCode] = cmd.ProcessState.ExitCode()
+	}
+	if stderr.Len() > 0 {
+		fields[FieldStderr] = stderr.String()
+	}
+
+	entry := NewEntry().WithFields(FieldsFromContext(ctx))
+	if err == nil {
+		entry.WithFields(fields).Debug("command")
+		return nil
+	}
+
+	err = &CommandError{err: Trace(err), fields: fields}
+	entry.WithError(err).Error("command failed")
+	return err
+}
+
+// Error return the message of the wrapped error.
+func (ce *CommandError) Error() string {
+	return ce.err.Error()
+}
+
+// Unwrap return the wrapped error.
+func (ce *CommandError) Unwrap() error {
+	return ce.err
+}
+
+// ExitCode return the exit code of the command, or -1 if the command didn't start or was killed by a signal.
+func (ce *CommandError) ExitCode() int {
+	var ee *exec.ExitError
+	if errors.As(ce.err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
+
+// SetLogFields is used by Entry.WithError to populate log fields.
+func (ce *CommandError) SetLogFields(logFields map[string]interface{}) {
+	for k, v := range ce.fields {
+		logFields[k] = v
+	}
+}
+
+// redactArgs return a copy of the command line arguments, with secret flag values and URL passwords redacted.
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	secretNext := false
+	for i, arg := range args {
+		switch {
+		case secretNext:
+			arg = RedactedValue
+			secretNext = false
+		case strings.HasPrefix(arg, "-"):
+			name, _, hasValue := strings.Cut(arg, "=")
+			if CommandSecretFlags.MatchString(name) {
+				if hasValue {
+					arg = name + "=" + RedactedValue
+				} else {
+					secretNext = true
+				}
+			}
+		case strings.Contains(arg, "://"):
+			if u, err := url.Parse(arg); err == nil && u.User != nil {
+				if _, ok := u.User.Password(); ok {
+					user := url.User(u.User.Username()).String()
+					arg = strings.Replace(arg, u.User.String()+"@", user+":"+RedactedValue+"@", 1)
+				}
+			}
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+// tailBuffer keep the last max bytes written to it. The buffer isn't embedded, since io.Copy would use its ReadFrom
+// method and bypass the truncation.
+type tailBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+	n, _ := tb.buf.Write(p)
+	if over := tb.buf.Len() - tb.max; over > 0 {
+		tb.buf.Next(over)
+	}
+	return n, nil
+}
+
+func (tb *tailBuffer) Len() int {
+	return tb.buf.Len()
+}
+
+func (tb *tailBuffer) String() string {
+	return tb.buf.String()
+}
@@ -0,0 +1,70 @@
+package eal
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+	CommandStderrMaxBytes = 8
+	t.Cleanup(func() { CommandStderrMaxBytes = 4096 })
+	entries := captureLog(t)
+
+	ctx := WithFields(context.Background(), Fields{"job": "backup"})
+	err := Command(ctx, exec.Command("sh", "-c", "echo 'connection refused' >&2; exit 3", "--password", "hunter2"))
+	ce, ok := err.(*CommandError)
+	if !ok {
+		t.Fatalf("got error: %T, want *CommandError", err)
+	}
+	if ce.ExitCode() != 3 {
+		t.Errorf("got exit code: %d, want: 3", ce.ExitCode())
+	}
+	if _, ok := GetErrorStackTrace(err); !ok {
+		t.Error("got error without stacktrace")
+	}
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	for k, want := range map[string]interface{}{
+		"job":         "backup",
+		"level":       "error",
+		FieldExitCode: float64(3),
+		FieldStderr:   "refused\n",
+		FieldCommand:  "sh -c echo 'connection refused' >&2; exit 3 --password " + RedactedValue,
+	} {
+		if logged[0][k] != want {
+			t.Errorf("got %s: %v, want: %v", k, logged[0][k], want)
+		}
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"mysql", "-u", "root", "--password=hunter2", "db"},
+			want: []string{"mysql", "-u", "root", "--password=" + RedactedValue, "db"},
+		},
+		{
+			args: []string{"curl", "--api-key", "abc", "-v"},
+			want: []string{"curl", "--api-key", RedactedValue, "-v"},
+		},
+		{
+			args: []string{"psql", "postgres://admin:hunter2@db:5432/app"},
+			want: []string{"psql", "postgres://admin:" + RedactedValue + "@db:5432/app"},
+		},
+	} {
+		if got := redactArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got: %q, want: %q", got, tt.want)
+		}
+	}
+}
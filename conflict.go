@@ -0,0 +1,133 @@
+package eal
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ConflictPolicy decide what happens when a log field is set to a new value, and the field already have a different
+// value. See FieldConflictPolicy.
+type ConflictPolicy int
+
+const (
+	// ConflictOverride replace the existing value with the new value (last write wins). This is the default policy.
+	ConflictOverride ConflictPolicy = iota
+
+	// ConflictKeepFirst keep the existing value, and ignore the new value (first write wins).
+	ConflictKeepFirst
+
+	// ConflictSuffix keep the existing value, and store the new value with a numbered suffix, i.e. if "tenant" is
+	// already set, the new value is stored in "tenant_2", then "tenant_3" and so on.
+	ConflictSuffix
+
+	// ConflictCollect keep all the different values of the field, in a CollectedValues array in the order they were
+	// written.
+	ConflictCollect
+)
+
+// CollectedValues hold the different values of a log field, written with the ConflictCollect policy.
+type CollectedValues []interface{}
+
+var (
+	// FieldConflictPolicy is the policy used when Entry.WithFields, AddContextFields or WithFields set a log field
+	// that already have a different value.
+	//
+	// Fields can be set on several levels, with the following precedence (the last one is written last):
+	//  global   - fields set by SetGlobalFields, only added to log entries that don't already have the field
+	//  route    - fields set by the RouteOptions of the route (see SetRouteOptions), only added to the access log
+	//             entries of the route that don't already have the field
+	//  context  - fields set by ContextLogFuncs, AddContextFields or WithFields(ctx, ...)
+	//  entry    - fields set directly on the log entry by Entry.WithFields or Entry.WithError
+	// Within the context and entry levels, FieldConflictPolicy decide what happens when a field is written again.
+	FieldConflictPolicy = ConflictOverride
+
+	// ErrorFieldConflictPolicy is the policy used when more than one error in an error chain set the same log field,
+	// see UnwrapError. With the default policy, ConflictOverride, the fields of wrapped errors overwrite the fields of
+	// the errors that wrap them. Field names can also be namespaced per error type, see RegisterErrorFieldPrefix.
+	ErrorFieldConflictPolicy = ConflictOverride
+
+	// LogFieldConflicts make eal write a warning log entry each time a field conflict is detected, which can be used
+	// to find out which code is overwriting a field.
+	LogFieldConflicts bool
+
+	globalFieldsMu sync.RWMutex
+	globalFields   Fields
+)
+
+// SetGlobalFields set fields that are added to all log entries, for example service name or environment. Global
+// fields have the lowest precedence, and are only added to entries that don't already have the field.
+func SetGlobalFields(fields Fields) {
+	installHook()
+	gf := make(Fields, len(fields))
+	for k, v := range fields {
+		gf[k] = v
+	}
+	globalFieldsMu.Lock()
+	globalFields = gf
+	globalFieldsMu.Unlock()
+}
+
+// addGlobalFields add the global fields that aren't already set in data.
+func addGlobalFields(data map[string]interface{}) {
+	globalFieldsMu.RLock()
+	defer globalFieldsMu.RUnlock()
+	for k, v := range globalFields {
+		if _, ok := data[k]; !ok {
+			data[k] = v
+		}
+	}
+}
+
+// setField set a log field, applying the FieldConflictPolicy if the field already have a different value.
+func setField(fields map[string]interface{}, k string, v interface{}) {
+	setFieldWithPolicy(fields, k, v, FieldConflictPolicy)
+}
+
+// setFieldWithPolicy set a log field, applying the policy if the field already have a different value.
+func setFieldWithPolicy(fields map[string]interface{}, k string, v interface{}, policy ConflictPolicy) {
+	old, exists := fields[k]
+	if !exists || policy == ConflictOverride && !LogFieldConflicts {
+		fields[k] = v
+		return
+	}
+	if reflect.DeepEqual(old, v) {
+		return
+	}
+
+	target, stored := k, v
+	switch policy {
+	case ConflictKeepFirst:
+		target = ""
+	case ConflictCollect:
+		collected, ok := old.(CollectedValues)
+		if !ok {
+			collected = CollectedValues{old}
+		}
+		for _, c := range collected {
+			if reflect.DeepEqual(c, v) {
+				return
+			}
+		}
+		stored = append(collected, v)
+	case ConflictSuffix:
+		for i := 2; ; i++ {
+			target = fmt.Sprintf("%s_%d", k, i)
+			if _, ok := fields[target]; !ok {
+				break
+			}
+		}
+	}
+	if target != "" {
+		fields[target] = stored
+	}
+
+	if LogFieldConflicts {
+		NewEntry().WithFields(Fields{
+			FieldConflictField:    k,
+			FieldConflictOldValue: fmt.Sprint(old),
+			FieldConflictNewValue: fmt.Sprint(v),
+			FieldConflictStoredAs: target,
+		}).Warn("field_conflict")
+	}
+}
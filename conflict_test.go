@@ -0,0 +1,51 @@
+package eal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetField(t *testing.T) {
+	defer func() { FieldConflictPolicy = ConflictOverride }()
+
+	for _, tt := range []struct {
+		policy ConflictPolicy
+		want   map[string]interface{}
+	}{
+		{policy: ConflictOverride, want: map[string]interface{}{"tenant": "lib"}},
+		{policy: ConflictKeepFirst, want: map[string]interface{}{"tenant": "app"}},
+		{policy: ConflictSuffix, want: map[string]interface{}{"tenant": "app", "tenant_2": "lib", "tenant_3": "other"}},
+		{policy: ConflictCollect, want: map[string]interface{}{"tenant": CollectedValues{"app", "lib", "other"}}},
+	} {
+		FieldConflictPolicy = tt.policy
+		fields := map[string]interface{}{}
+		setField(fields, "tenant", "app")
+		setField(fields, "tenant", "app")
+		setField(fields, "tenant", "lib")
+		if tt.policy == ConflictSuffix || tt.policy == ConflictCollect {
+			setField(fields, "tenant", "other")
+		}
+		if tt.policy == ConflictCollect {
+			setField(fields, "tenant", "lib")
+		}
+		if !reflect.DeepEqual(fields, tt.want) {
+			t.Errorf("policy %d: got: %v, want: %v", tt.policy, fields, tt.want)
+		}
+	}
+}
+
+func TestGlobalFields(t *testing.T) {
+	entries := captureLog(t)
+	SetGlobalFields(Fields{"service": "users", "env": "test"})
+	defer SetGlobalFields(nil)
+
+	NewEntry().WithFields(Fields{"env": "override"}).Info("hello")
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0]["service"] != "users" || logged[0]["env"] != "override" {
+		t.Errorf("got: %v, want service=users and env=override", logged[0])
+	}
+}
@@ -0,0 +1,119 @@
+package eal
+
+import (
+	"context"
+	"sync"
+)
+
+// requestFields hold the log fields of a request that is handled by the logger middleware. The fields can be added to
+// by the handler, and by goroutines started by the handler, until the middleware close them to write the access log
+// entry.
+type requestFields struct {
+	mu     sync.Mutex
+	fields Fields
+	closed bool
+
+	// clock and stages are used by Stage, stages is protected by mu
+	clock  Clock
+	stages *stageTracker
+}
+
+// WithFields add log fields to the context. If the context is derived from a request context set up by the
+// CreateLoggerMiddleware, the fields are added to the access log entry of the request, which make it possible for
+// service layers that only have access to a context.Context to enrich the access log. Otherwise, a new context that
+// hold the fields of ctx and the new fields is returned, and ctx is left unchanged.
+//
+// The fields can be retrieved by FieldsFromContext, for example to be used with Entry.WithFields. WithFields is safe
+// for concurrent use.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	add := func(logFields Fields) {
+		for k, v := range fields {
+			setField(logFields, k, v)
+		}
+	}
+	if rf := contextRequestFields(ctx); rf != nil && rf.update(add) {
+		return ctx
+	}
+
+	parent := contextFields(ctx)
+	logFields := make(Fields, len(parent)+len(fields))
+	for k, v := range parent {
+		logFields[k] = v
+	}
+	add(logFields)
+	return context.WithValue(ctx, fieldsContextKey{}, logFields)
+}
+
+// FieldsFromContext return a copy of the log fields stored in the context, by either WithFields or the
+// CreateLoggerMiddleware. If the context don't have any log fields, nil is returned.
+func FieldsFromContext(ctx context.Context) Fields {
+	logFields := contextFields(ctx)
+	if logFields == nil {
+		return nil
+	}
+
+	fields := make(Fields, len(logFields))
+	for k, v := range logFields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// contextFields return the log fields stored in the context, by WithFields or CreateLoggerMiddleware. The returned
+// fields must not be modified.
+func contextFields(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	switch fields := ctx.Value(fieldsContextKey{}).(type) {
+	case *requestFields:
+		return fields.snapshot()
+	case Fields:
+		return fields
+	}
+	return nil
+}
+
+// contextRequestFields return the request fields of the logger middleware, if they are the closest fields of ctx.
+func contextRequestFields(ctx context.Context) *requestFields {
+	if ctx == nil {
+		return nil
+	}
+	rf, _ := ctx.Value(fieldsContextKey{}).(*requestFields)
+	return rf
+}
+
+// update call f with the fields while they are locked, and report if f was called. The fields can't be updated after
+// they have been closed.
+func (rf *requestFields) update(f func(fields Fields)) bool {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return false
+	}
+	f(rf.fields)
+	return true
+}
+
+// snapshot return a copy of the fields.
+func (rf *requestFields) snapshot() Fields {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	fields := make(Fields, len(rf.fields))
+	for k, v := range rf.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// close stop all further updates of the fields, and return a copy of them that is owned by the caller.
+func (rf *requestFields) close() Fields {
+	rf.mu.Lock()
+	rf.closed = true
+	rf.mu.Unlock()
+	return rf.snapshot()
+}
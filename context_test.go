@@ -0,0 +1,221 @@
+package eal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithFields(t *testing.T) {
+	ctx := WithFields(context.Background(), Fields{"tenant": "acme"})
+	ctx = WithFields(ctx, Fields{"user_id": 42})
+
+	want := Fields{"tenant": "acme", "user_id": 42}
+	if got := FieldsFromContext(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if got := FieldsFromContext(context.Background()); got != nil {
+		t.Errorf("got: %v, want: nil", got)
+	}
+
+	parent := WithFields(context.Background(), Fields{"tenant": "acme"})
+	WithFields(parent, Fields{"order_id": 7})
+	sibling := WithFields(parent, Fields{"user_id": 42})
+	if got, want := FieldsFromContext(parent), (Fields{"tenant": "acme"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got parent fields: %v, want: %v", got, want)
+	}
+	if got, want := FieldsFromContext(sibling), (Fields{"tenant": "acme", "user_id": 42}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got sibling fields: %v, want: %v", got, want)
+	}
+}
+
+func TestWithFieldsMiddleware(t *testing.T) {
+	entries := captureLog(t)
+
+	service := func(ctx context.Context) {
+		WithFields(ctx, Fields{"tenant": "acme"})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		service(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0]["tenant"] != "acme" {
+		t.Errorf("got tenant: %v, want: acme", logged[0]["tenant"])
+	}
+}
+
+func TestWithFieldsMiddlewareConcurrent(t *testing.T) {
+	entries := captureLog(t)
+
+	var late sync.WaitGroup
+	late.Add(1)
+	req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		ctx := c.Request().Context()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				WithFields(ctx, Fields{fmt.Sprintf("worker_%d", i): i})
+				FieldsFromContext(ctx)
+			}(i)
+		}
+		wg.Wait()
+		go func() {
+			defer late.Done()
+			// The access log entry may already have been written
+			ctx := WithFields(ctx, Fields{"late": true})
+			NewEntry().WithContext(ctx).Info("late")
+		}()
+		return c.NoContent(http.StatusOK)
+	})
+	late.Wait()
+
+	var access map[string]interface{}
+	for _, l := range entries() {
+		if l["msg"] == "access" {
+			access = l
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if access[fmt.Sprintf("worker_%d", i)] != float64(i) {
+			t.Errorf("got access entry: %v, want worker_%d", access, i)
+		}
+	}
+}
+
+// contextHook record the context of the log entries.
+type contextHook struct{ ctx *context.Context }
+
+func (h contextHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h contextHook) Fire(entry *logrus.Entry) error {
+	*h.ctx = entry.Context
+	return nil
+}
+
+func TestEntryWithContext(t *testing.T) {
+	entries := captureLog(t)
+	var hookCtx context.Context
+	hooks := logrus.LevelHooks{}
+	for l, hs := range logrus.StandardLogger().Hooks {
+		hooks[l] = append([]logrus.Hook(nil), hs...)
+	}
+	logrus.AddHook(contextHook{ctx: &hookCtx})
+	t.Cleanup(func() { logrus.StandardLogger().ReplaceHooks(hooks) })
+
+	ctx := WithFields(context.Background(), Fields{"tenant": "acme"})
+	NewEntry().WithContext(ctx).WithFields(Fields{"order_id": 7}).Info("order updated")
+	if hookCtx != ctx {
+		t.Errorf("got hook context: %v, want the entry context", hookCtx)
+	}
+	var noCtx context.Context
+	NewEntry().WithContext(noCtx).Info("no context")
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0]["tenant"] != "acme" || logged[0]["order_id"] != float64(7) {
+		t.Errorf("got entry: %v, want the context fields", logged[0])
+	}
+	if hookCtx != nil {
+		t.Error("got a context in the hook for the entry without context")
+	}
+}
+
+func TestEntryWithRequest(t *testing.T) {
+	entries := captureLog(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?id=7", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	req.Header.Set("X-Forwarded-Host", "shop.example.com:8443")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	NewEntry().WithRequest(req).Info("proxied")
+
+	out, _ := http.NewRequest(http.MethodGet, "http://upstream/items?page=2", nil)
+	out.RemoteAddr = "10.0.0.1:1234"
+	NewEntry().WithRequest(out).Info("outbound")
+	NewEntry().WithRequest(nil).Info("no request")
+
+	logged := entries()
+	if len(logged) != 3 {
+		t.Fatalf("got %d log entries, want 3", len(logged))
+	}
+	for i, want := range []map[string]interface{}{
+		{FieldMethod: "POST", FieldURI: "/orders?id=7", FieldHost: "shop.example.com", FieldRequestID: "req-1", FieldRemoteAddr: "203.0.113.7"},
+		{FieldMethod: "GET", FieldURI: "/items?page=2", FieldHost: "", FieldRequestID: nil, FieldRemoteAddr: "10.0.0.1:1234"},
+		{FieldMethod: nil, FieldURI: nil, FieldHost: nil, FieldRequestID: nil, FieldRemoteAddr: nil},
+	} {
+		for k, v := range want {
+			if logged[i][k] != v {
+				t.Errorf("entry %d: got %s: %v, want: %v", i, k, logged[i][k], v)
+			}
+		}
+	}
+	if req.Header.Get("X-Host") != "" {
+		t.Error("WithRequest modified the request headers")
+	}
+}
+
+func TestEntryWithRequestConfigHeaders(t *testing.T) {
+	entries := captureLog(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-Id", "req-1")
+	req.Header.Set("X-View-Id", "pv-1")
+	mw := CreateLoggerMiddlewareWithConfig(LoggerConfig{RequestIDHeader: "X-Correlation-Id", PageViewIDHeader: "X-View-Id"})
+	serve(mw, req, func(c echo.Context) error {
+		NewEntry().WithRequest(c.Request()).Info("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	logged := entries()
+	if len(logged) == 0 {
+		t.Fatal("got no log entries")
+	}
+	if logged[0][FieldRequestID] != "req-1" || logged[0][FieldPageViewID] != "pv-1" {
+		t.Errorf("got entry: %v, want request_id req-1 and page_view_id pv-1", logged[0])
+	}
+}
+
+func TestEntryRelease(t *testing.T) {
+	entries := captureLog(t)
+
+	for i := 0; i < 3; i++ {
+		e := NewEntry()
+		if len(e.Data) != 0 || e.Context != nil || e.Logger != logrus.StandardLogger() {
+			t.Fatalf("got reused entry: %+v, want an empty entry", e.Entry)
+		}
+		e.WithContext(context.Background()).WithFields(Fields{"run": i}).Info("released")
+		e.Release()
+	}
+	var nilEntry *Entry
+	nilEntry.Release()
+
+	logged := entries()
+	if len(logged) != 3 {
+		t.Fatalf("got %d log entries, want 3", len(logged))
+	}
+	for i, l := range logged {
+		if l["run"] != float64(i) {
+			t.Errorf("entry %d: got run: %v, want: %d", i, l["run"], i)
+		}
+	}
+}
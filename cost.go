@@ -0,0 +1,90 @@
+package eal
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+type (
+	// RouteCost hold the accumulated request cost for a route, see AddCost and RouteCosts.
+	RouteCost struct {
+		Route    string  `json:"route"`
+		Requests int64   `json:"requests"`
+		Total    float64 `json:"total"`
+	}
+
+	costContextKey struct{}
+
+	costCounter struct {
+		mu    sync.Mutex
+		units float64
+	}
+)
+
+var (
+	routeCostsMu sync.Mutex
+	routeCosts   = make(map[string]*RouteCost)
+)
+
+// AddCost add cost units to the current request. The accumulated cost is logged in the request_cost field by the
+// CreateLoggerMiddleware, and summarized per route (see RouteCosts). What a unit represent is up to the application,
+// for example rows scanned, rendered report pages or CPU milliseconds. AddCost is safe to call from several
+// goroutines handling the same request, and is a no-op if ctx isn't derived from a request context set up by
+// CreateLoggerMiddleware.
+func AddCost(ctx context.Context, units float64) {
+	if ctx == nil {
+		return
+	}
+	cc, ok := ctx.Value(costContextKey{}).(*costCounter)
+	if !ok {
+		return
+	}
+	cc.mu.Lock()
+	cc.units += units
+	cc.mu.Unlock()
+}
+
+// RouteCosts return the accumulated request cost per route, for routes where AddCost have been used, sorted with the
+// most costly route first.
+func RouteCosts() []RouteCost {
+	routeCostsMu.Lock()
+	defer routeCostsMu.Unlock()
+
+	list := make([]RouteCost, 0, len(routeCosts))
+	for _, rc := range routeCosts {
+		list = append(list, *rc)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Total > list[j].Total })
+	return list
+}
+
+func withCostCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, costContextKey{}, &costCounter{})
+}
+
+// setCostField add the request_cost field if AddCost have been used during the request, and add the cost to the route
+// summary.
+func setCostField(ctx context.Context, route string, fields Fields) {
+	cc, ok := ctx.Value(costContextKey{}).(*costCounter)
+	if !ok {
+		return
+	}
+	cc.mu.Lock()
+	units := cc.units
+	cc.mu.Unlock()
+	if units == 0 {
+		return
+	}
+	fields[FieldRequestCost] = units
+
+	routeCostsMu.Lock()
+	defer routeCostsMu.Unlock()
+	rc, ok := routeCosts[route]
+	if !ok {
+		rc = &RouteCost{Route: route}
+		routeCosts[route] = rc
+	}
+	rc.Requests++
+	rc.Total += units
+}
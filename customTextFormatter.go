@@ -19,8 +19,10 @@ const (
 )
 
 // Init initialize the logrus logger. If devMode is true, a text based logger will be used, otherwise a JSON logger
-// is used to output the log information to STDOUT.
+// is used to output the log information to STDOUT. Init only select the output format, error details are exposed in
+// responses only when SetDevEnvironment(true) is called, see LoggerConfig.DevErrorResponses.
 func Init(devMode bool) {
+	installHook()
 	if !devMode {
 		logrus.SetFormatter(&logrus.JSONFormatter{})
 	} else {
@@ -32,7 +34,7 @@ func (f *CustomTextFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	var b *bytes.Buffer
 	keys := make([]string, 0, len(entry.Data))
 	for k := range entry.Data {
-		if k != errorStack {
+		if k != FieldErrorStack {
 			keys = append(keys, k)
 		}
 	}
@@ -68,9 +70,9 @@ func (f *CustomTextFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 
 	b.WriteByte('\n')
 
-	if stack, ok := entry.Data[errorStack]; ok {
+	if stack, ok := entry.Data[FieldErrorStack]; ok {
 		if stack, ok := stack.(string); ok {
-			fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m=", levelColor, errorStack)
+			fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m=", levelColor, FieldErrorStack)
 			b.WriteByte('\n')
 			for _, r := range strings.Split(stack, `\n`) {
 				b.WriteString(r)
@@ -0,0 +1,144 @@
+package eal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// dedupFormatter wrap the logrus formatter, and drop error entries that repeat an error that was logged within the
+	// interval.
+	dedupFormatter struct {
+		next     logrus.Formatter
+		interval time.Duration
+
+		mu        sync.Mutex
+		errors    map[string]*dedupState
+		lastSweep time.Time
+		decisions dropDecisions
+		pending   []byte
+	}
+
+	// dedupState track an error fingerprint during the current interval.
+	dedupState struct {
+		start      time.Time
+		suppressed int
+		last       *logrus.Entry
+	}
+)
+
+// SetErrorDedup enable deduplication of error entries, to avoid flooding the logs with identical errors during
+// incidents. Errors are identified by their fingerprint (see Fingerprint) and log message. The first occurrence of an
+// error is logged, and repeated occurrences within the interval are dropped. When the interval has passed, the last
+// dropped occurrence is logged with the repeat_count field set to the number of dropped occurrences.
+//
+// The repeated entries are written when the next entry is logged after the interval, so a repeat_count entry may be
+// delayed if the application is otherwise silent.
+//
+// SetErrorDedup wrap the current logrus formatter, so it must be called after Init (or InitMsgpack etc.). An interval
+// of zero disable deduplication.
+func SetErrorDedup(interval time.Duration) {
+	f := logrus.StandardLogger().Formatter
+	if df, ok := f.(*dedupFormatter); ok {
+		f = df.next
+	}
+	installHook()
+	defer func() { updateEntryDroppers(logrus.StandardLogger().Formatter) }()
+	if interval <= 0 {
+		logrus.SetFormatter(f)
+		return
+	}
+	logrus.SetFormatter(&dedupFormatter{next: f, interval: interval, errors: map[string]*dedupState{}})
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *dedupFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	drop, ok := f.decisions.take(entry)
+	if !ok {
+		drop = f.decide(entry)
+	}
+	repeated := append(f.pending, f.sweep(entry.Time)...)
+	f.pending = nil
+	if len(repeated) == 0 {
+		repeated = nil
+	}
+	if drop {
+		return repeated, nil
+	}
+
+	b, err := f.next.Format(entry)
+	if err != nil || repeated == nil {
+		return b, err
+	}
+	return append(repeated, b...), nil
+}
+
+// dropEntry implements the entryDropper interface.
+func (f *dedupFormatter) dropEntry(entry *logrus.Entry, dropped bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	drop := !dropped && f.decide(entry)
+	f.decisions.set(entry, drop)
+	return drop
+}
+
+// decide report if the entry repeat an error that was logged within the interval, and should be dropped. The
+// intervals that have passed are ended first. f.mu must be held.
+func (f *dedupFormatter) decide(entry *logrus.Entry) bool {
+	f.pending = append(f.pending, f.sweep(entry.Time)...)
+	if entry.Level > logrus.ErrorLevel {
+		return false
+	}
+	fp := Fingerprint(entry.Data)
+	if fp == "" {
+		return false
+	}
+	key := entry.Message + "\x00" + fp
+	if state, ok := f.errors[key]; ok {
+		state.suppressed++
+		state.last = copyEntry(entry)
+		return true
+	}
+	f.errors[key] = &dedupState{start: entry.Time}
+	return false
+}
+
+// sweep end the intervals that have passed, and return the formatted repeat_count entries of the errors that were
+// dropped within them.
+func (f *dedupFormatter) sweep(now time.Time) []byte {
+	if now.Sub(f.lastSweep) < time.Second && now.After(f.lastSweep) {
+		return nil
+	}
+	f.lastSweep = now
+
+	var out []byte
+	for key, state := range f.errors {
+		if now.Sub(state.start) < f.interval {
+			continue
+		}
+		delete(f.errors, key)
+		if state.suppressed == 0 {
+			continue
+		}
+		state.last.Data[FieldRepeatCount] = state.suppressed
+		sequenceEntry(state.last.Data)
+		if b, err := f.next.Format(state.last); err == nil {
+			out = append(out, b...)
+		}
+	}
+	return out
+}
+
+// copyEntry return a copy of the entry that can be formatted later.
+func copyEntry(entry *logrus.Entry) *logrus.Entry {
+	data := make(logrus.Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	return &logrus.Entry{Logger: entry.Logger, Data: data, Time: entry.Time, Level: entry.Level, Message: entry.Message, Context: entry.Context}
+}
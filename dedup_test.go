@@ -0,0 +1,52 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSetErrorDedup(t *testing.T) {
+	entries := captureLog(t)
+	SetErrorDedup(time.Minute)
+	defer SetErrorDedup(0)
+
+	start := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		e := NewEntry().WithError(fmt.Errorf("order %d not found", i))
+		e.Entry.Time = start.Add(time.Duration(i) * time.Second)
+		e.Error("ERROR")
+	}
+	other := NewEntry().WithError(errors.New("connection refused"))
+	other.Entry.Time = start.Add(30 * time.Second)
+	other.Error("ERROR")
+	info := NewEntry()
+	info.Entry.Time = start.Add(2 * time.Minute)
+	info.Info("done")
+
+	logged := entries()
+	if len(logged) != 4 {
+		t.Fatalf("got %d log entries, want 4: %v", len(logged), logged)
+	}
+	if logged[0][FieldErrorMessage] != "order 0 not found" || logged[0][FieldRepeatCount] != nil {
+		t.Errorf("got first entry: %v, want the first occurrence without repeat_count", logged[0])
+	}
+	if logged[1][FieldErrorMessage] != "connection refused" {
+		t.Errorf("got second entry: %v, want the other error", logged[1])
+	}
+
+	// The repeated errors are written before the first entry after the interval
+	var repeated map[string]interface{}
+	for _, e := range logged[2:] {
+		if e[FieldRepeatCount] != nil {
+			repeated = e
+		}
+	}
+	if repeated == nil || repeated[FieldRepeatCount] != float64(9) || repeated[FieldErrorMessage] != "order 9 not found" {
+		t.Errorf("got repeated entry: %v, want the last occurrence with repeat_count 9", repeated)
+	}
+	if logged[3]["msg"] != "done" {
+		t.Errorf("got last entry: %v, want the info entry", logged[3])
+	}
+}
@@ -0,0 +1,119 @@
+package eal
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	idGeneratorContextName    = "mfContextIDGenerator"
+	requestHeadersContextName = "mfContextRequestHeaders"
+)
+
+type (
+	// IDGenerator generate the request IDs of requests that don't have an X-Request-Id header, see
+	// LoggerConfig.RequestIDGenerator.
+	IDGenerator interface {
+		NewID() string
+	}
+
+	// Clock is used by the logger middleware to measure latency and to timestamp the access log entries.
+	Clock interface {
+		Now() time.Time
+	}
+
+	// Emitter write the access log entries produced by the logger middleware.
+	Emitter interface {
+		Emit(r Record)
+	}
+
+	// Deps hold the dependencies of the logger middleware, see NewLoggerMiddleware. Dependencies that aren't set use
+	// the default implementations: UUIDGenerator, SystemClock and LogEmitter.
+	Deps struct {
+		IDGenerator IDGenerator
+		Clock       Clock
+		Emitter     Emitter
+	}
+
+	// UUIDGenerator is an IDGenerator that generate random (version 4) UUIDs, with github.com/google/uuid that read
+	// from crypto/rand. If crypto/rand fail, the error is logged and a UUID from math/rand is returned instead, since
+	// request IDs only need to be unique, not unpredictable.
+	UUIDGenerator struct{}
+
+	// SystemClock is a Clock that return the current local time.
+	SystemClock struct{}
+
+	// LogEmitter is an Emitter that write the access log entries in the same way as Entry, i.e. through logrus or the
+	// Sink set by SetSink.
+	LogEmitter struct{}
+)
+
+// NewID implements the IDGenerator interface.
+func (UUIDGenerator) NewID() string {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		NewEntry().withError(err).Error("failed to generate request ID")
+		return fallbackUUID().String()
+	}
+	return id.String()
+}
+
+// fallbackUUID return a version 4 UUID from math/rand, used when crypto/rand fail.
+func fallbackUUID() uuid.UUID {
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+	binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	id[6] = id[6]&0x0f | 0x40 // Version 4
+	id[8] = id[8]&0x3f | 0x80 // Variant RFC 4122
+	return id
+}
+
+// Now implements the Clock interface.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Emit implements the Emitter interface.
+func (LogEmitter) Emit(r Record) {
+	e := NewEntry().WithFields(r.Fields)
+	e.Entry.Time = r.Time
+	e.Entry.Context = r.Context
+	e.Log(logrus.Level(r.Level), r.Message)
+	e.Release()
+}
+
+// withDefaults return a copy of the dependencies, with the default implementations set for missing dependencies.
+func (d Deps) withDefaults() Deps {
+	if d.IDGenerator == nil {
+		d.IDGenerator = UUIDGenerator{}
+	}
+	if d.Clock == nil {
+		d.Clock = SystemClock{}
+	}
+	if d.Emitter == nil {
+		d.Emitter = LogEmitter{}
+	}
+	return d
+}
+
+// contextIDGenerator return the IDGenerator of the logger middleware that handle the request.
+func contextIDGenerator(c echo.Context) IDGenerator {
+	if g, ok := c.Get(idGeneratorContextName).(IDGenerator); ok {
+		return g
+	}
+	return UUIDGenerator{}
+}
+
+// contextRequestHeaders return the request headers of the logger middleware that handle the request, or the headers
+// of DefaultLoggerConfig if the request isn't handled by a logger middleware.
+func contextRequestHeaders(c echo.Context) requestHeaders {
+	if h, ok := c.Get(requestHeadersContextName).(requestHeaders); ok {
+		return h
+	}
+	return DefaultLoggerConfig.requestHeaders()
+}
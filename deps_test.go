@@ -0,0 +1,164 @@
+package eal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	fakeIDGenerator string
+
+	// fakeClock advance a second each time Now is called.
+	fakeClock struct {
+		mu  sync.Mutex
+		now time.Time
+	}
+
+	recordingEmitter struct {
+		mu      sync.Mutex
+		records []Record
+	}
+)
+
+func (g fakeIDGenerator) NewID() string {
+	return string(g)
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func (e *recordingEmitter) Emit(r Record) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = append(e.records, r)
+}
+
+// waitRecords wait until n records have been emitted, and return them.
+func (e *recordingEmitter) waitRecords(t *testing.T, n int) []Record {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		e.mu.Lock()
+		records := append([]Record(nil), e.records...)
+		e.mu.Unlock()
+		if len(records) >= n || time.Now().After(deadline) {
+			return records
+		}
+	}
+}
+
+func TestNewLoggerMiddleware(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)}
+	emitter := &recordingEmitter{}
+	deps := Deps{IDGenerator: fakeIDGenerator("req-1"), Clock: clock, Emitter: emitter}
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	rec := serve(NewLoggerMiddleware(deps, LoggerConfig{}), req, func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	if got := rec.Header().Get("X-Request-Id"); got != "req-1" {
+		t.Errorf("got X-Request-Id: %q, want: req-1", got)
+	}
+	if len(emitter.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(emitter.records))
+	}
+	r := emitter.records[0]
+	if r.Level != InfoLevel || r.Message != defaultAccessMessage {
+		t.Errorf("got level: %s, message: %q", r.Level, r.Message)
+	}
+	if !r.Time.Equal(clock.now) {
+		t.Errorf("got time: %s, want: %s", r.Time, clock.now)
+	}
+	for k, want := range map[string]interface{}{
+		FieldRequestID: "req-1",
+		FieldLatencyMs: int64(1000),
+		FieldStatus:    http.StatusNoContent,
+	} {
+		if r.Fields[k] != want {
+			t.Errorf("got %s: %v, want: %v", k, r.Fields[k], want)
+		}
+	}
+}
+
+func TestRequestIDConfig(t *testing.T) {
+	// echoRequestID set the response header in the same way as the echo RequestID middleware
+	echoRequestID := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("X-Correlation-Id", "echo-id")
+			return next(c)
+		}
+	}
+
+	for _, tt := range []struct {
+		name       string
+		echoMW     bool
+		reqHeader  string
+		wantID     string
+		wantHeader string
+	}{
+		{name: "generated", wantID: "generated-id", wantHeader: "generated-id"},
+		{name: "from request", reqHeader: "client-id", wantID: "client-id"},
+		{name: "from echo middleware", echoMW: true, wantID: "echo-id", wantHeader: "echo-id"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLog(t)
+			e := echo.New()
+			if tt.echoMW {
+				e.Use(echoRequestID)
+			}
+			e.Use(CreateLoggerMiddlewareWithConfig(LoggerConfig{RequestIDHeader: "X-Correlation-Id", RequestIDGenerator: fakeIDGenerator("generated-id")}))
+			var handlerID string
+			e.GET("/", func(c echo.Context) error {
+				handlerID = c.Request().Header.Get("X-Correlation-Id")
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.reqHeader != "" {
+				req.Header.Set("X-Correlation-Id", tt.reqHeader)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			logged := entries()
+			if len(logged) != 1 || logged[0][FieldRequestID] != tt.wantID {
+				t.Fatalf("got entries: %v, want request_id %s", logged, tt.wantID)
+			}
+			if handlerID != tt.wantID {
+				t.Errorf("got request header: %q, want: %q", handlerID, tt.wantID)
+			}
+			if got := rec.Header().Get("X-Correlation-Id"); got != tt.wantHeader {
+				t.Errorf("got response header: %q, want: %q", got, tt.wantHeader)
+			}
+			if rec.Header().Get(echo.HeaderXRequestID) != "" {
+				t.Error("got X-Request-Id response header")
+			}
+		})
+	}
+}
+
+func TestUUIDGeneratorFallback(t *testing.T) {
+	entries := captureLog(t)
+	uuid.SetRand(iotest.ErrReader(errors.New("no entropy")))
+	t.Cleanup(func() { uuid.SetRand(nil) })
+
+	id, err := uuid.Parse(UUIDGenerator{}.NewID())
+	if err != nil || id.Version() != 4 || id.Variant() != uuid.RFC4122 {
+		t.Errorf("got id: %v (%v), want a version 4 UUID", id, err)
+	}
+	if logged := entries(); len(logged) != 1 || logged[0]["msg"] != "failed to generate request ID" {
+		t.Errorf("got log entries: %v, want the crypto/rand error", logged)
+	}
+}
@@ -0,0 +1,53 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// devEnvironment is set by SetDevEnvironment, and is required for LoggerConfig.DevErrorResponses to have any effect.
+var devEnvironment atomic.Bool
+
+// devErrorChainItem is an error in the error_chain of a dev mode error response.
+type devErrorChainItem struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// SetDevEnvironment mark the service as running in a development environment, which is required for
+// LoggerConfig.DevErrorResponses to have any effect. It's separate from the output format selected by Init, so that
+// services that log text in production, or JSON in development, don't expose or lose the error details by accident.
+func SetDevEnvironment(enabled bool) {
+	devEnvironment.Store(enabled)
+}
+
+// errorResponse return the echo.HTTPError that is sent to the caller. If dev mode error responses are enabled, and the
+// response is a server error, the message is replaced with a message that also contain the error chain and the
+// stacktrace of err.
+func errorResponse(config LoggerConfig, he *echo.HTTPError, err error) *echo.HTTPError {
+	if !config.DevErrorResponses || !devEnvironment.Load() || he.Code < http.StatusInternalServerError {
+		return he
+	}
+
+	message := he.Message
+	if m, ok := message.(string); ok {
+		message = m
+	} else if m, ok := message.(error); ok {
+		message = m.Error()
+	}
+
+	var chain []devErrorChainItem
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, devErrorChainItem{Type: fmt.Sprintf("%T", e), Message: e.Error()})
+	}
+
+	body := echo.Map{"message": message, "error_chain": chain}
+	if st, ok := GetErrorStackTrace(err); ok {
+		body[FieldErrorStack] = st.Stack()
+	}
+	return &echo.HTTPError{Code: he.Code, Message: body, Internal: he.Internal}
+}
@@ -0,0 +1,49 @@
+package eal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDevErrorResponses(t *testing.T) {
+	errDB := errors.New("connection refused")
+	t.Cleanup(func() { SetDevEnvironment(false) })
+
+	for _, tt := range []struct {
+		name        string
+		devEnv      bool
+		err         error
+		wantDetails bool
+	}{
+		{name: "dev_environment", devEnv: true, err: Trace(errDB), wantDetails: true},
+		{name: "production", devEnv: false, err: Trace(errDB)},
+		{name: "client_error", devEnv: true, err: NewHTTPError(Trace(errDB), http.StatusNotFound, "Nope")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = captureLog(t)
+			SetDevEnvironment(tt.devEnv)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{DevErrorResponses: true}), req, func(c echo.Context) error {
+				return tt.err
+			})
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			_, hasChain := body["error_chain"]
+			_, hasStack := body[FieldErrorStack]
+			if hasChain != tt.wantDetails || hasStack != tt.wantDetails {
+				t.Errorf("got response: %v, want error details: %t", body, tt.wantDetails)
+			}
+			if body["message"] == nil {
+				t.Errorf("got no message in response: %v", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package eal
+
+// DefaultSummaryFields is the fields that are written to the hot sink by DualSink, if SummaryFields is empty.
+var DefaultSummaryFields = []string{
+	FieldRequestID, FieldMethod, FieldRouterPath, FieldStatus, FieldLatencyMs, FieldErrorMessage, FieldErrorType,
+	FieldErrorOrigin, FieldTraceID,
+}
+
+// DualSink is a Sink that split request log entries between a hot and a cold sink. The hot sink, typically STDOUT
+// that is shipped to an expensive log index, get a compact summary of each entry with only the SummaryFields. The cold
+// sink, typically a file that is archived to object storage (see Archiver), get the full-fidelity entry with bodies,
+// stacktraces and spans. The entries are linked by the request_id field.
+//
+// Entries without a request_id field, that don't belong to a request, are written unmodified to both sinks.
+//
+//	hot := logrus.New()
+//	cold := logrus.New()
+//	cold.SetFormatter(&logrus.JSONFormatter{})
+//	cold.SetOutput(file)
+//	eal.SetSink(&eal.DualSink{Hot: eal.NewLogrusSink(hot), Cold: eal.NewLogrusSink(cold)})
+type DualSink struct {
+	Hot  Sink
+	Cold Sink
+
+	// SummaryFields is the fields that are written to the hot sink, DefaultSummaryFields is used if not set.
+	SummaryFields []string
+}
+
+// Write implements the Sink interface. Both sinks are always written to, and the first error is returned.
+func (s *DualSink) Write(r Record) error {
+	errCold := s.Cold.Write(r)
+
+	if _, ok := r.Fields[FieldRequestID]; ok {
+		names := s.SummaryFields
+		if len(names) == 0 {
+			names = DefaultSummaryFields
+		}
+		summary := make(Fields, len(names))
+		for _, name := range names {
+			if v, ok := r.Fields[name]; ok {
+				summary[name] = v
+			}
+		}
+		r.Fields = summary
+	}
+
+	if err := s.Hot.Write(r); err != nil {
+		return err
+	}
+	return errCold
+}
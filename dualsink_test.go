@@ -0,0 +1,32 @@
+package eal
+
+import (
+	"testing"
+)
+
+func TestDualSink(t *testing.T) {
+	hot, cold := &recordingSink{}, &recordingSink{}
+	s := &DualSink{Hot: hot, Cold: cold}
+
+	_ = s.Write(Record{Message: "access", Fields: Fields{
+		FieldRequestID:    "req-1",
+		FieldStatus:       500,
+		FieldErrorMessage: "boom",
+		FieldErrorStack:   "goroutine 1 [running]",
+		FieldRequestBody:  `{"name":"droid"}`,
+	}})
+	_ = s.Write(Record{Message: "App started", Fields: Fields{"version": "1.2.3"}})
+
+	if len(hot.records) != 2 || len(cold.records) != 2 {
+		t.Fatalf("got %d hot and %d cold records, want 2 of each", len(hot.records), len(cold.records))
+	}
+	if f := hot.records[0].Fields; len(f) != 3 || f[FieldRequestID] != "req-1" || f[FieldStatus] != 500 || f[FieldErrorMessage] != "boom" {
+		t.Errorf("got hot fields: %v, want request_id, status and error_message", f)
+	}
+	if f := cold.records[0].Fields; f[FieldErrorStack] == nil || f[FieldRequestBody] == nil || f[FieldRequestID] != "req-1" {
+		t.Errorf("got cold fields: %v, want the full entry", f)
+	}
+	if hot.records[1].Fields["version"] != "1.2.3" || cold.records[1].Fields["version"] != "1.2.3" {
+		t.Error("got entry without request_id modified, want it written unmodified to both sinks")
+	}
+}
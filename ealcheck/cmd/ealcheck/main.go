@@ -0,0 +1,13 @@
+// Command ealcheck run the ealcheck analyzer, and is intended to be used with go vet:
+//
+//	go vet -vettool=$(which ealcheck) ./...
+package main
+
+import (
+	"github.com/modfin/eal/ealcheck"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(ealcheck.Analyzer)
+}
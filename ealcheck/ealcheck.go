@@ -0,0 +1,164 @@
+// Package ealcheck provide an analyzer that check that errors are logged in the way eal expect, so that teams can
+// enforce consistent usage with go vet:
+//
+//	go install github.com/modfin/eal/ealcheck/cmd/ealcheck@latest
+//	go vet -vettool=$(which ealcheck) ./...
+//
+// The analyzer report:
+//   - echo handlers that return a local error variable as-is, instead of wrapping it with eal.Trace, eal.NewHTTPError
+//     or similar, so that the logged error lack a stacktrace.
+//   - log calls (eal.Entry and logrus) that pass an error value as a message argument, instead of adding it with
+//     WithError, so that the error fields (error_stack, http_status etc.) are lost.
+package ealcheck
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const (
+	echoPackage   = "github.com/labstack/echo/v4"
+	logrusPackage = "github.com/sirupsen/logrus"
+	ealPackage    = "github.com/modfin/eal"
+)
+
+// Analyzer report errors that are returned from echo handlers without being traced, and errors that are passed to
+// log calls as message arguments, see the package documentation.
+var Analyzer = &analysis.Analyzer{
+	Name:     "ealcheck",
+	Doc:      "report errors that are returned from echo handlers without eal.Trace, or logged without WithError",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// logMethods is the names of the logrus methods that write a log entry, and that eal.Entry inherit.
+var logMethods = map[string]struct{}{}
+
+func init() {
+	for _, level := range []string{"Trace", "Debug", "Info", "Print", "Warn", "Warning", "Error", "Fatal", "Panic"} {
+		for _, suffix := range []string{"", "f", "ln"} {
+			logMethods[level+suffix] = struct{}{}
+		}
+	}
+	logMethods["Log"] = struct{}{}
+	logMethods["Logf"] = struct{}{}
+	logMethods["Logln"] = struct{}{}
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil), (*ast.CallExpr)(nil)}
+	insp.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil && isHandler(pass.TypesInfo.Defs[n.Name].Type().(*types.Signature)) {
+				checkHandlerReturns(pass, n.Body)
+			}
+		case *ast.FuncLit:
+			if sig, ok := pass.TypesInfo.TypeOf(n).(*types.Signature); ok && isHandler(sig) {
+				checkHandlerReturns(pass, n.Body)
+			}
+		case *ast.CallExpr:
+			checkLogCall(pass, n)
+		}
+	})
+	return nil, nil
+}
+
+// isHandler report if the signature is an echo.HandlerFunc, func(echo.Context) error.
+func isHandler(sig *types.Signature) bool {
+	if sig.Params().Len() != 1 || sig.Results().Len() != 1 || !isError(sig.Results().At(0).Type()) {
+		return false
+	}
+	return isNamed(sig.Params().At(0).Type(), echoPackage, "Context")
+}
+
+// checkHandlerReturns report return statements that return a local error variable as-is. Nested function literals
+// are skipped, since they are checked separately if they are handlers.
+func checkHandlerReturns(pass *analysis.Pass, body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(n.Results) != 1 {
+				return true
+			}
+			id, ok := unparen(n.Results[0]).(*ast.Ident)
+			if !ok {
+				return true
+			}
+			v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+			if !ok || v.Parent() == nil || v.Parent() == v.Pkg().Scope() || isNamedPointer(v.Type(), echoPackage, "HTTPError") {
+				return true
+			}
+			if isError(v.Type()) {
+				pass.Reportf(n.Pos(), "error %s is returned from echo handler without eal.Trace or eal.NewHTTPError", id.Name)
+			}
+		}
+		return true
+	})
+}
+
+// checkLogCall report calls to logrus and eal log methods, that pass an error value as a message argument.
+func checkLogCall(pass *analysis.Pass, call *ast.CallExpr) {
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return
+	}
+	switch fn.Pkg().Path() {
+	case logrusPackage:
+	case ealPackage:
+		if fn.Type().(*types.Signature).Recv() == nil {
+			return
+		}
+	default:
+		return
+	}
+	if _, ok := logMethods[fn.Name()]; !ok {
+		return
+	}
+
+	for _, arg := range call.Args {
+		t := pass.TypesInfo.TypeOf(arg)
+		if t == nil || types.Identical(t, types.Typ[types.UntypedNil]) || !isError(t) {
+			continue
+		}
+		pass.Reportf(arg.Pos(), "error passed as argument to %s, use WithError to log the error fields", fn.Name())
+	}
+}
+
+func isError(t types.Type) bool {
+	return types.Implements(t, errorType)
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == pkg && n.Obj().Name() == name
+}
+
+func isNamedPointer(t types.Type, pkg, name string) bool {
+	p, ok := t.(*types.Pointer)
+	return ok && isNamed(p.Elem(), pkg, name)
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
@@ -0,0 +1,106 @@
+package ealcheck
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+// srcImporter type-check the packages in testdata/src, and fall back to the standard library.
+type srcImporter struct {
+	fset *token.FileSet
+	pkgs map[string]*types.Package
+}
+
+func (si *srcImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := si.pkgs[path]; ok {
+		return pkg, nil
+	}
+	dir := filepath.Join("testdata", "src", filepath.FromSlash(path))
+	if _, err := os.Stat(dir); err != nil {
+		return importer.Default().Import(path)
+	}
+	pkg, _, _, err := si.check(path, dir)
+	return pkg, err
+}
+
+func (si *srcImporter) check(path, dir string) (*types.Package, []*ast.File, *types.Info, error) {
+	pkgs, err := parser.ParseDir(si.fset, dir, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	pkg, err := (&types.Config{Importer: si}).Check(path, si.fset, files, info)
+	si.pkgs[path] = pkg
+	return pkg, files, info, err
+}
+
+// TestAnalyzer run the analyzer on testdata/src/a, and compare the diagnostics with the "// want `...`" comments, in
+// the same way as analysistest (which can't be used, since the x/tools version don't build with newer Go versions).
+func TestAnalyzer(t *testing.T) {
+	si := &srcImporter{fset: token.NewFileSet(), pkgs: map[string]*types.Package{}}
+	pkg, files, info, err := si.check("a", filepath.Join("testdata", "src", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diagnostics []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer:  Analyzer,
+		Fset:      si.fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+		ResultOf:  map[*analysis.Analyzer]interface{}{},
+		Report:    func(d analysis.Diagnostic) { diagnostics = append(diagnostics, d) },
+	}
+	if pass.ResultOf[inspect.Analyzer], err = inspect.Analyzer.Run(pass); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Analyzer.Run(pass); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]*regexp.Regexp{}
+	wantPattern := regexp.MustCompile("// want `([^`]*)`")
+	for _, f := range files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if m := wantPattern.FindStringSubmatch(c.Text); m != nil {
+					want[si.fset.Position(c.Pos()).Line] = regexp.MustCompile(m[1])
+				}
+			}
+		}
+	}
+
+	for _, d := range diagnostics {
+		line := si.fset.Position(d.Pos).Line
+		if re, ok := want[line]; !ok || !re.MatchString(d.Message) {
+			t.Errorf("got unexpected diagnostic on line %d: %s", line, d.Message)
+		}
+		delete(want, line)
+	}
+	for line, re := range want {
+		t.Errorf("got no diagnostic on line %d, want: %s", line, strings.TrimSpace(re.String()))
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/modfin/eal/ealcheck
+
+go 1.21
+
+require golang.org/x/tools v0.19.0
@@ -0,0 +1,51 @@
+package a
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"github.com/modfin/eal"
+	"github.com/sirupsen/logrus"
+)
+
+var errSentinel = errors.New("sentinel")
+
+func load() error { return nil }
+
+func handler(c echo.Context) error {
+	if err := load(); err != nil {
+		return err // want `error err is returned from echo handler without eal.Trace or eal.NewHTTPError`
+	}
+	if err := load(); err != nil {
+		return eal.Trace(err)
+	}
+	he := &echo.HTTPError{Code: 400}
+	if he != nil {
+		return he
+	}
+	if c == nil {
+		return errSentinel
+	}
+	return c.String(200, "ok")
+}
+
+func register() {
+	_ = func(c echo.Context) error {
+		err := load()
+		return (err) // want `error err is returned from echo handler without eal.Trace`
+	}
+}
+
+func notHandler() error {
+	err := load()
+	return err
+}
+
+func logging() {
+	err := load()
+	eal.NewEntry().Errorf("load failed: %v", err) // want `error passed as argument to Errorf, use WithError to log the error fields`
+	eal.NewEntry().WithError(err).Error("load failed")
+	logrus.Error(err) // want `error passed as argument to Error`
+	logrus.Infof("done: %d", 1)
+	logrus.WithError(err).Error("load failed")
+}
@@ -0,0 +1,13 @@
+package echo
+
+type Context interface {
+	String(code int, s string) error
+}
+
+type HTTPError struct {
+	Code int
+}
+
+func (he *HTTPError) Error() string { return "" }
+
+var ErrNotFound = &HTTPError{Code: 404}
@@ -0,0 +1,13 @@
+package eal
+
+import "github.com/sirupsen/logrus"
+
+type Entry struct {
+	*logrus.Entry
+}
+
+func NewEntry() *Entry { return &Entry{Entry: &logrus.Entry{}} }
+
+func (e *Entry) WithError(err error) *Entry { return e }
+
+func Trace(err error) error { return err }
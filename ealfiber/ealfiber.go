@@ -0,0 +1,176 @@
+// Package ealfiber provide a Fiber middleware that write the same structured access log entries as the eal logger
+// middleware, for services that use Fiber (fasthttp) instead of echo:
+//
+//	app := fiber.New()
+//	app.Use(ealfiber.CreateLoggerMiddleware())
+//
+// Fields are added to the access log entry with AddContextFields, or with eal.WithFields on the user context of the
+// request, c.UserContext(), in the same way as with the echo middleware.
+package ealfiber
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/modfin/eal"
+)
+
+type (
+	// ContextLogFunc can be implemented to be able to add log fields from a Fiber context, it's the Fiber version of
+	// eal.ContextLogFunc.
+	ContextLogFunc func(c *fiber.Ctx, fields eal.Fields)
+
+	// Config defines the config for the Fiber middleware, see NewLoggerMiddleware.
+	Config struct {
+		// LoggerConfig configure the access log entries in the same way as for the echo middleware, see
+		// eal.AccessLogger. The ContextLogFuncs and ResultLogFuncs of the LoggerConfig aren't used, since they take an
+		// echo.Context.
+		eal.LoggerConfig
+
+		// ContextLogFuncs is called before the request is handled, to add log fields from the Fiber context.
+		// If no functions are provided, DefaultContextLogFunc is used.
+		ContextLogFuncs []ContextLogFunc
+	}
+
+	// clientError wrap the error of a request that failed with a client error status (4xx), that isn't an
+	// echo.HTTPError, when it's logged. The request is logged with info level, in the same way as echo.HTTPErrors with a
+	// 4xx status are logged by the logger middleware.
+	clientError struct {
+		err error
+	}
+
+	accessLoggerKey    struct{}
+	requestIDHeaderKey struct{}
+)
+
+// DefaultContextLogFunc add the request_id, remote_addr, host, method and uri fields, in the same way as
+// eal.DefaultContextLogFunc. Requests that don't have a request ID header get a generated ID, that is set in the
+// header of both the request and the response. The values are copied, since the strings returned by the Fiber context
+// are only valid until the request have been handled, and log entries may be written later by the sink.
+var DefaultContextLogFunc ContextLogFunc = func(c *fiber.Ctx, fields eal.Fields) {
+	host := c.Get("X-Host")
+	if host == "" {
+		if alt := c.Get("X-Forwarded-Host"); alt != "" {
+			host = strings.Split(alt, ":")[0]
+		}
+	}
+
+	header, _ := c.Locals(requestIDHeaderKey{}).(string)
+	if header == "" {
+		header = echo.HeaderXRequestID
+	}
+	id := c.Get(header)
+	if id == "" {
+		id = string(c.Response().Header.Peek(header))
+		if id == "" {
+			if l, ok := c.Locals(accessLoggerKey{}).(*eal.AccessLogger); ok {
+				id = l.NewID()
+			} else {
+				id = eal.UUIDGenerator{}.NewID()
+			}
+			c.Set(header, id)
+		}
+		c.Request().Header.Set(header, id)
+	}
+
+	fields[eal.FieldRequestID] = strings.Clone(id)
+	fields[eal.FieldRemoteAddr] = strings.Clone(clientAddr(c))
+	fields[eal.FieldHost] = strings.Clone(host)
+	fields[eal.FieldMethod] = strings.Clone(c.Method())
+	fields[eal.FieldURI] = string(c.Request().RequestURI())
+}
+
+// AddContextFields add log fields to the access log entry of the request, it's the Fiber version of
+// eal.AddContextFields.
+func AddContextFields(c *fiber.Ctx, fields eal.Fields) {
+	eal.WithFields(c.UserContext(), fields)
+}
+
+// CreateLoggerMiddleware return a Fiber middleware that handle access and error logging of the request, configured by
+// eal.DefaultLoggerConfig, and with the ContextLogFuncs if any are provided.
+func CreateLoggerMiddleware(logFunctions ...ContextLogFunc) fiber.Handler {
+	return NewLoggerMiddleware(eal.Deps{}, Config{LoggerConfig: eal.DefaultLoggerConfig, ContextLogFuncs: logFunctions})
+}
+
+// NewLoggerMiddleware return a Fiber middleware that handle access and error logging of the request, configured by the
+// config, with the dependencies provided by deps, see eal.NewLoggerMiddleware.
+//
+// Errors returned by the handlers are passed to the error handler of the Fiber app, and the status of the response is
+// logged. If the error chain contain an echo.HTTPError, for example from eal.NewHTTPError, it's passed to the error
+// handler as a *fiber.Error with the same status code and message. The router_path field is set to the path of the
+// route that handled the request.
+func NewLoggerMiddleware(deps eal.Deps, config Config) fiber.Handler {
+	l := eal.NewAccessLogger(deps, config.LoggerConfig)
+	if len(config.ContextLogFuncs) == 0 {
+		config.ContextLogFuncs = []ContextLogFunc{DefaultContextLogFunc}
+	}
+	header := config.RequestIDHeader
+	if header == "" {
+		header = echo.HeaderXRequestID
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Locals(accessLoggerKey{}, l)
+		c.Locals(requestIDHeaderKey{}, header)
+		fields := eal.Fields{}
+		for _, f := range config.ContextLogFuncs {
+			f(c, fields)
+		}
+		ctx := l.Start(c.UserContext(), fields)
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if err != nil {
+			if herr := c.App().ErrorHandler(c, fiberError(err)); herr != nil {
+				_ = c.SendStatus(http.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if err != nil && status < http.StatusInternalServerError && eal.GetInnerHTTPError(err) == nil {
+			err = &clientError{err: err}
+		}
+		eal.WithFields(ctx, eal.Fields{eal.FieldRouterPath: strings.Clone(c.Route().Path)})
+		l.Finish(ctx, status, err)
+		return nil
+	}
+}
+
+// clientAddr attempt to get the remote address of the client, from the proxy headers or the connection.
+func clientAddr(c *fiber.Ctx) string {
+	for _, h := range []string{"X-Forwarded-For", "X-Real-Ip", "X-Remote-Addr"} {
+		if addr := c.Get(h); addr != "" {
+			return addr
+		}
+	}
+	return c.Context().RemoteAddr().String()
+}
+
+// fiberError return the error that is passed to the error handler of the Fiber app.
+func fiberError(err error) error {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe
+	}
+	if he := eal.GetInnerHTTPError(err); he != nil {
+		return fiber.NewError(he.Code, fmt.Sprint(he.Message))
+	}
+	return err
+}
+
+func (e *clientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *clientError) Unwrap() error {
+	return e.err
+}
+
+// LogLevel return the level of the access log entries of requests that fail with a client error.
+func (e *clientError) LogLevel() eal.Level {
+	return eal.InfoLevel
+}
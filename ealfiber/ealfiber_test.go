@@ -0,0 +1,95 @@
+package ealfiber
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/modfin/eal"
+)
+
+type (
+	recordingEmitter struct {
+		mu      sync.Mutex
+		records []eal.Record
+	}
+
+	fakeIDGenerator string
+)
+
+func (e *recordingEmitter) Emit(r eal.Record) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = append(e.records, r)
+}
+
+func (g fakeIDGenerator) NewID() string {
+	return string(g)
+}
+
+func TestNewLoggerMiddleware(t *testing.T) {
+	emitter := &recordingEmitter{}
+	app := fiber.New()
+	app.Use(NewLoggerMiddleware(eal.Deps{Emitter: emitter, IDGenerator: fakeIDGenerator("generated")}, Config{
+		ContextLogFuncs: []ContextLogFunc{DefaultContextLogFunc, func(c *fiber.Ctx, fields eal.Fields) {
+			fields["tenant"] = c.Get("X-Tenant")
+		}},
+	}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		AddContextFields(c, eal.Fields{"user_id": c.Params("id")})
+		switch c.Params("id") {
+		case "missing":
+			return eal.NewHTTPError(errors.New("no such user"), http.StatusNotFound)
+		case "gone":
+			return fiber.ErrGone
+		case "broken":
+			return errors.New("db down")
+		}
+		return c.SendString("ok")
+	})
+
+	for _, tt := range []struct {
+		path       string
+		status     int
+		level      eal.Level
+		requestID  string
+		wantHeader string
+	}{
+		{path: "/users/1", status: http.StatusOK, level: eal.InfoLevel, requestID: "generated", wantHeader: "generated"},
+		{path: "/users/missing", status: http.StatusNotFound, level: eal.InfoLevel, requestID: "req-1"},
+		{path: "/users/gone", status: http.StatusGone, level: eal.InfoLevel, requestID: "req-1"},
+		{path: "/users/broken", status: http.StatusInternalServerError, level: eal.ErrorLevel, requestID: "req-1"},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			emitter.records = nil
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Tenant", "acme")
+			if tt.wantHeader == "" {
+				req.Header.Set("X-Request-Id", tt.requestID)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.StatusCode != tt.status || res.Header.Get("X-Request-Id") != tt.wantHeader {
+				t.Errorf("got status: %d, X-Request-Id: %q, want: %d, %q", res.StatusCode, res.Header.Get("X-Request-Id"), tt.status, tt.wantHeader)
+			}
+			if len(emitter.records) != 1 {
+				t.Fatalf("got %d records, want 1", len(emitter.records))
+			}
+			r := emitter.records[0]
+			if r.Level != tt.level || r.Fields[eal.FieldStatus] != tt.status || r.Fields[eal.FieldRequestID] != tt.requestID ||
+				r.Fields[eal.FieldRouterPath] != "/users/:id" || r.Fields[eal.FieldURI] != tt.path ||
+				r.Fields[eal.FieldMethod] != http.MethodGet || r.Fields["tenant"] != "acme" || r.Fields["user_id"] == nil {
+				t.Errorf("got %s: %v, want %s level with the request fields", r.Level, r.Fields, tt.level)
+			}
+			if tt.status >= http.StatusBadRequest && r.Fields[eal.FieldErrorMessage] == nil {
+				t.Errorf("got fields: %v, want the error fields", r.Fields)
+			}
+		})
+	}
+}
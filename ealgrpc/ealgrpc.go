@@ -0,0 +1,191 @@
+// Package ealgrpc provide gRPC server interceptors that write the same structured access log entries as the eal
+// logger middleware:
+//
+//	s := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(ealgrpc.UnaryServerInterceptor()),
+//		grpc.ChainStreamInterceptor(ealgrpc.StreamServerInterceptor()),
+//	)
+//
+// The access log entries have the request_id, remote_addr (the peer address), method and router_path (both the full
+// gRPC method name), latency, status and grpc_code fields, and the error fields of eal.UnwrapError if the call failed.
+// Fields can be added to the access log entry by the handlers with eal.WithFields.
+package ealgrpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/modfin/eal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// FieldCode is the name of the field that hold the gRPC status code of the call, for example "NotFound".
+const FieldCode = "grpc_code"
+
+type (
+	// statusError wrap the error of a failed call when it's logged, to add the grpc_code field.
+	statusError struct {
+		err  error
+		code codes.Code
+	}
+
+	// clientStatusError is a statusError with a status code that is caused by the client, the call is logged with
+	// info level, in the same way as echo.HTTPErrors with a 4xx status are logged by the logger middleware.
+	clientStatusError struct {
+		statusError
+	}
+
+	// serverStream is a grpc.ServerStream with the context of the access logger.
+	serverStream struct {
+		grpc.ServerStream
+		ctx context.Context
+	}
+)
+
+func init() {
+	eal.RegisterFieldNames(FieldCode)
+}
+
+// UnaryServerInterceptor return a gRPC unary server interceptor that write access log entries, configured by
+// eal.DefaultLoggerConfig.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return NewUnaryServerInterceptor(eal.Deps{}, eal.DefaultLoggerConfig)
+}
+
+// NewUnaryServerInterceptor return a gRPC unary server interceptor that write access log entries, configured by the
+// config, with the dependencies provided by deps, see eal.NewLoggerMiddleware.
+func NewUnaryServerInterceptor(deps eal.Deps, config eal.LoggerConfig) grpc.UnaryServerInterceptor {
+	l := eal.NewAccessLogger(deps, config)
+	header := requestIDHeader(config)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = l.Start(ctx, requestFields(ctx, l, header, info.FullMethod))
+		res, err := handler(ctx, req)
+		finish(ctx, l, err)
+		return res, err
+	}
+}
+
+// StreamServerInterceptor return a gRPC stream server interceptor that write access log entries, configured by
+// eal.DefaultLoggerConfig. The access log entry of a stream is written when the stream end.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return NewStreamServerInterceptor(eal.Deps{}, eal.DefaultLoggerConfig)
+}
+
+// NewStreamServerInterceptor return a gRPC stream server interceptor that write access log entries, configured by the
+// config, with the dependencies provided by deps, see eal.NewLoggerMiddleware.
+func NewStreamServerInterceptor(deps eal.Deps, config eal.LoggerConfig) grpc.StreamServerInterceptor {
+	l := eal.NewAccessLogger(deps, config)
+	header := requestIDHeader(config)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := l.Start(ss.Context(), requestFields(ss.Context(), l, header, info.FullMethod))
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		finish(ctx, l, err)
+		return err
+	}
+}
+
+// HTTPStatus return the HTTP status that correspond to a gRPC status code, in the same way as grpc-gateway.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// requestIDHeader return the metadata key of the request ID, the lower-cased RequestIDHeader of the config.
+func requestIDHeader(config eal.LoggerConfig) string {
+	if config.RequestIDHeader != "" {
+		return strings.ToLower(config.RequestIDHeader)
+	}
+	return strings.ToLower(echo.HeaderXRequestID)
+}
+
+// requestFields return the request fields of a call. The request ID is read from the incoming metadata, or generated
+// and sent in the header metadata of the response.
+func requestFields(ctx context.Context, l *eal.AccessLogger, header, method string) eal.Fields {
+	fields := eal.Fields{FieldCode: codes.OK.String(), eal.FieldMethod: method, eal.FieldRouterPath: method}
+
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(header); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = l.NewID()
+		_ = grpc.SetHeader(ctx, metadata.Pairs(header, id))
+	}
+	fields[eal.FieldRequestID] = id
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields[eal.FieldRemoteAddr] = p.Addr.String()
+	}
+	return fields
+}
+
+// finish write the access log entry of a call, with the status of the error.
+func finish(ctx context.Context, l *eal.AccessLogger, err error) {
+	if err == nil {
+		l.Finish(ctx, http.StatusOK, nil)
+		return
+	}
+
+	code := status.Code(err)
+	se := statusError{err: err, code: code}
+	httpStatus := HTTPStatus(code)
+	if httpStatus < http.StatusInternalServerError {
+		l.Finish(ctx, httpStatus, &clientStatusError{statusError: se})
+	} else {
+		l.Finish(ctx, httpStatus, &se)
+	}
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// SetLogFields add the grpc_code field.
+func (e *statusError) SetLogFields(fields map[string]interface{}) {
+	fields[FieldCode] = e.code.String()
+}
+
+// LogLevel return the level of the access log entries of calls that fail with a client error.
+func (e *clientStatusError) LogLevel() eal.Level {
+	return eal.InfoLevel
+}
+
+// Context return the context of the access logger, that carry the log fields of the call.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
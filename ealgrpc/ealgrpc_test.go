@@ -0,0 +1,143 @@
+package ealgrpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/modfin/eal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type (
+	healthServer struct {
+		healthpb.UnimplementedHealthServer
+	}
+
+	recordingEmitter struct {
+		mu      sync.Mutex
+		records []eal.Record
+	}
+
+	fakeIDGenerator string
+)
+
+func (healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	eal.WithFields(ctx, eal.Fields{"service": req.Service})
+	switch req.Service {
+	case "missing":
+		return nil, status.Error(codes.NotFound, "unknown service")
+	case "broken":
+		return nil, status.Error(codes.Internal, "db down")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	eal.WithFields(stream.Context(), eal.Fields{"service": req.Service})
+	return status.Error(codes.Unavailable, "shutting down")
+}
+
+func (e *recordingEmitter) Emit(r eal.Record) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = append(e.records, r)
+}
+
+func (g fakeIDGenerator) NewID() string {
+	return string(g)
+}
+
+// dial start a server with the interceptors and the health service, and return a client connected to it.
+func dial(t *testing.T, deps eal.Deps) healthpb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(NewUnaryServerInterceptor(deps, eal.LoggerConfig{})),
+		grpc.ChainStreamInterceptor(NewStreamServerInterceptor(deps, eal.LoggerConfig{})),
+	)
+	healthpb.RegisterHealthServer(s, healthServer{})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	emitter := &recordingEmitter{}
+	client := dial(t, eal.Deps{Emitter: emitter, IDGenerator: fakeIDGenerator("generated")})
+
+	var header metadata.MD
+	ctx := context.Background()
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "req-1")
+	client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	client.Check(ctx, &healthpb.HealthCheckRequest{Service: "broken"})
+
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "generated" {
+		t.Errorf("got x-request-id header: %v, want the generated ID", got)
+	}
+	if len(emitter.records) != 3 {
+		t.Fatalf("got %d records, want 3", len(emitter.records))
+	}
+	for i, want := range []struct {
+		requestID, service, code string
+		status                   int
+		level                    eal.Level
+	}{
+		{requestID: "generated", service: "", code: "OK", status: http.StatusOK, level: eal.InfoLevel},
+		{requestID: "req-1", service: "missing", code: "NotFound", status: http.StatusNotFound, level: eal.InfoLevel},
+		{requestID: "req-1", service: "broken", code: "Internal", status: http.StatusInternalServerError, level: eal.ErrorLevel},
+	} {
+		r := emitter.records[i]
+		if r.Level != want.level || r.Fields[eal.FieldRequestID] != want.requestID || r.Fields["service"] != want.service ||
+			r.Fields[FieldCode] != want.code || r.Fields[eal.FieldStatus] != want.status ||
+			r.Fields[eal.FieldMethod] != healthpb.Health_Check_FullMethodName || r.Fields[eal.FieldRemoteAddr] == nil {
+			t.Errorf("record %d: got %s: %v, want %s level with request_id: %s, service: %s, grpc_code: %s, status: %d",
+				i, r.Level, r.Fields, want.level, want.requestID, want.service, want.code, want.status)
+		}
+	}
+	if emitter.records[2].Fields[eal.FieldErrorMessage] == nil {
+		t.Errorf("got fields: %v, want the error fields", emitter.records[2].Fields)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	emitter := &recordingEmitter{}
+	client := dial(t, eal.Deps{Emitter: emitter})
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got error: %v, want unavailable", err)
+	}
+
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+	if len(emitter.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(emitter.records))
+	}
+	r := emitter.records[0]
+	if r.Level != eal.ErrorLevel || r.Fields["service"] != "users" || r.Fields[FieldCode] != "Unavailable" ||
+		r.Fields[eal.FieldStatus] != http.StatusServiceUnavailable || r.Fields[eal.FieldMethod] != healthpb.Health_Watch_FullMethodName {
+		t.Errorf("got %s: %v, want the stream fields", r.Level, r.Fields)
+	}
+}
@@ -0,0 +1,68 @@
+// Package ealmaxmind resolve the geo_country, geo_city, geo_asn and geo_as_org fields of the eal access log entries
+// with MaxMind DB files, like GeoLite2-City and GeoLite2-ASN, read by github.com/oschwald/maxminddb-golang:
+//
+//	city, err := maxminddb.Open("/var/lib/geoip/GeoLite2-City.mmdb")
+//	...
+//	asn, err := maxminddb.Open("/var/lib/geoip/GeoLite2-ASN.mmdb")
+//	...
+//	e.Use(eal.CreateLoggerMiddlewareWithConfig(eal.LoggerConfig{GeoIP: ealmaxmind.GeoIP{City: city, ASN: asn}}))
+package ealmaxmind
+
+import (
+	"net"
+
+	"github.com/modfin/eal"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type (
+	// GeoIP is an eal.GeoIPResolver that look up IP addresses in MaxMind DB files. The country and city are resolved
+	// with the City database (a Country database can be used as well), and the autonomous system with the ASN
+	// database. Either database can be nil.
+	GeoIP struct {
+		City *maxminddb.Reader
+		ASN  *maxminddb.Reader
+	}
+
+	// cityRecord is the part of a GeoIP2/GeoLite2 City or Country record that is logged.
+	cityRecord struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+		City struct {
+			Names struct {
+				EN string `maxminddb:"en"`
+			} `maxminddb:"names"`
+		} `maxminddb:"city"`
+	}
+
+	// asnRecord is a GeoIP2/GeoLite2 ASN record.
+	asnRecord struct {
+		Number       uint32 `maxminddb:"autonomous_system_number"`
+		Organization string `maxminddb:"autonomous_system_organization"`
+	}
+)
+
+// LookupIP implements the eal.GeoIPResolver interface. Addresses that can't be looked up, like IPv6 addresses in an
+// IPv4 database, are not found.
+func (g GeoIP) LookupIP(ip net.IP) (eal.GeoIPLocation, bool) {
+	var loc eal.GeoIPLocation
+	found := false
+	if g.City != nil {
+		var rec cityRecord
+		if _, ok, err := g.City.LookupNetwork(ip, &rec); err == nil && ok {
+			found = true
+			loc.CountryCode = rec.Country.ISOCode
+			loc.City = rec.City.Names.EN
+		}
+	}
+	if g.ASN != nil {
+		var rec asnRecord
+		if _, ok, err := g.ASN.LookupNetwork(ip, &rec); err == nil && ok {
+			found = true
+			loc.ASN = rec.Number
+			loc.ASOrganization = rec.Organization
+		}
+	}
+	return loc, found
+}
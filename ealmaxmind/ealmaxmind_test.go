@@ -0,0 +1,149 @@
+package ealmaxmind
+
+import (
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/modfin/eal"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// mmdbEncode encode a value in the MaxMind DB data section format. Maps are encoded with sorted keys.
+func mmdbEncode(v interface{}) []byte {
+	ctrl := func(typ, size int) []byte {
+		var b []byte
+		if typ <= 7 {
+			b = []byte{byte(typ << 5)}
+		} else {
+			b = []byte{0, byte(typ - 7)}
+		}
+		switch {
+		case size < 29:
+			b[0] |= byte(size)
+		case size < 285:
+			b[0] |= 29
+			b = append(b, byte(size-29))
+		default:
+			b[0] |= 30
+			b = append(b, byte((size-285)>>8), byte(size-285))
+		}
+		return b
+	}
+
+	switch v := v.(type) {
+	case string:
+		return append(ctrl(2, len(v)), v...)
+	case uint32:
+		b := binary.BigEndian.AppendUint32(nil, v)
+		return append(ctrl(6, 4), b...)
+	case uint16:
+		b := binary.BigEndian.AppendUint16(nil, v)
+		return append(ctrl(5, 2), b...)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b := ctrl(7, len(v))
+		for _, k := range keys {
+			b = append(b, mmdbEncode(k)...)
+			b = append(b, mmdbEncode(v[k])...)
+		}
+		return b
+	}
+	panic("unsupported type")
+}
+
+// openTestMMDB open an IPv4 database with 24 bit records, that only have a record for the /8 network with the first
+// byte of the IP addresses.
+func openTestMMDB(t *testing.T, first byte, rec map[string]interface{}) *maxminddb.Reader {
+	// The search tree is a chain of 8 nodes, and the unused records point at nodeCount (not found)
+	const nodeCount = 8
+	var tree []byte
+	for bit := 0; bit < 8; bit++ {
+		records := [2]uint32{nodeCount, nodeCount}
+		next := uint32(bit + 1)
+		if bit == 7 {
+			next = nodeCount + 16
+		}
+		records[(first>>(7-bit))&1] = next
+		for _, r := range records {
+			tree = append(tree, byte(r>>16), byte(r>>8), byte(r))
+		}
+	}
+
+	buf := append(tree, make([]byte, 16)...)
+	buf = append(buf, mmdbEncode(rec)...)
+	buf = append(buf, "\xab\xcd\xefMaxMind.com"...)
+	buf = append(buf, mmdbEncode(map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"database_type":               "Test-City",
+		"binary_format_major_version": uint16(2),
+	})...)
+
+	db, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestGeoIP(t *testing.T) {
+	db := openTestMMDB(t, 81, map[string]interface{}{
+		"country":                        map[string]interface{}{"iso_code": "SE"},
+		"city":                           map[string]interface{}{"names": map[string]interface{}{"en": "Stockholm", "sv": "Stockholm"}},
+		"autonomous_system_number":       uint32(3301),
+		"autonomous_system_organization": "Telia Company AB",
+	})
+
+	geo := GeoIP{City: db, ASN: db}
+	for _, tt := range []struct {
+		ip        string
+		want      eal.GeoIPLocation
+		wantFound bool
+	}{
+		{ip: "81.2.3.4", want: eal.GeoIPLocation{CountryCode: "SE", City: "Stockholm", ASN: 3301, ASOrganization: "Telia Company AB"}, wantFound: true},
+		{ip: "82.2.3.4"},
+		{ip: "2001:db8::1"},
+	} {
+		got, found := geo.LookupIP(net.ParseIP(tt.ip))
+		if found != tt.wantFound || got != tt.want {
+			t.Errorf("%s: got location: %+v, %v, want: %+v, %v", tt.ip, got, found, tt.want, tt.wantFound)
+		}
+	}
+}
+
+// recordingEmitter is an eal.Emitter that record the access log entries.
+type recordingEmitter struct {
+	records []eal.Record
+}
+
+func (e *recordingEmitter) Emit(r eal.Record) {
+	e.records = append(e.records, r)
+}
+
+func TestGeoIPFields(t *testing.T) {
+	emitter := &recordingEmitter{}
+	geo := GeoIP{City: openTestMMDB(t, 81, map[string]interface{}{"country": map[string]interface{}{"iso_code": "SE"}})}
+	e := echo.New()
+	e.Use(eal.NewLoggerMiddleware(eal.Deps{Emitter: emitter}, eal.LoggerConfig{GeoIP: geo}))
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-For", "81.2.3.4, 10.0.0.1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	if len(emitter.records) != 1 {
+		t.Fatalf("got %d access log entries, want 1", len(emitter.records))
+	}
+	if fields := emitter.records[0].Fields; fields[eal.FieldGeoCountry] != "SE" || fields[eal.FieldGeoCity] != nil {
+		t.Errorf("got fields: %v, want geo_country SE and no geo_city", fields)
+	}
+}
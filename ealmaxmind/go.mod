@@ -0,0 +1,26 @@
+module github.com/modfin/eal/ealmaxmind
+
+go 1.21
+
+require (
+	github.com/labstack/echo/v4 v4.12.0
+	github.com/modfin/eal v0.0.0-00010101000000-000000000000
+	github.com/oschwald/maxminddb-golang v1.12.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/sirupsen/logrus v1.9.3 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.22.0 // indirect
+	golang.org/x/net v0.24.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.19.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/modfin/eal => ../
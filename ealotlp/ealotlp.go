@@ -0,0 +1,331 @@
+// Package ealotlp provide an eal.Sink that export log records to an OpenTelemetry collector, using the OTLP/gRPC
+// protocol:
+//
+//	conn, err := grpc.NewClient("otel-collector:4317", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	...
+//	s := ealotlp.NewSink(conn, ealotlp.Config{Resource: map[string]string{"service.name": "users"}})
+//	defer s.Close()
+//	eal.SetSink(s)
+//
+// The records are mapped in the same way as by eal.OTLPSink, that export log records using OTLP/HTTP: well-known
+// fields are set as the OpenTelemetry semantic convention attributes (see eal.OTLPAttributeName), and the trace_id and
+// span_id fields are set as the trace context of the log records.
+package ealotlp
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modfin/eal"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// Config configure a Sink.
+	Config struct {
+		// Headers is added as metadata to each export call, for example for authentication.
+		Headers map[string]string
+
+		// Resource is the resource attributes, for example {"service.name": "users"}.
+		Resource map[string]string
+
+		// BatchSize is the maximum number of log records sent in one export call, 512 is used if not set.
+		BatchSize int
+
+		// FlushInterval is the maximum time a log record is buffered before it's exported, 5s is used if not set.
+		FlushInterval time.Duration
+
+		// QueueSize is the maximum number of buffered log records, records are dropped when the queue is full.
+		// 4096 is used if not set.
+		QueueSize int
+
+		// MaxRetries is the number of times a failed export is retried, with exponential backoff. 3 is used if not set.
+		MaxRetries int
+
+		// Timeout is the timeout of each export call, 10s is used if not set.
+		Timeout time.Duration
+	}
+
+	// Sink is an eal.Sink that export log records to an OpenTelemetry collector, using the OTLP/gRPC protocol. Records
+	// are batched, and exports that fail with a retryable status are retried.
+	Sink struct {
+		client   collogspb.LogsServiceClient
+		config   Config
+		resource *resourcepb.Resource
+		queue    chan eal.Record
+		flush    chan chan struct{}
+		done     chan struct{}
+		once     sync.Once
+		dropped  atomic.Uint64
+	}
+)
+
+// NewSink create a Sink that export log records over the connection, and start the background exporter. Close must
+// be called to flush buffered records when the application exit.
+func NewSink(conn grpc.ClientConnInterface, config Config) *Sink {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 4096
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	resource := &resourcepb.Resource{}
+	for k, v := range config.Resource {
+		resource.Attributes = append(resource.Attributes, keyValue(k, v))
+	}
+	sort.Slice(resource.Attributes, func(i, j int) bool { return resource.Attributes[i].Key < resource.Attributes[j].Key })
+
+	s := &Sink{
+		client:   collogspb.NewLogsServiceClient(conn),
+		config:   config,
+		resource: resource,
+		queue:    make(chan eal.Record, config.QueueSize),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write implements the eal.Sink interface. The record is queued for export, or dropped if the queue is full.
+func (s *Sink) Write(r eal.Record) error {
+	select {
+	case s.queue <- r:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// Dropped return the number of records that have been dropped because the queue was full, or the export failed.
+func (s *Sink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Flush export all queued records, and wait for the export to complete.
+func (s *Sink) Flush() {
+	ch := make(chan struct{})
+	select {
+	case s.flush <- ch:
+		<-ch
+	case <-s.done:
+	}
+}
+
+// Close flush all queued records, and stop the background exporter.
+func (s *Sink) Close() {
+	s.once.Do(func() {
+		s.Flush()
+		close(s.done)
+	})
+}
+
+// LogRecord return the OTLP log record of an eal record.
+func LogRecord(r eal.Record) *logspb.LogRecord {
+	lr := &logspb.LogRecord{
+		TimeUnixNano:   uint64(r.Time.UnixNano()),
+		SeverityNumber: logspb.SeverityNumber(eal.OTLPSeverityNumber(r.Level)),
+		SeverityText:   r.Level.String(),
+		Body:           anyValue(r.Message),
+	}
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := r.Fields[k]
+		switch k {
+		case eal.FieldTraceID:
+			if id, err := hex.DecodeString(fmt.Sprint(v)); err == nil && len(id) == 16 {
+				lr.TraceId = id
+				continue
+			}
+		case eal.FieldSpanID:
+			if id, err := hex.DecodeString(fmt.Sprint(v)); err == nil && len(id) == 8 {
+				lr.SpanId = id
+				continue
+			}
+		}
+		lr.Attributes = append(lr.Attributes, keyValue(eal.OTLPAttributeName(k), v))
+	}
+	return lr
+}
+
+func (s *Sink) run() {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*logspb.LogRecord, 0, s.config.BatchSize)
+	export := func() {
+		if len(batch) > 0 {
+			s.export(batch)
+			batch = make([]*logspb.LogRecord, 0, s.config.BatchSize)
+		}
+	}
+
+	for {
+		select {
+		case r := <-s.queue:
+			batch = append(batch, LogRecord(r))
+			if len(batch) >= s.config.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ch := <-s.flush:
+			for len(s.queue) > 0 {
+				batch = append(batch, LogRecord(<-s.queue))
+				if len(batch) >= s.config.BatchSize {
+					export()
+				}
+			}
+			export()
+			close(ch)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// export send the batch to the collector, retrying retryable failures with exponential backoff.
+func (s *Sink) export(batch []*logspb.LogRecord) {
+	req := &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: s.resource,
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: "github.com/modfin/eal"},
+				LogRecords: batch,
+			}},
+		}},
+	}
+
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		if len(s.config.Headers) > 0 {
+			ctx = metadata.NewOutgoingContext(ctx, metadata.New(s.config.Headers))
+		}
+		_, err := s.client.Export(ctx, req)
+		cancel()
+		if err == nil {
+			return
+		}
+		if !retryable(err) || attempt >= s.config.MaxRetries {
+			s.dropped.Add(uint64(len(batch)))
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// retryable report if a failed export should be retried, see the OTLP specification.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.OutOfRange,
+		codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+func keyValue(k string, v interface{}) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: anyValue(v)}
+}
+
+func anyValue(v interface{}) *commonpb.AnyValue {
+	switch t := v.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: t}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: t}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case int8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case int16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case int32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: t}}
+	case uint8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case uint16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case uint32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(t)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: t}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: t}}
+	case error:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: t.Error()}}
+	case eal.Fields:
+		return kvlist(t)
+	case map[string]interface{}:
+		return kvlist(t)
+	case []interface{}:
+		arr := &commonpb.ArrayValue{Values: make([]*commonpb.AnyValue, 0, len(t))}
+		for _, item := range t {
+			arr.Values = append(arr.Values, anyValue(item))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: arr}}
+	case []string:
+		arr := &commonpb.ArrayValue{Values: make([]*commonpb.AnyValue, 0, len(t))}
+		for _, item := range t {
+			arr.Values = append(arr.Values, anyValue(item))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: arr}}
+	case nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{}}
+	default:
+		// Use the JSON representation for other types, including uint and uint64 that may overflow an int64
+		b, err := json.Marshal(t)
+		s := string(b)
+		if err != nil {
+			s = fmt.Sprint(t)
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+	}
+}
+
+func kvlist(m map[string]interface{}) *commonpb.AnyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kv := &commonpb.KeyValueList{Values: make([]*commonpb.KeyValue, 0, len(m))}
+	for _, k := range keys {
+		kv.Values = append(kv.Values, keyValue(k, m[k]))
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: kv}}
+}
@@ -0,0 +1,98 @@
+package ealotlp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modfin/eal"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// collector record the exported log records, and fail the first export with an unavailable status.
+type collector struct {
+	collogspb.UnimplementedLogsServiceServer
+	mu       sync.Mutex
+	calls    int
+	auth     []string
+	resource string
+	records  []*logspb.LogRecord
+}
+
+func (c *collector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.calls == 1 {
+		return nil, status.Error(codes.Unavailable, "starting")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.auth = md.Get("authorization")
+	for _, rl := range req.ResourceLogs {
+		c.resource = rl.Resource.Attributes[0].Value.GetStringValue()
+		for _, sl := range rl.ScopeLogs {
+			c.records = append(c.records, sl.LogRecords...)
+		}
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func TestSink(t *testing.T) {
+	c := &collector{}
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(s, c)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	sink := NewSink(conn, Config{
+		Headers:       map[string]string{"authorization": "Bearer token"},
+		Resource:      map[string]string{"service.name": "users"},
+		FlushInterval: time.Hour,
+	})
+	now := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	sink.Write(eal.Record{Time: now, Level: eal.ErrorLevel, Message: "access", Fields: eal.Fields{
+		eal.FieldStatus:  http.StatusInternalServerError,
+		eal.FieldTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		eal.FieldSpanID:  "00f067aa0ba902b7",
+		"user":           eal.Fields{"id": 7},
+	}})
+	sink.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.records) != 1 || sink.Dropped() != 0 {
+		t.Fatalf("got %d records, %d dropped, want 1 record", len(c.records), sink.Dropped())
+	}
+	if c.calls != 2 || len(c.auth) != 1 || c.auth[0] != "Bearer token" || c.resource != "users" {
+		t.Errorf("got %d calls, authorization: %v, resource: %s, want a retried export with the headers and resource", c.calls, c.auth, c.resource)
+	}
+
+	lr := c.records[0]
+	if lr.TimeUnixNano != uint64(now.UnixNano()) || lr.SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_ERROR ||
+		lr.Body.GetStringValue() != "access" || len(lr.TraceId) != 16 || len(lr.SpanId) != 8 {
+		t.Errorf("got record: %v, want error 'access' with trace context", lr)
+	}
+	if len(lr.Attributes) != 2 || lr.Attributes[0].Key != "http.response.status_code" || lr.Attributes[0].Value.GetIntValue() != 500 ||
+		lr.Attributes[1].Key != "user" || lr.Attributes[1].Value.GetKvlistValue().Values[0].Value.GetIntValue() != 7 {
+		t.Errorf("got attributes: %v, want status code and user", lr.Attributes)
+	}
+}
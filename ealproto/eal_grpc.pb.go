@@ -0,0 +1,125 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: eal.proto
+
+package ealproto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LogForwarder_Forward_FullMethodName = "/eal.v1.LogForwarder/Forward"
+)
+
+// LogForwarderClient is the client API for LogForwarder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LogForwarder receive log entries forwarded by services, see ForwardSink.
+type LogForwarderClient interface {
+	Forward(ctx context.Context, in *ForwardRequest, opts ...grpc.CallOption) (*ForwardResponse, error)
+}
+
+type logForwarderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogForwarderClient(cc grpc.ClientConnInterface) LogForwarderClient {
+	return &logForwarderClient{cc}
+}
+
+func (c *logForwarderClient) Forward(ctx context.Context, in *ForwardRequest, opts ...grpc.CallOption) (*ForwardResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForwardResponse)
+	err := c.cc.Invoke(ctx, LogForwarder_Forward_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogForwarderServer is the server API for LogForwarder service.
+// All implementations must embed UnimplementedLogForwarderServer
+// for forward compatibility.
+//
+// LogForwarder receive log entries forwarded by services, see ForwardSink.
+type LogForwarderServer interface {
+	Forward(context.Context, *ForwardRequest) (*ForwardResponse, error)
+	mustEmbedUnimplementedLogForwarderServer()
+}
+
+// UnimplementedLogForwarderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogForwarderServer struct{}
+
+func (UnimplementedLogForwarderServer) Forward(context.Context, *ForwardRequest) (*ForwardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Forward not implemented")
+}
+func (UnimplementedLogForwarderServer) mustEmbedUnimplementedLogForwarderServer() {}
+func (UnimplementedLogForwarderServer) testEmbeddedByValue()                      {}
+
+// UnsafeLogForwarderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogForwarderServer will
+// result in compilation errors.
+type UnsafeLogForwarderServer interface {
+	mustEmbedUnimplementedLogForwarderServer()
+}
+
+func RegisterLogForwarderServer(s grpc.ServiceRegistrar, srv LogForwarderServer) {
+	// If the following call pancis, it indicates UnimplementedLogForwarderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LogForwarder_ServiceDesc, srv)
+}
+
+func _LogForwarder_Forward_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForwardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogForwarderServer).Forward(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogForwarder_Forward_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogForwarderServer).Forward(ctx, req.(*ForwardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LogForwarder_ServiceDesc is the grpc.ServiceDesc for LogForwarder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogForwarder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "eal.v1.LogForwarder",
+	HandlerType: (*LogForwarderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Forward",
+			Handler:    _LogForwarder_Forward_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "eal.proto",
+}
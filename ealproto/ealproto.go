@@ -0,0 +1,154 @@
+// Package ealproto define a protobuf schema for eal log entries (eal.proto), with helpers to convert between
+// eal.Record and Entry, and a ForwardSink that forward log entries to an aggregator over gRPC:
+//
+//	conn, err := grpc.NewClient("log-aggregator:9000", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	...
+//	s := ealproto.NewForwardSink(conn, ealproto.ForwardConfig{})
+//	defer s.Close()
+//	eal.SetSink(s)
+//
+// The aggregator implement the LogForwarder service, for example with NewLogForwarderServer that write the entries to
+// an eal.Sink.
+package ealproto
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative eal.proto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modfin/eal"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// forwarderServer is a LogForwarderServer that write the entries to a sink.
+type forwarderServer struct {
+	UnimplementedLogForwarderServer
+	sink eal.Sink
+}
+
+// FromRecord return the Entry of a log record. The request fields of access log entries, i.e. entries with an int
+// status field, are set in the AccessEvent, and the other fields are converted to protobuf values: numbers, strings,
+// booleans, nil, maps and slices are converted directly, errors are converted to their message, and other values are
+// converted through their JSON representation.
+func FromRecord(r eal.Record) *Entry {
+	e := &Entry{Time: timestamppb.New(r.Time), Level: Level(r.Level), Message: r.Message}
+
+	fields := r.Fields
+	if status, ok := fields[eal.FieldStatus].(int); ok {
+		fields = make(eal.Fields, len(r.Fields))
+		for k, v := range r.Fields {
+			fields[k] = v
+		}
+		e.Access = &AccessEvent{Status: int32(status)}
+		delete(fields, eal.FieldStatus)
+		for name, field := range map[string]*string{
+			eal.FieldRequestID: &e.Access.RequestId, eal.FieldRemoteAddr: &e.Access.RemoteAddr, eal.FieldHost: &e.Access.Host,
+			eal.FieldMethod: &e.Access.Method, eal.FieldURI: &e.Access.Uri, eal.FieldRouterPath: &e.Access.RouterPath,
+		} {
+			if s, ok := fields[name].(string); ok {
+				*field = s
+				delete(fields, name)
+			}
+		}
+		if ms, ok := fields[eal.FieldLatencyMs].(int64); ok {
+			e.Access.LatencyMs = ms
+			delete(fields, eal.FieldLatencyMs)
+		}
+	}
+
+	e.Fields = &structpb.Struct{Fields: make(map[string]*structpb.Value, len(fields))}
+	for k, v := range fields {
+		e.Fields.Fields[k] = value(v)
+	}
+	return e
+}
+
+// Record return the log record of the entry. Numbers in the fields are float64s, except the status and latency_ms
+// fields of access log entries, which are an int and an int64 as in the entries written by eal. The record have a
+// background context.
+func (e *Entry) Record() eal.Record {
+	r := eal.Record{
+		Time:    e.GetTime().AsTime(),
+		Level:   eal.Level(e.GetLevel()),
+		Message: e.GetMessage(),
+		Fields:  eal.Fields(e.GetFields().AsMap()),
+		Context: context.Background(),
+	}
+
+	if a := e.GetAccess(); a != nil {
+		r.Fields[eal.FieldStatus] = int(a.Status)
+		r.Fields[eal.FieldLatencyMs] = a.LatencyMs
+		for name, field := range map[string]string{
+			eal.FieldRequestID: a.RequestId, eal.FieldRemoteAddr: a.RemoteAddr, eal.FieldHost: a.Host,
+			eal.FieldMethod: a.Method, eal.FieldURI: a.Uri, eal.FieldRouterPath: a.RouterPath,
+		} {
+			if field != "" {
+				r.Fields[name] = field
+			}
+		}
+	}
+	return r
+}
+
+// Marshal return the protobuf encoding of the Entry of a log record.
+func Marshal(r eal.Record) ([]byte, error) {
+	return proto.Marshal(FromRecord(r))
+}
+
+// Unmarshal return the log record of a protobuf encoded Entry.
+func Unmarshal(b []byte) (eal.Record, error) {
+	var e Entry
+	if err := proto.Unmarshal(b, &e); err != nil {
+		return eal.Record{}, err
+	}
+	return e.Record(), nil
+}
+
+// NewLogForwarderServer return a LogForwarderServer that write the forwarded entries to the sink, for example to
+// eal.NewLogrusSink(logger) in a log aggregator:
+//
+//	ealproto.RegisterLogForwarderServer(s, ealproto.NewLogForwarderServer(eal.NewLogrusSink(logger)))
+func NewLogForwarderServer(sink eal.Sink) LogForwarderServer {
+	return &forwarderServer{sink: sink}
+}
+
+func (s *forwarderServer) Forward(ctx context.Context, req *ForwardRequest) (*ForwardResponse, error) {
+	for _, e := range req.GetEntries() {
+		if err := s.sink.Write(e.Record()); err != nil {
+			return nil, err
+		}
+	}
+	return &ForwardResponse{}, nil
+}
+
+// value return the protobuf value of a field value, see FromRecord.
+func value(v interface{}) *structpb.Value {
+	switch t := v.(type) {
+	case error:
+		return structpb.NewStringValue(t.Error())
+	case eal.Fields:
+		v = map[string]interface{}(t)
+	}
+	if pv, err := structpb.NewValue(v); err == nil {
+		return pv
+	}
+
+	// Use the JSON representation for other types
+	b, err := json.Marshal(v)
+	if err != nil {
+		return structpb.NewStringValue(fmt.Sprint(v))
+	}
+	var jv interface{}
+	if err := json.Unmarshal(b, &jv); err != nil {
+		return structpb.NewStringValue(string(b))
+	}
+	pv, err := structpb.NewValue(jv)
+	if err != nil {
+		return structpb.NewStringValue(string(b))
+	}
+	return pv
+}
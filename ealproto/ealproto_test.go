@@ -0,0 +1,59 @@
+package ealproto
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/modfin/eal"
+)
+
+func TestMarshal(t *testing.T) {
+	now := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name   string
+		record eal.Record
+		want   eal.Fields
+	}{
+		{
+			name: "access",
+			record: eal.Record{Time: now, Level: eal.WarnLevel, Message: "access", Fields: eal.Fields{
+				eal.FieldRequestID: "req-1", eal.FieldMethod: http.MethodGet, eal.FieldURI: "/users/1",
+				eal.FieldRouterPath: "/users/:id", eal.FieldStatus: http.StatusNotFound, eal.FieldLatencyMs: int64(12),
+				"user_id": 7, "tags": []string{"a", "b"},
+			}},
+			want: eal.Fields{
+				eal.FieldRequestID: "req-1", eal.FieldMethod: http.MethodGet, eal.FieldURI: "/users/1",
+				eal.FieldRouterPath: "/users/:id", eal.FieldStatus: http.StatusNotFound, eal.FieldLatencyMs: int64(12),
+				"user_id": float64(7), "tags": []interface{}{"a", "b"},
+			},
+		},
+		{
+			name: "entry",
+			record: eal.Record{Time: now, Level: eal.ErrorLevel, Message: "reindex failed", Fields: eal.Fields{
+				eal.FieldErrorMessage: "db down", "cause": errors.New("timeout"), "nested": eal.Fields{"n": true},
+			}},
+			want: eal.Fields{eal.FieldErrorMessage: "db down", "cause": "timeout", "nested": map[string]interface{}{"n": true}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Marshal(tt.record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Unmarshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !got.Time.Equal(tt.record.Time) || got.Level != tt.record.Level || got.Message != tt.record.Message {
+				t.Errorf("got record: %+v, want: %+v", got, tt.record)
+			}
+			if !reflect.DeepEqual(got.Fields, tt.want) {
+				t.Errorf("got fields: %#v, want: %#v", got.Fields, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package ealproto
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modfin/eal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// ForwardConfig configure a ForwardSink.
+	ForwardConfig struct {
+		// BatchSize is the maximum number of entries sent in one Forward call, 512 is used if not set.
+		BatchSize int
+
+		// FlushInterval is the maximum time an entry is buffered before it's forwarded, 5s is used if not set.
+		FlushInterval time.Duration
+
+		// QueueSize is the maximum number of buffered entries, entries are dropped when the queue is full. 4096 is used
+		// if not set.
+		QueueSize int
+
+		// MaxRetries is the number of times a failed Forward call is retried, with exponential backoff. 3 is used if not
+		// set.
+		MaxRetries int
+
+		// Timeout is the timeout of each Forward call, 10s is used if not set.
+		Timeout time.Duration
+	}
+
+	// ForwardSink is an eal.Sink that forward log entries to an aggregator that implement the LogForwarder service.
+	// Entries are batched, and calls that fail with a retryable status (Unavailable, ResourceExhausted, Aborted or
+	// DeadlineExceeded) are retried.
+	ForwardSink struct {
+		client  LogForwarderClient
+		config  ForwardConfig
+		queue   chan eal.Record
+		flush   chan chan struct{}
+		done    chan struct{}
+		once    sync.Once
+		dropped atomic.Uint64
+	}
+)
+
+// NewForwardSink create a ForwardSink that forward entries over the connection, and start the background forwarder.
+// Close must be called to flush buffered entries when the application exit.
+func NewForwardSink(conn grpc.ClientConnInterface, config ForwardConfig) *ForwardSink {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 4096
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	s := &ForwardSink{
+		client: NewLogForwarderClient(conn),
+		config: config,
+		queue:  make(chan eal.Record, config.QueueSize),
+		flush:  make(chan chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write implements the eal.Sink interface. The entry is queued to be forwarded, or dropped if the queue is full.
+func (s *ForwardSink) Write(r eal.Record) error {
+	select {
+	case s.queue <- r:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// Dropped return the number of entries that have been dropped because the queue was full, or the Forward call failed.
+func (s *ForwardSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Flush forward all queued entries, and wait for the Forward calls to complete.
+func (s *ForwardSink) Flush() {
+	ch := make(chan struct{})
+	select {
+	case s.flush <- ch:
+		<-ch
+	case <-s.done:
+	}
+}
+
+// Close flush all queued entries, and stop the background forwarder.
+func (s *ForwardSink) Close() {
+	s.once.Do(func() {
+		s.Flush()
+		close(s.done)
+	})
+}
+
+func (s *ForwardSink) run() {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Entry, 0, s.config.BatchSize)
+	forward := func() {
+		if len(batch) > 0 {
+			s.forward(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case r := <-s.queue:
+			batch = append(batch, FromRecord(r))
+			if len(batch) >= s.config.BatchSize {
+				forward()
+			}
+		case <-ticker.C:
+			forward()
+		case ch := <-s.flush:
+			for len(s.queue) > 0 {
+				batch = append(batch, FromRecord(<-s.queue))
+				if len(batch) >= s.config.BatchSize {
+					forward()
+				}
+			}
+			forward()
+			close(ch)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// forward send the batch to the aggregator, retrying retryable failures with exponential backoff.
+func (s *ForwardSink) forward(batch []*Entry) {
+	req := &ForwardRequest{Entries: batch}
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		_, err := s.client.Forward(ctx, req)
+		cancel()
+		if err == nil {
+			return
+		}
+		if !retryable(err) || attempt >= s.config.MaxRetries {
+			s.dropped.Add(uint64(len(batch)))
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// retryable report if a failed Forward call should be retried.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
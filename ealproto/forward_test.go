@@ -0,0 +1,86 @@
+package ealproto
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modfin/eal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type (
+	recordingSink struct {
+		mu      sync.Mutex
+		records []eal.Record
+	}
+
+	// flakyServer fail the first Forward call with an unavailable status.
+	flakyServer struct {
+		LogForwarderServer
+		mu    sync.Mutex
+		calls int
+	}
+)
+
+func (s *recordingSink) Write(r eal.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *flakyServer) Forward(ctx context.Context, req *ForwardRequest) (*ForwardResponse, error) {
+	s.mu.Lock()
+	s.calls++
+	first := s.calls == 1
+	s.mu.Unlock()
+	if first {
+		return nil, status.Error(codes.Unavailable, "starting")
+	}
+	return s.LogForwarderServer.Forward(ctx, req)
+}
+
+func TestForwardSink(t *testing.T) {
+	sink := &recordingSink{}
+	server := &flakyServer{LogForwarderServer: NewLogForwarderServer(sink)}
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterLogForwarderServer(s, server)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	fs := NewForwardSink(conn, ForwardConfig{BatchSize: 2, FlushInterval: time.Hour})
+	for _, msg := range []string{"one", "two", "three"} {
+		fs.Write(eal.Record{Time: time.Now(), Level: eal.InfoLevel, Message: msg, Fields: eal.Fields{"n": msg}})
+	}
+	fs.Close()
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.records) != 3 || fs.Dropped() != 0 {
+		t.Fatalf("got %d records, %d dropped, want 3 records", len(sink.records), fs.Dropped())
+	}
+	for i, want := range []string{"one", "two", "three"} {
+		if r := sink.records[i]; r.Message != want || r.Fields["n"] != want {
+			t.Errorf("got record %d: %+v, want message %s", i, r, want)
+		}
+	}
+	if server.calls != 3 {
+		t.Errorf("got %d Forward calls, want 3 (a retried batch and a flushed batch)", server.calls)
+	}
+}
@@ -0,0 +1,66 @@
+// Package ealredis share the access log sampling decisions of eal between instances with Redis (version 7 or later),
+// so that requests with the same request ID are either all logged or all dropped:
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: "redis:6379", Password: os.Getenv("REDIS_PASSWORD")})
+//	e.Use(eal.CreateLoggerMiddlewareWithConfig(eal.LoggerConfig{
+//	  Sampling: eal.SamplingConfig{Rate: 100, Coordinator: &ealredis.SamplingCoordinator{Client: rdb}},
+//	}))
+package ealredis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SamplingCoordinator is an eal.SamplingCoordinator that store the sampling decisions in Redis, with a single
+// SET NX GET command per decision.
+type SamplingCoordinator struct {
+	// Client is the Redis client, for example a *redis.Client or a *redis.ClusterClient. The connections are pooled
+	// by the client, which is not closed by the SamplingCoordinator.
+	Client redis.Cmdable
+
+	// KeyPrefix is prepended to the request IDs, "eal:sampled:" is used if KeyPrefix isn't set.
+	KeyPrefix string
+
+	// TTL is how long the decisions are stored, ten minutes is used if TTL isn't set.
+	TTL time.Duration
+
+	// Timeout limit the time that a decision may take, 100 milliseconds is used if Timeout isn't set. The timeout
+	// apply in addition to the deadline of the request context, if any.
+	Timeout time.Duration
+}
+
+// Decide implements the eal.SamplingCoordinator interface.
+func (sc *SamplingCoordinator) Decide(ctx context.Context, key string, sampled bool) (bool, error) {
+	prefix := sc.KeyPrefix
+	if prefix == "" {
+		prefix = "eal:sampled:"
+	}
+	ttl := sc.TTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	timeout := sc.Timeout
+	if timeout <= 0 {
+		timeout = 100 * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	value := "0"
+	if sampled {
+		value = "1"
+	}
+	reply, err := sc.Client.SetArgs(ctx, prefix+key, value, redis.SetArgs{Mode: "NX", Get: true, TTL: ttl}).Result()
+	if errors.Is(err, redis.Nil) {
+		// The key didn't exist, so the proposed decision was stored
+		return sampled, nil
+	}
+	if err != nil {
+		return sampled, err
+	}
+	return reply == "1", nil
+}
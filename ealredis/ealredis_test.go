@@ -0,0 +1,44 @@
+package ealredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/modfin/eal"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ eal.SamplingCoordinator = (*SamplingCoordinator)(nil)
+
+func TestSamplingCoordinator(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	sc := &SamplingCoordinator{Client: rdb, TTL: time.Minute}
+
+	for _, tt := range []struct {
+		key      string
+		proposed bool
+		want     bool
+	}{
+		{key: "r1", proposed: true, want: true},
+		{key: "r1", proposed: false, want: true},
+		{key: "r2", proposed: false, want: false},
+		{key: "r2", proposed: true, want: false},
+	} {
+		got, err := sc.Decide(context.Background(), tt.key, tt.proposed)
+		if err != nil || got != tt.want {
+			t.Errorf("Decide(%s, %v): got %v, %v, want: %v", tt.key, tt.proposed, got, err, tt.want)
+		}
+	}
+	if ttl := mr.TTL("eal:sampled:r1"); ttl != time.Minute {
+		t.Errorf("got TTL: %v, want: %v", ttl, time.Minute)
+	}
+
+	mr.Close()
+	if got, err := sc.Decide(context.Background(), "r3", true); err == nil || !got {
+		t.Errorf("got %v, %v, want the proposed decision and an error", got, err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package ealsentry report server errors logged by eal to Sentry:
+//
+//	sentry.Init(sentry.ClientOptions{Dsn: dsn})
+//	eal.RegisterErrorReporter(ealsentry.Reporter(nil))
+//
+// The events have the stacktrace of the ErrorStackTrace (or PanicError) in the error chain, the request fields as tags
+// and extra data, and are grouped by the eal error fingerprint.
+package ealsentry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/modfin/eal"
+)
+
+// TagFields is the log fields that are set as Sentry tags, which can be searched and filtered on. All other fields,
+// except the stacktraces, are set as extra data.
+var TagFields = []string{
+	eal.FieldRequestID, eal.FieldMethod, eal.FieldRouterPath, eal.FieldStatus, eal.FieldErrorType,
+	eal.FieldErrorCodeName, eal.FieldErrorOrigin, eal.FieldTraceID,
+}
+
+// Reporter return an eal.ErrorReporter that send the errors to Sentry through the hub, or sentry.CurrentHub() if hub
+// is nil.
+func Reporter(hub *sentry.Hub) eal.ErrorReporter {
+	return func(err error, fields eal.Fields) {
+		h := hub
+		if h == nil {
+			h = sentry.CurrentHub()
+		}
+		h.CaptureEvent(Event(err, fields))
+	}
+}
+
+// Event return the Sentry event of an error and its log fields.
+func Event(err error, fields eal.Fields) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Message = err.Error()
+
+	excType, _ := fields[eal.FieldErrorType].(string)
+	if excType == "" {
+		excType = fmt.Sprintf("%T", err)
+	}
+	exc := sentry.Exception{Type: excType, Value: err.Error()}
+	var pe *eal.PanicError
+	if st, ok := eal.GetErrorStackTrace(err); ok && st.Stack() != "" {
+		exc.Stacktrace = ParseStack(st.Stack())
+	} else if errors.As(err, &pe) {
+		exc.Stacktrace = ParseStack(pe.Stack())
+	}
+	event.Exception = []sentry.Exception{exc}
+
+	if fp := eal.Fingerprint(fields); fp != "" {
+		event.Fingerprint = []string{fp}
+	}
+	if method, ok := fields[eal.FieldMethod].(string); ok {
+		uri, _ := fields[eal.FieldURI].(string)
+		event.Request = &sentry.Request{Method: method, URL: uri}
+	}
+
+	for k, v := range fields {
+		switch {
+		case k == eal.FieldErrorStack || k == eal.FieldSpawnStack:
+		case contains(TagFields, k):
+			event.Tags[k] = fmt.Sprint(v)
+		default:
+			event.Extra[k] = v
+		}
+	}
+	return event
+}
+
+// ParseStack convert a stacktrace to a Sentry stacktrace, with the frames in the order that Sentry expect (the outermost
+// caller first). Both the stacktraces of eal.Trace and eal.Go, which start with "goroutine [running]:" and have "(...)"
+// in place of the function arguments, and the runtime/debug.Stack stacktraces of recovered panics are parsed. The
+// runtime/debug.Stack frame itself is skipped, and lines that aren't frames, like "...additional frames elided...",
+// are ignored.
+func ParseStack(stack string) *sentry.Stacktrace {
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+	var frames []sentry.Frame
+	for i := 1; i+1 < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "\t") || !strings.HasPrefix(lines[i+1], "\t") {
+			continue
+		}
+		function := strings.TrimPrefix(lines[i], "created by ")
+		if j := strings.Index(function, " in goroutine "); j >= 0 {
+			function = function[:j]
+		}
+		if strings.HasSuffix(function, ")") {
+			if j := strings.LastIndex(function, "("); j > 0 {
+				function = function[:j]
+			}
+		}
+		location := strings.TrimSpace(lines[i+1])
+		i++
+		if function == "runtime/debug.Stack" {
+			continue
+		}
+
+		if j := strings.LastIndex(location, " +0x"); j >= 0 {
+			location = location[:j]
+		}
+		file, line := location, 0
+		if j := strings.LastIndex(location, ":"); j >= 0 {
+			file = location[:j]
+			line, _ = strconv.Atoi(location[j+1:])
+		}
+
+		module, name := splitFunction(function)
+		frames = append(frames, sentry.Frame{
+			Function: name,
+			Module:   module,
+			AbsPath:  file,
+			Filename: file[strings.LastIndex(file, "/")+1:],
+			Lineno:   line,
+			InApp:    isInApp(module),
+		})
+	}
+
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// splitFunction split a function name like "github.com/modfin/eal.(*Entry).WithError" into the package path and the
+// function name.
+func splitFunction(function string) (string, string) {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot], function[slash+1+dot+1:]
+	}
+	return "", function
+}
+
+// isInApp report if the package belong to the application, i.e. isn't part of the standard library or eal.
+func isInApp(module string) bool {
+	first, _, _ := strings.Cut(module, "/")
+	return strings.Contains(first, ".") && module != "github.com/modfin/eal"
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package ealsentry
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/modfin/eal"
+)
+
+func TestReporter(t *testing.T) {
+	transport := &sentry.MockTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Reporter(sentry.NewHub(client, sentry.NewScope()))
+
+	fields := eal.Fields{
+		eal.FieldRequestID: "req-1",
+		eal.FieldMethod:    http.MethodGet,
+		eal.FieldURI:       "/users/1",
+		eal.FieldStatus:    http.StatusInternalServerError,
+		"tenant":           "acme",
+	}
+	traced := eal.Trace(errors.New("db down"))
+	eal.UnwrapError(traced, fields)
+	report(traced, fields)
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Tags[eal.FieldRequestID] != "req-1" || event.Tags[eal.FieldStatus] != "500" || event.Extra["tenant"] != "acme" {
+		t.Errorf("got tags: %v, extra: %v, want request fields", event.Tags, event.Extra)
+	}
+	if _, ok := event.Extra[eal.FieldErrorStack]; ok {
+		t.Error("got error_stack in extra data, want it only in the exception stacktrace")
+	}
+	if event.Request == nil || event.Request.URL != "/users/1" {
+		t.Errorf("got request: %+v, want GET /users/1", event.Request)
+	}
+
+	frames := event.Exception[0].Stacktrace.Frames
+	var inApp []sentry.Frame
+	for _, f := range frames {
+		if f.InApp {
+			inApp = append(inApp, f)
+		}
+	}
+	if len(inApp) == 0 || inApp[len(inApp)-1].Function != "TestReporter" || inApp[len(inApp)-1].Lineno == 0 {
+		t.Errorf("got frames: %+v, want TestReporter as the innermost in-app frame", frames)
+	}
+}
+
+func TestParseStack(t *testing.T) {
+	stack := `goroutine 7 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+github.com/modfin/eal.Trace({0xc4a8f0, 0xca7c20?})
+	/src/eal/errorstacktrace.go:134 +0x24d
+example.com/app/users.(*Service).Load(...)
+	/src/app/users/service.go:42
+created by example.com/app/jobs.Start in goroutine 1
+	/src/app/jobs/jobs.go:17 +0xb9
+`
+	want := []sentry.Frame{
+		{Function: "Start", Module: "example.com/app/jobs", AbsPath: "/src/app/jobs/jobs.go", Filename: "jobs.go", Lineno: 17, InApp: true},
+		{Function: "(*Service).Load", Module: "example.com/app/users", AbsPath: "/src/app/users/service.go", Filename: "service.go", Lineno: 42, InApp: true},
+		{Function: "Trace", Module: "github.com/modfin/eal", AbsPath: "/src/eal/errorstacktrace.go", Filename: "errorstacktrace.go", Lineno: 134},
+	}
+	assertFrames(t, ParseStack(stack).Frames, want)
+}
+
+func TestParseTraceStack(t *testing.T) {
+	stack := `goroutine [running]:
+example.com/app/users.(*Service).Load(...)
+	/src/app/users/service.go:42 +0x4f
+...additional frames elided...
+example.com/app/users.(*Handler).Get(...)
+	/src/app/users/handler.go:17 +0x2b
+`
+	want := []sentry.Frame{
+		{Function: "(*Handler).Get", Module: "example.com/app/users", AbsPath: "/src/app/users/handler.go", Filename: "handler.go", Lineno: 17, InApp: true},
+		{Function: "(*Service).Load", Module: "example.com/app/users", AbsPath: "/src/app/users/service.go", Filename: "service.go", Lineno: 42, InApp: true},
+	}
+	assertFrames(t, ParseStack(stack).Frames, want)
+
+	st, _ := eal.GetErrorStackTrace(eal.Trace(errors.New("db down")))
+	frames := ParseStack(st.Stack()).Frames
+	if len(frames) == 0 || frames[len(frames)-1].Function != "TestParseTraceStack" || frames[len(frames)-1].Lineno == 0 {
+		t.Errorf("got frames: %+v, want TestParseTraceStack as the innermost frame", frames)
+	}
+}
+
+func assertFrames(t *testing.T, got, want []sentry.Frame) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d frames: %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i].Function != want[i].Function || got[i].Module != want[i].Module || got[i].AbsPath != want[i].AbsPath ||
+			got[i].Filename != want[i].Filename || got[i].Lineno != want[i].Lineno || got[i].InApp != want[i].InApp {
+			t.Errorf("got frame %d: %+v, want: %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package ealzap write the log entries of eal with zap, so that services can use the error unwrapping and access
+// logging of eal with the encoders of zap:
+//
+//	logger, _ := zap.NewProduction()
+//	eal.SetSink(ealzap.NewSink(logger))
+//
+// Errors can also be added to entries written directly with zap, with the error fields of eal.UnwrapError:
+//
+//	logger.Error("load failed", ealzap.Error(err))
+package ealzap
+
+import (
+	"sort"
+
+	"github.com/modfin/eal"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	sink struct {
+		core zapcore.Core
+	}
+
+	// errorFields is a zapcore.ObjectMarshaler that add the fields of eal.UnwrapError.
+	errorFields struct {
+		err error
+	}
+)
+
+// NewSink return an eal.Sink that write log entries to the core of the logger. The entries are written with the time,
+// level and message of the eal entry, and the fields are added in sorted order. The hooks of the logger, like the exit
+// on fatal level, aren't run, that is done by eal.
+func NewSink(logger *zap.Logger) eal.Sink {
+	return &sink{core: logger.Core()}
+}
+
+// Level return the zap level of an eal level. eal.TraceLevel is mapped to zapcore.DebugLevel, since zap don't have a
+// trace level.
+func Level(level eal.Level) zapcore.Level {
+	switch level {
+	case eal.PanicLevel:
+		return zapcore.PanicLevel
+	case eal.FatalLevel:
+		return zapcore.FatalLevel
+	case eal.ErrorLevel:
+		return zapcore.ErrorLevel
+	case eal.WarnLevel:
+		return zapcore.WarnLevel
+	case eal.InfoLevel:
+		return zapcore.InfoLevel
+	}
+	return zapcore.DebugLevel
+}
+
+// Fields return the eal fields as zap fields, in sorted order.
+func Fields(fields eal.Fields) []zap.Field {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	zf := make([]zap.Field, len(keys))
+	for i, k := range keys {
+		zf[i] = zap.Any(k, fields[k])
+	}
+	return zf
+}
+
+// Error return a zap field that add the fields of eal.UnwrapError for the error chain of err, inlined in the entry.
+// Nothing is added if err is nil.
+func Error(err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Inline(errorFields{err: err})
+}
+
+func (s *sink) Write(r eal.Record) error {
+	ce := s.core.Check(zapcore.Entry{Level: Level(r.Level), Time: r.Time, Message: r.Message}, nil)
+	if ce == nil {
+		return nil
+	}
+	ce.Write(Fields(r.Fields)...)
+	return nil
+}
+
+// MarshalLogObject implements the zapcore.ObjectMarshaler interface.
+func (e errorFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	fields := eal.Fields{}
+	eal.UnwrapError(e.err, fields)
+	for _, f := range Fields(fields) {
+		f.AddTo(enc)
+	}
+	return nil
+}
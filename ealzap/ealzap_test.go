@@ -0,0 +1,69 @@
+package ealzap
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/modfin/eal"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSink(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	eal.SetSink(NewSink(zap.New(core)))
+	t.Cleanup(func() { eal.SetSink(nil) })
+
+	eal.NewEntry().WithFields(eal.Fields{"user_id": 7}).Info("loaded")
+	eal.NewEntry().Debug("not logged")
+	eal.NewEntry().WithError(eal.NewHTTPError(errors.New("no such user"), http.StatusNotFound)).Warn("lookup failed")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if got := entries[0]; got.Message != "loaded" || got.Level != zapcore.InfoLevel || got.ContextMap()["user_id"] != int64(7) {
+		t.Errorf("got first entry: %+v, want info 'loaded' with user_id", got)
+	}
+	if got := entries[1]; got.Level != zapcore.WarnLevel || got.ContextMap()[eal.FieldErrorMessage] == nil {
+		t.Errorf("got second entry: %+v, want warning with the error fields", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	logger.Error("load failed", Error(eal.Trace(errors.New("timeout"))))
+	logger.Info("no error", Error(nil))
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields[eal.FieldErrorMessage] != "timeout" || fields[eal.FieldErrorStack] == nil {
+		t.Errorf("got fields: %v, want the eal error fields", fields)
+	}
+	if fields := entries[1].ContextMap(); len(fields) != 0 {
+		t.Errorf("got fields: %v, want none", fields)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	for level, want := range map[eal.Level]zapcore.Level{
+		eal.PanicLevel: zapcore.PanicLevel,
+		eal.FatalLevel: zapcore.FatalLevel,
+		eal.ErrorLevel: zapcore.ErrorLevel,
+		eal.WarnLevel:  zapcore.WarnLevel,
+		eal.InfoLevel:  zapcore.InfoLevel,
+		eal.DebugLevel: zapcore.DebugLevel,
+		eal.TraceLevel: zapcore.DebugLevel,
+	} {
+		if got := Level(level); got != want {
+			t.Errorf("got %s level for %s, want: %s", got, level, want)
+		}
+	}
+}
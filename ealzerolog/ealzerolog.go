@@ -0,0 +1,78 @@
+// Package ealzerolog write the log entries of eal with zerolog, so that the entries written by eal.Entry, including
+// the error fields of WithError and the request fields of WithCtx, and the access log entries of the logger
+// middleware, are written as zerolog events:
+//
+//	logger := zerolog.New(os.Stdout)
+//	eal.SetSink(ealzerolog.NewSink(logger))
+//
+// Errors can also be added to events written directly with zerolog, with the error fields of eal.UnwrapError:
+//
+//	logger.Error().EmbedObject(ealzerolog.Error(err)).Msg("load failed")
+package ealzerolog
+
+import (
+	"github.com/modfin/eal"
+	"github.com/rs/zerolog"
+)
+
+type (
+	sink struct {
+		logger zerolog.Logger
+	}
+
+	// errorFields is a zerolog.LogObjectMarshaler that add the fields of eal.UnwrapError.
+	errorFields struct {
+		err error
+	}
+)
+
+// NewSink return an eal.Sink that write log entries as events of the logger. The events are written with the time,
+// level and message of the eal entry, so the logger should not add a timestamp itself. Events are written with
+// zerolog.Logger.WithLevel, so panic and fatal level entries don't panic or exit in zerolog, that is done by eal.
+func NewSink(logger zerolog.Logger) eal.Sink {
+	return &sink{logger: logger}
+}
+
+// Level return the zerolog level of an eal level.
+func Level(level eal.Level) zerolog.Level {
+	switch level {
+	case eal.PanicLevel:
+		return zerolog.PanicLevel
+	case eal.FatalLevel:
+		return zerolog.FatalLevel
+	case eal.ErrorLevel:
+		return zerolog.ErrorLevel
+	case eal.WarnLevel:
+		return zerolog.WarnLevel
+	case eal.InfoLevel:
+		return zerolog.InfoLevel
+	case eal.DebugLevel:
+		return zerolog.DebugLevel
+	}
+	return zerolog.TraceLevel
+}
+
+// Error return a zerolog.LogObjectMarshaler that add the fields of eal.UnwrapError for the error chain of err, to be
+// embedded in an event with zerolog.Event.EmbedObject. Nothing is added if err is nil.
+func Error(err error) zerolog.LogObjectMarshaler {
+	return errorFields{err: err}
+}
+
+func (s *sink) Write(r eal.Record) error {
+	e := s.logger.WithLevel(Level(r.Level))
+	if e == nil {
+		return nil
+	}
+	e.Ctx(r.Context).Time(zerolog.TimestampFieldName, r.Time).Fields(map[string]interface{}(r.Fields)).Msg(r.Message)
+	return nil
+}
+
+// MarshalZerologObject implements the zerolog.LogObjectMarshaler interface.
+func (e errorFields) MarshalZerologObject(event *zerolog.Event) {
+	if e.err == nil {
+		return
+	}
+	fields := eal.Fields{}
+	eal.UnwrapError(e.err, fields)
+	event.Fields(map[string]interface{}(fields))
+}
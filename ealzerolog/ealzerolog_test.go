@@ -0,0 +1,85 @@
+package ealzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modfin/eal"
+	"github.com/rs/zerolog"
+)
+
+// decode return the events written to buf.
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestSink(t *testing.T) {
+	var buf bytes.Buffer
+	eal.SetSink(NewSink(zerolog.New(&buf).Level(zerolog.InfoLevel)))
+	t.Cleanup(func() { eal.SetSink(nil) })
+
+	eal.NewEntry().WithFields(eal.Fields{"user_id": 7}).Info("loaded")
+	eal.NewEntry().Debug("not logged")
+	eal.NewEntry().WithError(eal.Trace(errors.New("no such user"))).Warn("lookup failed")
+
+	events := decode(t, &buf)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if got := events[0]; got["message"] != "loaded" || got["level"] != "info" || got["user_id"] != float64(7) || got["time"] == nil {
+		t.Errorf("got first event: %v, want info 'loaded' with time and user_id", got)
+	}
+	if got := events[1]; got["level"] != "warn" || got[eal.FieldErrorMessage] != "no such user" || got[eal.FieldErrorStack] == nil {
+		t.Errorf("got second event: %v, want warning with the error fields", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	logger.Error().EmbedObject(Error(eal.Trace(errors.New("timeout")))).Msg("load failed")
+	logger.Info().EmbedObject(Error(nil)).Msg("no error")
+
+	events := decode(t, &buf)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if got := events[0]; got[eal.FieldErrorMessage] != "timeout" || got[eal.FieldErrorStack] == nil {
+		t.Errorf("got event: %v, want the eal error fields", got)
+	}
+	if got := events[1]; len(got) != 2 {
+		t.Errorf("got event: %v, want only level and message", got)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	for level, want := range map[eal.Level]zerolog.Level{
+		eal.PanicLevel: zerolog.PanicLevel,
+		eal.FatalLevel: zerolog.FatalLevel,
+		eal.ErrorLevel: zerolog.ErrorLevel,
+		eal.WarnLevel:  zerolog.WarnLevel,
+		eal.InfoLevel:  zerolog.InfoLevel,
+		eal.DebugLevel: zerolog.DebugLevel,
+		eal.TraceLevel: zerolog.TraceLevel,
+	} {
+		if got := Level(level); got != want {
+			t.Errorf("got %s level for %s, want: %s", got, level, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package eal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ECSVersion is the version of the Elastic Common Schema that the ECS field mapping follow.
+const ECSVersion = "8.11.0"
+
+// ECSFieldNames map eal field names to Elastic Common Schema field names. Fields that aren't in the map keep their
+// eal name. Application specific fields can be added to the map before logging starts.
+var ECSFieldNames = map[string]string{
+	FieldRequestID:     "http.request.id",
+	FieldRemoteAddr:    "client.address",
+	FieldHost:          "url.domain",
+	FieldMethod:        "http.request.method",
+	FieldURI:           "url.original",
+	FieldRouterPath:    "http.route",
+	FieldStatus:        "http.response.status_code",
+	FieldBytesIn:       "http.request.body.bytes",
+	FieldBytesOut:      "http.response.body.bytes",
+	FieldErrorMessage:  "error.message",
+	FieldErrorStack:    "error.stack_trace",
+	FieldErrorStackID:  "error.id",
+	FieldErrorType:     "error.type",
+	FieldTraceID:       "trace.id",
+	FieldSpanID:        "span.id",
+	FieldInstanceID:    "service.node.name",
+	FieldHostname:      "host.hostname",
+	FieldPID:           "process.pid",
+	FieldModuleVersion: "service.version",
+	FieldSeq:           "event.sequence",
+	FieldEventID:       "event.id",
+	FieldGeoCountry:    "client.geo.country_iso_code",
+	FieldGeoCity:       "client.geo.city_name",
+	FieldGeoASN:        "client.as.number",
+	FieldGeoASOrg:      "client.as.organization.name",
+}
+
+// ECSFormatter is a logrus.Formatter that write log entries as JSON, with the field names mapped to Elastic Common
+// Schema (ECS) names, see ECSFieldNames. The latency_ms (or latency_ns) field is written as event.duration, in
+// nanoseconds, and url.path is added with the path part of the uri field. The output can be indexed by Elasticsearch
+// without a translation pipeline.
+type ECSFormatter struct{}
+
+// InitECS initialize the logrus logger to output ECS formatted JSON log entries to STDOUT.
+func InitECS() {
+	installHook()
+	logrus.SetFormatter(&ECSFormatter{})
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := ecsFields(entry.Data)
+	data["@timestamp"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	data["log.level"] = entry.Level.String()
+	data["message"] = entry.Message
+	data["ecs.version"] = ECSVersion
+	for k, v := range data {
+		if err, ok := v.(error); ok {
+			// Same as logrus.JSONFormatter, since errors otherwise is marshalled as empty objects
+			data[k] = err.Error()
+		}
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	if err := json.NewEncoder(b).Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// ecsFields return a copy of the fields, with the field names mapped to ECS names.
+func ecsFields(fields map[string]interface{}) map[string]interface{} {
+	mapped := make(map[string]interface{}, len(fields)+4)
+	for k, v := range fields {
+		switch k {
+		case FieldLatencyMs:
+			switch ms := v.(type) {
+			case int64:
+				mapped["event.duration"] = ms * int64(time.Millisecond)
+				continue
+			case float64:
+				mapped["event.duration"] = int64(math.Round(ms * float64(time.Millisecond)))
+				continue
+			}
+		case FieldLatencyNs:
+			if ns, ok := v.(int64); ok {
+				mapped["event.duration"] = ns
+				continue
+			}
+		case FieldURI:
+			if uri, ok := v.(string); ok {
+				path, _, _ := strings.Cut(uri, "?")
+				mapped["url.path"] = path
+			}
+		}
+		if name, ok := ECSFieldNames[k]; ok {
+			k = name
+		}
+		mapped[k] = v
+	}
+	return mapped
+}
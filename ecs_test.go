@@ -0,0 +1,77 @@
+package eal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestECSFormatter(t *testing.T) {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Time = time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "access"
+	entry.Data = logrus.Fields{
+		FieldMethod:       "GET",
+		FieldStatus:       500,
+		FieldLatencyMs:    int64(12),
+		FieldErrorMessage: errors.New("boom"),
+		"tenant":          "t1",
+	}
+
+	b, err := (&ECSFormatter{}).Format(entry)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	var got map[string]interface{}
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+
+	for k, want := range map[string]interface{}{
+		"@timestamp":                "2024-05-17T12:00:00Z",
+		"log.level":                 "error",
+		"message":                   "access",
+		"ecs.version":               ECSVersion,
+		"http.request.method":       "GET",
+		"http.response.status_code": float64(500),
+		"event.duration":            float64(12 * time.Millisecond),
+		"error.message":             "boom",
+		"tenant":                    "t1",
+	} {
+		if got[k] != want {
+			t.Errorf("got %s: %v, want: %v", k, got[k], want)
+		}
+	}
+	if _, ok := got[FieldMethod]; ok {
+		t.Errorf("got unmapped field %s", FieldMethod)
+	}
+}
+
+func TestCreateLoggerMiddlewareECS(t *testing.T) {
+	entries := captureLog(t)
+	req := httptest.NewRequest(http.MethodGet, "/users?limit=10", nil)
+	serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{ECS: true}), req, func(c echo.Context) error {
+		return errors.New("boom")
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0]["level"] != "error" {
+		t.Errorf("got level: %v, want: error", logged[0]["level"])
+	}
+	if logged[0]["http.response.status_code"] != float64(http.StatusInternalServerError) || logged[0]["url.path"] != "/users" {
+		t.Errorf("got unexpected ECS fields: %v", logged[0])
+	}
+	if _, ok := logged[0][FieldStatus]; ok {
+		t.Errorf("got unmapped field %s", FieldStatus)
+	}
+}
@@ -1,9 +1,11 @@
 package eal
 
 import (
-	"errors"
+	"context"
+	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
@@ -17,17 +19,44 @@ type (
 	}
 )
 
-const (
-	errorMessage = "error_message"
-	errorStack   = "error_stack"
-	errorType    = "error_type"
-)
+// maxPooledFields is the maximum number of fields an entry can have to be returned to the entry pool, so that the
+// pool don't hold on to large maps.
+const maxPooledFields = 64
+
+var entryPool = sync.Pool{New: func() interface{} { return &Entry{} }}
 
 // NewEntry return an Entry instance to be used for creating a log entry.
 // For example:
 //  eal.NewEntry().Info("App started")
+//
+// Entries are taken from a pool, and can be returned to it with Release when they are no longer used.
 func NewEntry() *Entry {
-	return &Entry{Entry: *logrus.WithFields(logrus.Fields{})}
+	installHook()
+	e := entryPool.Get().(*Entry)
+	data := e.Entry.Data
+	if data == nil {
+		data = make(logrus.Fields, 8)
+	}
+	e.Entry = logrus.Entry{Logger: logrus.StandardLogger(), Data: data}
+	return e
+}
+
+// Release return the entry to the pool used by NewEntry, so that the entry and its fields map can be reused. This
+// reduce the allocations of code that write many log entries, like the logger middlewares. Calling Release is
+// optional, but the entry, and its Data map, must not be used after it have been released:
+//
+//	e := eal.NewEntry().WithFields(fields)
+//	e.Info("batch imported")
+//	e.Release()
+func (e *Entry) Release() {
+	if e == nil || e.Entry.Data == nil || len(e.Entry.Data) > maxPooledFields {
+		return
+	}
+	for k := range e.Entry.Data {
+		delete(e.Entry.Data, k)
+	}
+	e.Entry = logrus.Entry{Data: e.Entry.Data}
+	entryPool.Put(e)
 }
 
 // WithFields adds custom fields (key/value) to the log entry.
@@ -35,8 +64,8 @@ func NewEntry() *Entry {
 //  eal.NewEntry().WithFields(eal.Fields{"time": time.Since(start)}).Info("Work completed")
 func (e *Entry) WithFields(f map[string]interface{}) *Entry {
 	for k, v := range f {
-		if !strings.HasPrefix(k, "_") {
-			e.Entry.Data[k] = v
+		if !LegacyControlFields || !strings.HasPrefix(k, "_") {
+			setField(e.Entry.Data, k, v)
 		}
 	}
 	return e
@@ -45,27 +74,39 @@ func (e *Entry) WithFields(f map[string]interface{}) *Entry {
 // WithError uses UnwrapError internally to extract more information from the error and add it to the log entry fields.
 //
 // See UnwrapError and RegisterErrorLogFunc methods for more information about how to extend the log entry fields.
+//
+// Server errors are reported to the reporters registered with RegisterErrorReporter, when the entry is written with
+// error level or above.
 func (e *Entry) WithError(err error) *Entry {
+	e.withError(err)
+	if err != nil {
+		e.Entry.Data[fieldReportError] = err
+	}
+	return e
+}
+
+// withError add the error fields to the entry, without reporting the error.
+func (e *Entry) withError(err error) *Entry {
 	if err == nil {
 		return e
 	}
 
-	var innerErr = err
-	for errors.Unwrap(innerErr) != nil {
-		innerErr = errors.Unwrap(innerErr)
-	}
-	e.Entry.Data[errorType] = reflect.TypeOf(innerErr).String()
+	e.Entry.Data[FieldErrorType] = reflect.TypeOf(innermostError(err)).String()
 
 	UnwrapError(err, e.Entry.Data)
 
 	return e
 }
 
-// WithCtx add fields from the context, to the log entry.
+// WithCtx add fields from the context, to the log entry. If SpanFromContext is set, the active tracing span of the
+// request is also added.
 func (e *Entry) WithCtx(c echo.Context) *Entry {
 	if c == nil {
 		return e
 	}
+	if req := c.Request(); req != nil {
+		setSpanFields(req.Context(), e.Entry.Data)
+	}
 
 	// ContextLogFields are setup by the CreateLoggerMiddleware function.
 	contextLogFields := c.Get(contextName)
@@ -73,11 +114,83 @@ func (e *Entry) WithCtx(c echo.Context) *Entry {
 		return e
 	}
 
-	logFields, ok := contextLogFields.(map[string]interface{})
-	if !ok {
+	switch logFields := contextLogFields.(type) {
+	case *requestFields:
+		e.WithFields(logFields.snapshot())
+	case Fields:
+		e.WithFields(logFields)
+	case map[string]interface{}:
+		e.WithFields(logFields)
+	}
+	return e
+}
+
+// WithContext add the log fields stored in the context, by WithFields or the logger middleware, and the active tracing
+// span (see SpanFromContext) to the log entry. The context is also set on the logrus entry, so that logrus hooks can
+// access it. WithContext is the counterpart of WithCtx for code that only have access to a context.Context:
+//
+//	eal.NewEntry().WithContext(ctx).WithError(err).Error("failed to update order")
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	if ctx == nil {
 		return e
 	}
+	e.Entry.Context = ctx
+	setSpanFields(ctx, e.Entry.Data)
+	e.WithFields(contextFields(ctx))
+	return e
+}
 
-	e.WithFields(logFields)
+// WithRequest add the request fields that the logger middleware log, i.e. method, uri, host, request_id and
+// remote_addr, from the request. It can be used by code that log outside of the logger middleware, like custom
+// handlers and reverse proxies:
+//
+//	eal.NewEntry().WithRequest(r).WithError(err).Error("upstream failed")
+//
+// The request_id field is only added if the request have a request ID header, no ID is generated. The request ID and
+// page view ID headers of the logger middleware that handle the request are used, or the headers of
+// DefaultLoggerConfig if the request isn't handled by a logger middleware.
+func (e *Entry) WithRequest(req *http.Request) *Entry {
+	if req == nil {
+		return e
+	}
+	uri := req.RequestURI
+	if uri == "" && req.URL != nil {
+		uri = req.URL.RequestURI()
+	}
+	fields := Fields{
+		FieldMethod:     req.Method,
+		FieldURI:        uri,
+		FieldHost:       requestHost(req),
+		FieldRemoteAddr: clientAddr(req),
+	}
+	headers, ok := req.Context().Value(requestHeadersContextKey{}).(requestHeaders)
+	if !ok {
+		headers = DefaultLoggerConfig.requestHeaders()
+	}
+	if id := req.Header.Get(headers.requestID); id != "" {
+		fields[FieldRequestID] = id
+	}
+	if id := pageViewID(req, headers.pageViewID); id != "" {
+		fields[FieldPageViewID] = id
+	}
+	return e.WithFields(fields)
+}
+
+// WithCancelCause add a cancel_cause field to the log entry if the context have been canceled. The field hold the log
+// fields that UnwrapError produce for the cancellation cause, as returned by context.Cause.
+func (e *Entry) WithCancelCause(ctx context.Context) *Entry {
+	if ctx == nil {
+		return e
+	}
+	setCancelCauseField(ctx, e.Entry.Data)
 	return e
 }
+
+func setCancelCauseField(ctx context.Context, fields map[string]interface{}) {
+	if ctx.Err() == nil {
+		return
+	}
+	causeFields := make(map[string]interface{})
+	UnwrapError(context.Cause(ctx), causeFields)
+	fields[FieldCancelCause] = causeFields
+}
@@ -0,0 +1,127 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by InitFromEnv.
+const (
+	EnvLevel             = "EAL_LEVEL"
+	EnvFormat            = "EAL_FORMAT"
+	EnvSampling          = "EAL_SAMPLING"
+	EnvRedactKeys        = "EAL_REDACT_KEYS"
+	EnvStrictFields      = "EAL_STRICT_FIELDS"
+	EnvRecoverPanics     = "EAL_RECOVER_PANICS"
+	EnvLogSequence       = "EAL_LOG_SEQUENCE"
+	EnvLogEventID        = "EAL_LOG_EVENT_ID"
+	EnvLogBuildInfo      = "EAL_LOG_BUILD_INFO"
+	EnvDevErrorResponses = "EAL_DEV_ERROR_RESPONSES"
+	EnvDevEnvironment    = "EAL_DEV_ENVIRONMENT"
+)
+
+// InitFromEnv initialize eal from environment variables, so that all services get consistent logging behavior from
+// their deployment config. Variables that aren't set leave the corresponding setting unchanged:
+//
+//	EAL_LEVEL                log level, for example "debug" or "warn", see SetLevel
+//	EAL_FORMAT               "json", "text" (see Init) or "ecs" (see InitECS)
+//	EAL_SAMPLING             log every n:th successful request, see SamplingConfig.Rate
+//	EAL_REDACT_KEYS          comma separated field names (matched case-insensitively, as substrings) that are redacted
+//	EAL_STRICT_FIELDS        enable StrictFieldNames
+//	EAL_RECOVER_PANICS       enable LoggerConfig.RecoverPanics
+//	EAL_LOG_SEQUENCE         enable LogSequence
+//	EAL_LOG_EVENT_ID         enable LogEventID
+//	EAL_LOG_BUILD_INFO       enable LogBuildInfo
+//	EAL_DEV_ERROR_RESPONSES  enable LoggerConfig.DevErrorResponses
+//	EAL_DEV_ENVIRONMENT      mark the service as running in a development environment, see SetDevEnvironment
+//
+// Boolean variables accept the values of strconv.ParseBool. The middleware settings are applied to
+// DefaultLoggerConfig, which mean that InitFromEnv must be called before the middlewares are created. All variables
+// are validated, and an error that describe all invalid variables is returned. Valid variables are applied even if
+// other variables are invalid.
+func InitFromEnv() error {
+	installHook()
+	return initFromEnv(os.LookupEnv)
+}
+
+func initFromEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	invalid := func(name, value string, err error) {
+		errs = append(errs, fmt.Errorf("invalid %s %q: %w", name, value, err))
+	}
+
+	if v, ok := lookup(EnvFormat); ok {
+		switch strings.ToLower(v) {
+		case "json":
+			Init(false)
+		case "text":
+			Init(true)
+		case "ecs":
+			InitECS()
+		default:
+			invalid(EnvFormat, v, errors.New("must be json, text or ecs"))
+		}
+	}
+
+	if v, ok := lookup(EnvLevel); ok {
+		if level, err := ParseLevel(v); err != nil {
+			invalid(EnvLevel, v, err)
+		} else {
+			SetLevel(level)
+		}
+	}
+
+	if v, ok := lookup(EnvSampling); ok {
+		if rate, err := strconv.Atoi(v); err != nil || rate < 0 {
+			invalid(EnvSampling, v, errors.New("must be a non-negative integer"))
+		} else {
+			DefaultLoggerConfig.Sampling.Rate = rate
+		}
+	}
+
+	if v, ok := lookup(EnvRedactKeys); ok {
+		var keys []string
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keys = append(keys, regexp.QuoteMeta(k))
+			}
+		}
+		if len(keys) > 0 {
+			RegisterRedactor(RedactKeys(regexp.MustCompile(`(?i)` + strings.Join(keys, "|"))))
+		}
+	}
+
+	for _, b := range []struct {
+		name    string
+		setting *bool
+	}{
+		{EnvStrictFields, &StrictFieldNames},
+		{EnvRecoverPanics, &DefaultLoggerConfig.RecoverPanics},
+		{EnvLogSequence, &LogSequence},
+		{EnvLogEventID, &LogEventID},
+		{EnvLogBuildInfo, &LogBuildInfo},
+		{EnvDevErrorResponses, &DefaultLoggerConfig.DevErrorResponses},
+	} {
+		if v, ok := lookup(b.name); ok {
+			if enabled, err := strconv.ParseBool(v); err != nil {
+				invalid(b.name, v, err)
+			} else {
+				*b.setting = enabled
+			}
+		}
+	}
+
+	if v, ok := lookup(EnvDevEnvironment); ok {
+		if enabled, err := strconv.ParseBool(v); err != nil {
+			invalid(EnvDevEnvironment, v, err)
+		} else {
+			SetDevEnvironment(enabled)
+		}
+	}
+
+	return errors.Join(errs...)
+}
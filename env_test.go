@@ -0,0 +1,64 @@
+package eal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestInitFromEnv(t *testing.T) {
+	formatter, level := logrus.StandardLogger().Formatter, logrus.GetLevel()
+	defaults := DefaultLoggerConfig
+	t.Cleanup(func() {
+		logrus.SetFormatter(formatter)
+		logrus.SetLevel(level)
+		levelMu.Lock()
+		levelSet = false
+		levelMu.Unlock()
+		DefaultLoggerConfig = defaults
+		StrictFieldNames = false
+		LogSequence = false
+		SetDevEnvironment(false)
+		redactorsMu.Lock()
+		redactors = nil
+		redactorsMu.Unlock()
+	})
+
+	env := map[string]string{
+		EnvFormat:         "ecs",
+		EnvLevel:          "debug",
+		EnvSampling:       "10",
+		EnvRedactKeys:     "password, api_key",
+		EnvStrictFields:   "true",
+		EnvRecoverPanics:  "yes",
+		EnvLogSequence:    "1",
+		EnvDevEnvironment: "true",
+	}
+	err := initFromEnv(func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	})
+	if err == nil || !strings.Contains(err.Error(), EnvRecoverPanics) || strings.Contains(err.Error(), EnvLevel) {
+		t.Errorf("got error: %v, want only %s to be invalid", err, EnvRecoverPanics)
+	}
+
+	if _, ok := logrus.StandardLogger().Formatter.(*ECSFormatter); !ok {
+		t.Errorf("got formatter: %T, want *ECSFormatter", logrus.StandardLogger().Formatter)
+	}
+	if GetLevel() != DebugLevel {
+		t.Errorf("got level: %s, want debug", GetLevel())
+	}
+	if DefaultLoggerConfig.Sampling.Rate != 10 || DefaultLoggerConfig.RecoverPanics {
+		t.Errorf("got config: %+v, want sampling rate 10 and no panic recovery", DefaultLoggerConfig)
+	}
+	if !StrictFieldNames || !LogSequence {
+		t.Error("got strict field names or log sequence disabled, want enabled")
+	}
+	if !devEnvironment.Load() {
+		t.Errorf("got no dev environment, want it set by %s", EnvDevEnvironment)
+	}
+	if got := redactValue("user_password", "hunter2"); got != RedactedValue {
+		t.Errorf("got user_password: %v, want: %s", got, RedactedValue)
+	}
+}
@@ -0,0 +1,37 @@
+// Command ealerrgen generate typed error constructors from a YAML error catalog, see the errgen package. It's intended
+// to be run by go generate:
+//
+//	//go:generate go run github.com/modfin/eal/errgen/cmd/ealerrgen -in errors.yaml -out errors_gen.go -pkg errs
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/modfin/eal/errgen"
+)
+
+func main() {
+	in := flag.String("in", "errors.yaml", "the YAML error catalog")
+	out := flag.String("out", "errors_gen.go", "the generated Go file")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "the package name of the generated file, default $GOPACKAGE")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "ealerrgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	src, err := errgen.Generate(data, pkg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
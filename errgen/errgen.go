@@ -0,0 +1,178 @@
+// Package errgen generate typed error constructors from a YAML error catalog, so that the error codes, messages and
+// statuses are declared in one place. The generated code register each error with eal.RegisterErrorCode, and have a
+// constructor per error that wrap the cause with eal.Trace and eal.NewHTTPError, through eal.ErrorCode.Err.
+//
+// A catalog look like this:
+//
+//	errors:
+//	  - name: user.disabled
+//	    id: 42
+//	    status: 403
+//	    message: user.error.disabled
+//	    doc: the user account have been disabled by an administrator
+//	  - name: payment.failed
+//	    id: 43
+//	    status: 402
+//	    message: payment.error.failed
+//	    severity: warn
+//
+// and generate, among others, the variable CodeUserDisabled and the constructor UserDisabled(err error) error. The
+// code is usually generated with the ealerrgen command:
+//
+//	//go:generate go run github.com/modfin/eal/errgen/cmd/ealerrgen -in errors.yaml -out errors_gen.go -pkg errs
+package errgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/modfin/eal"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Catalog is the YAML error catalog.
+	Catalog struct {
+		Errors []Error `yaml:"errors"`
+	}
+
+	// Error is an error code in the catalog, see eal.ErrorCode.
+	Error struct {
+		Name     string `yaml:"name"`
+		ID       int    `yaml:"id"`
+		Status   int    `yaml:"status"`
+		Message  string `yaml:"message"`
+		Severity string `yaml:"severity"`
+		Doc      string `yaml:"doc"`
+	}
+
+	// templateError is an Error with the generated identifiers.
+	templateError struct {
+		Error
+		Ident    string
+		Severity string
+	}
+)
+
+// levelConstants is the names of the eal level constants, by level.
+var levelConstants = map[eal.Level]string{
+	eal.FatalLevel: "eal.FatalLevel",
+	eal.ErrorLevel: "eal.ErrorLevel",
+	eal.WarnLevel:  "eal.WarnLevel",
+	eal.InfoLevel:  "eal.InfoLevel",
+	eal.DebugLevel: "eal.DebugLevel",
+	eal.TraceLevel: "eal.TraceLevel",
+}
+
+var fileTemplate = template.Must(template.New("errors").Parse(`// Code generated by ealerrgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/modfin/eal"
+
+// Error codes of the error catalog.
+var (
+{{- range .Errors}}
+	// Code{{.Ident}} is the {{.Name}} error code.
+	Code{{.Ident}} = eal.RegisterErrorCode(eal.ErrorCode{Name: {{printf "%q" .Name}}, ID: {{.ID}}, Status: {{.Status}}, MessageKey: {{printf "%q" .Message}}{{with .Severity}}, Severity: {{.}}.Ptr(){{end}}})
+{{- end}}
+)
+{{range .Errors}}
+// {{.Ident}} return a {{.Name}} error, with err as the cause{{with .Doc}}: {{.}}{{end}}. err can be nil.
+func {{.Ident}}(err error) error {
+	return Code{{.Ident}}.Err(err)
+}
+{{end}}`))
+
+// Parse decode and validate a YAML error catalog. Names and ids must be unique, and the statuses must be valid HTTP
+// error statuses (400-599).
+func Parse(data []byte) (*Catalog, error) {
+	var c Catalog
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("errgen: decode catalog: %w", err)
+	}
+
+	names := map[string]struct{}{}
+	idents := map[string]string{}
+	ids := map[int]string{}
+	for _, e := range c.Errors {
+		switch {
+		case e.Name == "":
+			return nil, fmt.Errorf("errgen: error with id %d have no name", e.ID)
+		case e.Status < 400 || e.Status > 599:
+			return nil, fmt.Errorf("errgen: %s: invalid status %d", e.Name, e.Status)
+		}
+		if _, ok := names[e.Name]; ok {
+			return nil, fmt.Errorf("errgen: %s: duplicate name", e.Name)
+		}
+		if other, ok := ids[e.ID]; ok {
+			return nil, fmt.Errorf("errgen: %s: id %d already used by %s", e.Name, e.ID, other)
+		}
+		ident := Ident(e.Name)
+		if other, ok := idents[ident]; ok {
+			return nil, fmt.Errorf("errgen: %s: generated name %s clash with %s", e.Name, ident, other)
+		}
+		if e.Severity != "" {
+			if _, err := eal.ParseLevel(e.Severity); err != nil {
+				return nil, fmt.Errorf("errgen: %s: %w", e.Name, err)
+			}
+		}
+		names[e.Name] = struct{}{}
+		ids[e.ID] = e.Name
+		idents[ident] = e.Name
+	}
+	return &c, nil
+}
+
+// Generate return the gofmt:ed Go source of the package pkg, for the YAML error catalog.
+func Generate(data []byte, pkg string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("errgen: invalid package name %q", pkg)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]templateError, len(c.Errors))
+	for i, e := range c.Errors {
+		errs[i] = templateError{Error: e, Ident: Ident(e.Name)}
+		if e.Severity != "" {
+			level, _ := eal.ParseLevel(e.Severity)
+			errs[i].Severity = levelConstants[level]
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, struct {
+		Package string
+		Errors  []templateError
+	}{pkg, errs}); err != nil {
+		return nil, fmt.Errorf("errgen: %w", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("errgen: format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// Ident return the exported Go identifier of an error name, i.e. "user.disabled" become "UserDisabled". Characters
+// that aren't letters or digits separate words.
+func Ident(name string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		r := []rune(word)
+		sb.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	ident := sb.String()
+	if ident == "" || unicode.IsDigit([]rune(ident)[0]) {
+		ident = "Err" + ident
+	}
+	return ident
+}
@@ -0,0 +1,93 @@
+package errgen
+
+import (
+	"strings"
+	"testing"
+)
+
+const catalog = `
+errors:
+  - name: user.disabled
+    id: 42
+    status: 403
+    message: user.error.disabled
+    doc: the user account have been disabled by an administrator
+  - name: payment.failed
+    id: 43
+    status: 402
+    message: payment.error.failed
+    severity: warn
+`
+
+const want = `// Code generated by ealerrgen. DO NOT EDIT.
+
+package errs
+
+import "github.com/modfin/eal"
+
+// Error codes of the error catalog.
+var (
+	// CodeUserDisabled is the user.disabled error code.
+	CodeUserDisabled = eal.RegisterErrorCode(eal.ErrorCode{Name: "user.disabled", ID: 42, Status: 403, MessageKey: "user.error.disabled"})
+	// CodePaymentFailed is the payment.failed error code.
+	CodePaymentFailed = eal.RegisterErrorCode(eal.ErrorCode{Name: "payment.failed", ID: 43, Status: 402, MessageKey: "payment.error.failed", Severity: eal.WarnLevel.Ptr()})
+)
+
+// UserDisabled return a user.disabled error, with err as the cause: the user account have been disabled by an administrator. err can be nil.
+func UserDisabled(err error) error {
+	return CodeUserDisabled.Err(err)
+}
+
+// PaymentFailed return a payment.failed error, with err as the cause. err can be nil.
+func PaymentFailed(err error) error {
+	return CodePaymentFailed.Err(err)
+}
+`
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate([]byte(catalog), "errs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", src, want)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		catalog string
+		pkg     string
+		wantErr string
+	}{
+		{name: "invalid package", catalog: catalog, pkg: "my-errs", wantErr: "invalid package name"},
+		{name: "invalid yaml", catalog: "errors: [", pkg: "errs", wantErr: "decode catalog"},
+		{name: "missing name", catalog: "errors:\n  - id: 1\n    status: 400", pkg: "errs", wantErr: "have no name"},
+		{name: "invalid status", catalog: "errors:\n  - name: a\n    status: 200", pkg: "errs", wantErr: "invalid status 200"},
+		{name: "duplicate id", catalog: "errors:\n  - name: a\n    status: 400\n  - name: b\n    status: 400", pkg: "errs", wantErr: "id 0 already used by a"},
+		{name: "ident clash", catalog: "errors:\n  - name: a.b\n    status: 400\n  - name: a_b\n    id: 1\n    status: 400", pkg: "errs", wantErr: "clash"},
+		{name: "invalid severity", catalog: "errors:\n  - name: a\n    status: 400\n    severity: loud", pkg: "errs", wantErr: "not a valid logrus Level"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate([]byte(tt.catalog), tt.pkg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error: %v, want: %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIdent(t *testing.T) {
+	for name, want := range map[string]string{
+		"user.disabled":     "UserDisabled",
+		"order_not-found":   "OrderNotFound",
+		"http.404":          "Http404",
+		"404.page":          "Err404Page",
+		"already.CamelCase": "AlreadyCamelCase",
+	} {
+		if got := Ident(name); got != want {
+			t.Errorf("got %s: %s, want: %s", name, got, want)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package eal
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
+	"sync"
+	"sync/atomic"
 
 	"github.com/labstack/echo/v4"
 )
@@ -16,16 +19,49 @@ type (
 	//
 	// See RegisterErrorLogFunc and UnwrapError regarding the SetLogFields interface for more information.
 	ErrLogFunc func(err error, fields Fields)
-)
 
-// Define some common log field names used by the errorLogger
-const (
-	httpMessage    = "http_message"
-	httpStatusCode = "http_status"
+	// interfaceErrLogFunc is an ErrLogFunc that is registered for the errors that implement an interface.
+	interfaceErrLogFunc struct {
+		iface   reflect.Type
+		logFunc ErrLogFunc
+	}
+
+	// errorRegistry is a snapshot of the registered error log functions and field prefixes, that UnwrapError use to
+	// match the errors in the error chain without locking. A new snapshot is compiled each time a function or prefix is
+	// registered.
+	errorRegistry struct {
+		logFuncs  map[interface{}]ErrLogFunc
+		ifaces    []interfaceErrLogFunc
+		prefixes  map[interface{}]string
+		instances map[reflect.Type]bool
+		matchers  sync.Map // reflect.Type -> *errorMatcher
+
+		// stackTraceMatched is set if a field prefix is registered for *ErrorStackTrace, and httpErrorLogFunc hold the
+		// ErrLogFunc registered for *echo.HTTPError if no field prefix is registered for it. unwrapErrorChain handle
+		// these two errors, that are in most error chains, without looking up their errorMatcher.
+		stackTraceMatched bool
+		httpErrorLogFunc  ErrLogFunc
+	}
+
+	// errorMatcher hold the log function and field prefix that is registered for an error type, resolved the first
+	// time the type is seen, so that the reflection is done once per type instead of once per logged error.
+	errorMatcher struct {
+		logFunc   ErrLogFunc
+		ifaceFunc ErrLogFunc
+		prefix    string
+		hasPrefix bool
+		instances bool
+	}
 )
 
 var (
+	errorLogFunctionsMu         sync.Mutex
 	registeredErrorLogFunctions = make(map[interface{}]ErrLogFunc)
+	interfaceErrorLogFunctions  []interfaceErrLogFunc
+	registeredErrorFieldPrefix  = make(map[interface{}]string)
+
+	errorRegistrySnapshot atomic.Pointer[errorRegistry]
+	emptyErrorRegistry    errorRegistry
 )
 
 // InitDefaultErrorLogging register a error logger that append more information to the log for echo.HTTPError.
@@ -37,15 +73,33 @@ func errorLogger(err error, fields Fields) {
 	var i interface{} = err
 	switch e := i.(type) {
 	case *echo.HTTPError:
-		fields[httpMessage] = e.Message
-		fields[httpStatusCode] = e.Code
+		fields[FieldHTTPMessage] = e.Message
+		fields[FieldHTTPStatus] = statusValue(e.Code)
 	default:
-		fields["error_logger"] = fmt.Sprintf("eal.errorlogger: Don't know how to handle %T error type ", err)
+		fields[FieldErrorLogger] = fmt.Sprintf("eal.errorlogger: Don't know how to handle %T error type ", err)
 	}
 }
 
+// statusValues hold the HTTP status codes 100-599 as interface values, so that a status code can be set as a log field
+// without allocating.
+var statusValues = func() (values [500]interface{}) {
+	for i := range values {
+		values[i] = i + 100
+	}
+	return values
+}()
+
+// statusValue return the status code as a log field value.
+func statusValue(code int) interface{} {
+	if code >= 100 && code < 600 {
+		return statusValues[code-100]
+	}
+	return code
+}
+
 // GetInnerHTTPError check if the provided error is, or have a wrapped echo.HTTPError, and if there is one, it's returned.
-// If the error chain contains more than one, the inner/earliest is returned.
+// If the error chain contains more than one, the inner/earliest is returned. Errors that wrap several errors, like
+// the errors returned by errors.Join, are searched depth first, and the first echo.HTTPError that is found is followed.
 func GetInnerHTTPError(err error) *echo.HTTPError {
 	var errMsg *echo.HTTPError
 	for err != nil {
@@ -58,7 +112,23 @@ func GetInnerHTTPError(err error) *echo.HTTPError {
 	return errMsg
 }
 
-// NewHTTPError complements echo.NewHTTPError, this also takes an error as a parameter.
+// GetOuterHTTPError check if the provided error is, or have a wrapped echo.HTTPError, and if there is one, it's
+// returned. If the error chain contains more than one, the outer/latest is returned, which is normally the error that
+// was created closest to the handler.
+func GetOuterHTTPError(err error) *echo.HTTPError {
+	var errMsg *echo.HTTPError
+	if errors.As(err, &errMsg) {
+		return errMsg
+	}
+	return nil
+}
+
+// NewHTTPError complements echo.NewHTTPError, this also takes an error as a parameter. The error is set as the internal
+// error of the echo.HTTPError, wrapped with the status code so that errors.Is can match the error against a StatusCode:
+//
+//	if errors.Is(err, eal.StatusNotFound) {
+//
+// If err is nil, the echo.HTTPError is created without an internal error, and isn't matched by errors.Is.
 func NewHTTPError(err error, code int, msg ...interface{}) error {
 	var hErr *echo.HTTPError
 	if len(msg) > 0 {
@@ -66,11 +136,71 @@ func NewHTTPError(err error, code int, msg ...interface{}) error {
 	} else {
 		hErr = echo.NewHTTPError(code)
 	}
-	_ = hErr.SetInternal(err)
-
+	if err != nil {
+		_ = hErr.SetInternal(&statusError{err: err, code: code})
+	}
 	return hErr
 }
 
+// statusError is set as the internal error of the echo.HTTPError returned by NewHTTPError, and carry the status code
+// so that errors.Is can match the error chain against a StatusCode. It's transparent in the error message and the
+// log fields, which are those of the wrapped error.
+type statusError struct {
+	err  error
+	code int
+}
+
+func (se *statusError) Error() string {
+	return se.err.Error()
+}
+
+func (se *statusError) Unwrap() error {
+	return se.err
+}
+
+// Is report whether target is a StatusCode that match the status code of the error, either exactly or by class.
+func (se *statusError) Is(target error) bool {
+	sc, ok := target.(StatusCode)
+	return ok && sc.match(se.code)
+}
+
+// StatusCode is an HTTP status code that is used as target for errors.Is, to match errors created by NewHTTPError.
+type StatusCode int
+
+// Status codes that can be used as errors.Is targets. StatusClientError match all 4xx status codes, and
+// StatusServerError match all 5xx status codes. Any other status code can be matched by converting it to a StatusCode.
+const (
+	StatusClientError StatusCode = 4
+	StatusServerError StatusCode = 5
+
+	StatusBadRequest          StatusCode = http.StatusBadRequest
+	StatusUnauthorized        StatusCode = http.StatusUnauthorized
+	StatusForbidden           StatusCode = http.StatusForbidden
+	StatusNotFound            StatusCode = http.StatusNotFound
+	StatusConflict            StatusCode = http.StatusConflict
+	StatusUnprocessableEntity StatusCode = http.StatusUnprocessableEntity
+	StatusTooManyRequests     StatusCode = http.StatusTooManyRequests
+	StatusInternalServerError StatusCode = http.StatusInternalServerError
+	StatusBadGateway          StatusCode = http.StatusBadGateway
+	StatusServiceUnavailable  StatusCode = http.StatusServiceUnavailable
+)
+
+// Error return the status code and text, for example "404 Not Found", or "4xx" for a status class.
+func (sc StatusCode) Error() string {
+	if sc < 10 {
+		return fmt.Sprintf("%dxx", int(sc))
+	}
+	return fmt.Sprintf("%d %s", int(sc), http.StatusText(int(sc)))
+}
+
+// match report if the status code match sc.
+func (sc StatusCode) match(code int) bool {
+	if sc < 10 {
+		return code/100 == int(sc)
+	}
+	return code == int(sc)
+}
+
 // RegisterErrorLogFunc registers a function that is called when a specific error interface is seen by UnwrapError.
 // If you have your own error types (structs) that you want to log, it is easier to implement a SetLogFields method
 // to handle logging. RegisterErrorLogFunc should be used for other error types that you don't have any control over,
@@ -87,7 +217,12 @@ func NewHTTPError(err error, code int, msg ...interface{}) error {
 //	  fields["temporary"] = oe.Temporary()
 //	  fields["timeout"] = oe.Timeout()
 //	}, (*net.OpError)(nil))
+//
+// RegisterErrorLogFunc is safe for concurrent use, and functions can be registered while errors are logged, for example
+// by lazily initialized modules.
 func RegisterErrorLogFunc(errFmtFunc ErrLogFunc, errList ...error) {
+	errorLogFunctionsMu.Lock()
+	defer errorLogFunctionsMu.Unlock()
 	for _, err := range errList {
 		t := reflect.ValueOf(err)
 		if t.Kind() == reflect.Ptr && t.IsNil() {
@@ -96,36 +231,284 @@ func RegisterErrorLogFunc(errFmtFunc ErrLogFunc, errList ...error) {
 			registeredErrorLogFunctions[err] = errFmtFunc
 		}
 	}
+	compileErrorRegistry()
+}
+
+// RegisterErrorLogFuncFor registers a function that is called with the errors of type T in the error chain, without
+// the need for a type assertion in the function, or a nil pointer to register the type:
+//
+//	eal.RegisterErrorLogFuncFor(func(err *net.OpError, fields eal.Fields) {
+//	  fields["net_oper"] = err.Op
+//	})
+//
+// T can also be an interface type, for example net.Error, and is then registered as by
+// RegisterErrorLogFuncForInterface.
+func RegisterErrorLogFuncFor[T error](f func(err T, fields Fields)) {
+	logFunc := func(err error, fields Fields) {
+		if e, ok := err.(T); ok {
+			f(e, fields)
+		}
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Interface {
+		RegisterErrorLogFuncForInterface(logFunc, (*T)(nil))
+		return
+	}
+	errorLogFunctionsMu.Lock()
+	registeredErrorLogFunctions[t] = logFunc
+	compileErrorRegistry()
+	errorLogFunctionsMu.Unlock()
+}
+
+// RegisterErrorLogFuncForInterface registers a function that is called for the errors in the error chain that
+// implement an interface, for example net.Error, that isn't handled by SetLogFields or by a function registered with
+// RegisterErrorLogFunc. The interfaces are provided as nil pointers to the interface types:
+//
+//	eal.RegisterErrorLogFuncForInterface(func(err error, fields eal.Fields) {
+//	  fields["timeout"] = err.(interface{ Timeout() bool }).Timeout()
+//	}, (*interface{ Timeout() bool })(nil))
+//
+// If an error implement more than one of the registered interfaces, only the function of the interface that was
+// registered first is called. RegisterErrorLogFuncForInterface panics if a value isn't a pointer to an interface type.
+func RegisterErrorLogFuncForInterface(errFmtFunc ErrLogFunc, ifaceList ...interface{}) {
+	errorLogFunctionsMu.Lock()
+	defer errorLogFunctionsMu.Unlock()
+	for _, iface := range ifaceList {
+		t := reflect.TypeOf(iface)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+			panic(fmt.Sprintf("eal: RegisterErrorLogFuncForInterface: %T is not a pointer to an interface type", iface))
+		}
+		interfaceErrorLogFunctions = append(interfaceErrorLogFunctions, interfaceErrLogFunc{iface: t.Elem(), logFunc: errFmtFunc})
+	}
+	compileErrorRegistry()
+}
+
+// RegisterErrorFieldPrefix registers a prefix for the log fields that are set by the errors of a specific
+// type/instance, either by SetLogFields or by a registered ErrLogFunc. The fields are logged as "<prefix>.<field>",
+// which avoid collisions between libraries that use the same field names, for example:
+//
+//	eal.RegisterErrorFieldPrefix("pg", (*pgconn.PgError)(nil))
+//
+// log the "code" field of a *pgconn.PgError as "pg.code". Errors are registered in the same way as for
+// RegisterErrorLogFunc, and RegisterErrorFieldPrefix is safe for concurrent use. See also ErrorFieldConflictPolicy.
+func RegisterErrorFieldPrefix(prefix string, errList ...error) {
+	errorLogFunctionsMu.Lock()
+	defer errorLogFunctionsMu.Unlock()
+	for _, err := range errList {
+		t := reflect.ValueOf(err)
+		if t.Kind() == reflect.Ptr && t.IsNil() {
+			registeredErrorFieldPrefix[reflect.TypeOf(err)] = prefix
+		} else {
+			registeredErrorFieldPrefix[err] = prefix
+		}
+	}
+	compileErrorRegistry()
+}
+
+// compileErrorRegistry publish a new snapshot of the registered error log functions and field prefixes, it must be
+// called with errorLogFunctionsMu held, after the registrations have been changed.
+func compileErrorRegistry() {
+	r := &errorRegistry{
+		logFuncs:  make(map[interface{}]ErrLogFunc, len(registeredErrorLogFunctions)),
+		ifaces:    append([]interfaceErrLogFunc(nil), interfaceErrorLogFunctions...),
+		prefixes:  make(map[interface{}]string, len(registeredErrorFieldPrefix)),
+		instances: make(map[reflect.Type]bool),
+	}
+	for k, f := range registeredErrorLogFunctions {
+		r.logFuncs[k] = f
+		if _, ok := k.(reflect.Type); !ok {
+			r.instances[reflect.TypeOf(k)] = true
+		}
+	}
+	for k, prefix := range registeredErrorFieldPrefix {
+		r.prefixes[k] = prefix
+		if _, ok := k.(reflect.Type); !ok {
+			r.instances[reflect.TypeOf(k)] = true
+		}
+	}
+	stackTraceType, httpErrorType := reflect.TypeOf((*ErrorStackTrace)(nil)), reflect.TypeOf((*echo.HTTPError)(nil))
+	_, r.stackTraceMatched = r.prefixes[stackTraceType]
+	r.stackTraceMatched = r.stackTraceMatched || r.instances[stackTraceType]
+	if _, ok := r.prefixes[httpErrorType]; !ok && !r.instances[httpErrorType] {
+		r.httpErrorLogFunc = r.logFuncs[httpErrorType]
+	}
+	errorRegistrySnapshot.Store(r)
+}
+
+// loadErrorRegistry return the current snapshot of the error registrations.
+func loadErrorRegistry() *errorRegistry {
+	if r := errorRegistrySnapshot.Load(); r != nil {
+		return r
+	}
+	return &emptyErrorRegistry
+}
+
+// matcher return the errorMatcher of the error type, it is compiled the first time the type is seen.
+func (r *errorRegistry) matcher(err error) *errorMatcher {
+	t := reflect.TypeOf(err)
+	if m, ok := r.matchers.Load(t); ok {
+		return m.(*errorMatcher)
+	}
+
+	m := &errorMatcher{logFunc: r.logFuncs[t], instances: r.instances[t] && t.Comparable()}
+	m.prefix, m.hasPrefix = r.prefixes[t]
+	for _, ilf := range r.ifaces {
+		if t.Implements(ilf.iface) {
+			m.ifaceFunc = ilf.logFunc
+			break
+		}
+	}
+	actual, _ := r.matchers.LoadOrStore(t, m)
+	return actual.(*errorMatcher)
+}
+
+// errorLogFunc return the ErrLogFunc that is registered for the error type, the error instance, or for an interface
+// that the error implement.
+func (r *errorRegistry) errorLogFunc(err error, m *errorMatcher) (ErrLogFunc, bool) {
+	if m.logFunc != nil {
+		return m.logFunc, true
+	}
+	if m.instances {
+		if logFunc, ok := r.logFuncs[err]; ok {
+			return logFunc, true
+		}
+	}
+	return m.ifaceFunc, m.ifaceFunc != nil
+}
+
+// errorFieldPrefix return the field prefix that is registered for the error type or instance, see
+// RegisterErrorFieldPrefix.
+func (r *errorRegistry) errorFieldPrefix(err error, m *errorMatcher) (string, bool) {
+	if m.hasPrefix {
+		return m.prefix, true
+	}
+	if m.instances && len(r.prefixes) > 0 {
+		prefix, ok := r.prefixes[err]
+		return prefix, ok
+	}
+	return "", false
 }
 
 // UnwrapError walks the error-chain and add information to the provided log-fields. For each error in the error-chain,
 // it will check if the error either implements the SetLogFields(map[string]interface{}) interface or if the type have a
 // registered log function that is used to populate the log-fields.
 // This is used by Entry.WithError to add error information to a log event.
+//
+// When more than one error in the chain set the same field, ErrorFieldConflictPolicy decide which value is logged,
+// and fields can be namespaced per error type with RegisterErrorFieldPrefix.
+//
+// Errors that wrap several errors, like the errors returned by errors.Join, have all their branches walked. The fields
+// of the first branch are added in the same way as for a single error chain, and the fields of the other branches are
+// only added if they aren't already set. Errors that implement both SetLogFields and Unwrap() []error, like
+// GroupError, are expected to log the fields of their branches themselves, and their branches aren't walked.
+//
+// The registered functions and prefixes are resolved once per error type, so walking an error chain of already seen
+// error types is lock free. ErrorStackTrace and echo.HTTPError errors are handled without the lookup, so for the common
+// chain of the two, only the error_message field allocate.
 func UnwrapError(err error, fields map[string]interface{}) {
 	if err == nil {
 		return
 	}
 
-	fields[errorMessage] = err.Error()
+	fields[FieldErrorMessage] = err.Error()
+	unwrapErrorChain(loadErrorRegistry(), err, fields)
+}
 
+// unwrapErrorChain add the fields of the errors in the error chain, see UnwrapError.
+func unwrapErrorChain(r *errorRegistry, err error, fields map[string]interface{}) {
 	for err != nil {
+		if ErrorFieldConflictPolicy == ConflictOverride {
+			switch e := err.(type) {
+			case *ErrorStackTrace:
+				if !r.stackTraceMatched {
+					e.SetLogFields(fields)
+					err = e.err
+					continue
+				}
+			case *echo.HTTPError:
+				if r.httpErrorLogFunc != nil {
+					r.httpErrorLogFunc(e, fields)
+					err = e.Internal
+					continue
+				}
+			case *statusError:
+				err = e.err
+				continue
+			}
+		}
+
+		m := r.matcher(err)
+
 		// First check if error implement SetLogFields(LogFields)
 		if slf, ok := err.(interface{ SetLogFields(map[string]interface{}) }); ok {
-			slf.SetLogFields(fields)
+			prefix, hasPrefix := r.errorFieldPrefix(err, m)
+			setErrorFields(prefix, hasPrefix, fields, slf.SetLogFields)
 			err = errors.Unwrap(err)
 			continue
 		}
 
 		// Check if error type have a registered ErrLogFunc
-		t := reflect.TypeOf(err)
-		if logFunc, ok := registeredErrorLogFunctions[t]; ok {
-			logFunc(err, fields)
-		} else if t.Comparable() {
-			if logFunc, ok := registeredErrorLogFunctions[err]; ok {
-				logFunc(err, fields)
+		if logFunc, ok := r.errorLogFunc(err, m); ok {
+			prefix, hasPrefix := r.errorFieldPrefix(err, m)
+			setErrorFields(prefix, hasPrefix, fields, func(f map[string]interface{}) { logFunc(err, f) })
+		}
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for i, branch := range multi.Unwrap() {
+				if i == 0 {
+					unwrapErrorChain(r, branch, fields)
+					continue
+				}
+				branchFields := make(map[string]interface{})
+				unwrapErrorChain(r, branch, branchFields)
+				for k, v := range branchFields {
+					if _, ok := fields[k]; !ok || ErrorFieldConflictPolicy != ConflictOverride {
+						setFieldWithPolicy(fields, k, v, ErrorFieldConflictPolicy)
+					}
+				}
 			}
+			return
 		}
 		err = errors.Unwrap(err)
 	}
 }
+
+// setErrorFields call set to add the log fields of an error. If the error have a registered field prefix, or if
+// ErrorFieldConflictPolicy isn't ConflictOverride, the fields are first set in a separate map, and then merged into
+// fields.
+func setErrorFields(prefix string, ok bool, fields map[string]interface{}, set func(map[string]interface{})) {
+	if !ok && ErrorFieldConflictPolicy == ConflictOverride {
+		set(fields)
+		return
+	}
+
+	errFields := make(map[string]interface{})
+	set(errFields)
+	for k, v := range errFields {
+		if ok {
+			k = prefix + "." + k
+		}
+		setFieldWithPolicy(fields, k, v, ErrorFieldConflictPolicy)
+	}
+}
+
+// innermostError return the last error in the error chain. For errors that wrap several errors, the first branch is
+// followed, except for errors that implement SetLogFields, see UnwrapError.
+func innermostError(err error) error {
+	for {
+		if next := errors.Unwrap(err); next != nil {
+			err = next
+			continue
+		}
+		if _, ok := err.(interface{ SetLogFields(map[string]interface{}) }); ok {
+			return err
+		}
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			if errs := multi.Unwrap(); len(errs) > 0 && errs[0] != nil {
+				err = errs[0]
+				continue
+			}
+		}
+		return err
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -216,6 +218,16 @@ func TestUnwrapError(t *testing.T) {
 			err:  nonComparableError{lines: []string{"test", "lines"}},
 			want: map[string]interface{}{"error_message": "test,lines"},
 		},
+		{
+			name: "joined",
+			err:  errors.Join(testSetLogFieldsErr{}, fmt.Errorf("wrapped: %w", &testErr{e: context.DeadlineExceeded})),
+			want: map[string]interface{}{"error_message": "testErr\nwrapped: testErr", "set_log_fields": true, "registeredErrorLogFunctions": true, "timeout": true, "temporary": true, "type_*eal.testErr": true, "type_context.deadlineExceededError": true},
+		},
+		{
+			name: "joined_first_branch_first",
+			err:  fmt.Errorf("wrapped: %w", errors.Join(&testErr{}, context.DeadlineExceeded)),
+			want: map[string]interface{}{"error_message": "wrapped: testErr\ncontext deadline exceeded", "registeredErrorLogFunctions": true, "temporary": false, "timeout": true, "type_*eal.testErr": true, "type_context.deadlineExceededError": true},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			got := make(map[string]any)
@@ -229,3 +241,285 @@ func TestUnwrapError(t *testing.T) {
 		})
 	}
 }
+
+type concurrentTestError struct{ n int }
+
+func (e concurrentTestError) Error() string { return fmt.Sprintf("error %d", e.n) }
+
+// TestConcurrentRegistration is intended to be run with -race, to detect races between registration and logging.
+func TestConcurrentRegistration(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			err := concurrentTestError{n: i}
+			RegisterErrorLogFunc(func(err error, fields Fields) { fields["n"] = i }, err)
+			InhibitStacktraceForError(err)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			err := Trace(fmt.Errorf("wrapped: %w", concurrentTestError{n: i}))
+			UnwrapError(err, Fields{})
+		}(i)
+	}
+	wg.Wait()
+
+	t.Cleanup(func() {
+		errorLogFunctionsMu.Lock()
+		inhibitStacktraceMu.Lock()
+		for i := 0; i < 10; i++ {
+			delete(registeredErrorLogFunctions, concurrentTestError{n: i})
+			delete(inhibitStacktraceForError, concurrentTestError{n: i})
+		}
+		inhibitStacktraceMu.Unlock()
+		compileErrorRegistry()
+		errorLogFunctionsMu.Unlock()
+	})
+
+	fields := Fields{}
+	UnwrapError(concurrentTestError{n: 3}, fields)
+	if fields["n"] != 3 {
+		t.Errorf("got n: %v, want: 3", fields["n"])
+	}
+	if _, ok := GetErrorStackTrace(Trace(concurrentTestError{n: 3})); ok {
+		t.Error("got an ErrorStackTrace, want the inhibited error unmodified")
+	}
+}
+
+func TestJoinedErrors(t *testing.T) {
+	errNotFound := NewHTTPError(errors.New("no rows"), http.StatusNotFound, "user not found")
+	for _, tt := range []struct {
+		name     string
+		err      error
+		wantCode int
+		wantType string
+	}{
+		{name: "http error in second branch", err: errors.Join(errors.New("cache miss"), fmt.Errorf("load: %w", errNotFound)), wantCode: http.StatusNotFound, wantType: "*errors.errorString"},
+		{name: "inner http error", err: errors.Join(NewHTTPError(errors.Join(io.EOF, errNotFound), http.StatusBadGateway)), wantCode: http.StatusNotFound, wantType: "*errors.errorString"},
+		{name: "no http error", err: errors.Join(Trace(io.EOF), errors.New("b")), wantType: "*errors.errorString"},
+		{name: "group error", err: &GroupError{errs: []error{io.EOF, io.ErrClosedPipe}}, wantType: "*eal.GroupError"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			code := 0
+			if he := GetInnerHTTPError(tt.err); he != nil {
+				code = he.Code
+			}
+			if code != tt.wantCode {
+				t.Errorf("got HTTPError code: %d, want: %d", code, tt.wantCode)
+			}
+
+			e := NewEntry().withError(tt.err)
+			if got := e.Data[FieldErrorType]; got != tt.wantType {
+				t.Errorf("got %s: %v, want: %s", FieldErrorType, got, tt.wantType)
+			}
+		})
+	}
+}
+
+type (
+	retryableError  struct{ after int }
+	permanentError  struct{}
+	retryableTarget interface{ RetryAfter() int }
+)
+
+func (e *retryableError) Error() string   { return "retryable" }
+func (e *retryableError) RetryAfter() int { return e.after }
+func (permanentError) Error() string      { return "permanent" }
+
+func TestRegisterErrorLogFuncForInterface(t *testing.T) {
+	RegisterErrorLogFuncForInterface(func(err error, fields Fields) {
+		fields["retry_after"] = err.(retryableTarget).RetryAfter()
+	}, (*retryableTarget)(nil))
+	RegisterErrorLogFuncForInterface(func(err error, fields Fields) {
+		fields["any_error"] = true
+	}, (*error)(nil))
+	t.Cleanup(func() {
+		errorLogFunctionsMu.Lock()
+		interfaceErrorLogFunctions = nil
+		compileErrorRegistry()
+		errorLogFunctionsMu.Unlock()
+	})
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want Fields
+	}{
+		{name: "implements", err: &retryableError{after: 5}, want: Fields{FieldErrorMessage: "retryable", "retry_after": 5}},
+		{name: "wrapped", err: fmt.Errorf("call: %w", &retryableError{after: 5}), want: Fields{FieldErrorMessage: "call: retryable", "retry_after": 5, "any_error": true}},
+		{name: "first registered interface", err: permanentError{}, want: Fields{FieldErrorMessage: "permanent", "any_error": true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fields{}
+			UnwrapError(tt.err, got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got fields: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic for a non interface type, want panic")
+		}
+	}()
+	RegisterErrorLogFuncForInterface(func(err error, fields Fields) {}, (*retryableError)(nil))
+}
+
+type codeError struct{ code int }
+
+func (e codeError) Error() string { return fmt.Sprintf("code error %d", e.code) }
+
+func TestRegisterErrorLogFuncFor(t *testing.T) {
+	RegisterErrorLogFuncFor(func(err codeError, fields Fields) {
+		fields["code"] = err.code
+	})
+	RegisterErrorLogFuncFor(func(err *retryableError, fields Fields) {
+		fields["retry_after"] = err.after
+	})
+	RegisterErrorLogFuncFor(func(err interface {
+		error
+		RetryAfter() int
+	}, fields Fields) {
+		fields["retryable"] = true
+	})
+	t.Cleanup(func() {
+		errorLogFunctionsMu.Lock()
+		delete(registeredErrorLogFunctions, reflect.TypeOf(codeError{}))
+		delete(registeredErrorLogFunctions, reflect.TypeOf(&retryableError{}))
+		interfaceErrorLogFunctions = nil
+		compileErrorRegistry()
+		errorLogFunctionsMu.Unlock()
+	})
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want Fields
+	}{
+		{name: "value type", err: fmt.Errorf("call: %w", codeError{code: 7}), want: Fields{FieldErrorMessage: "call: code error 7", "code": 7}},
+		{name: "pointer type", err: &retryableError{after: 5}, want: Fields{FieldErrorMessage: "retryable", "retry_after": 5}},
+		{name: "interface type", err: struct{ *retryableError }{&retryableError{after: 5}}, want: Fields{FieldErrorMessage: "retryable", "retryable": true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fields{}
+			UnwrapError(tt.err, got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got fields: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorFieldNamespacing(t *testing.T) {
+	RegisterErrorLogFuncFor(func(err codeError, fields Fields) {
+		fields["code"] = err.code
+	})
+	RegisterErrorFieldPrefix("lib", codeError{code: 7})
+	t.Cleanup(func() {
+		errorLogFunctionsMu.Lock()
+		delete(registeredErrorLogFunctions, reflect.TypeOf(codeError{}))
+		delete(registeredErrorFieldPrefix, codeError{code: 7})
+		compileErrorRegistry()
+		errorLogFunctionsMu.Unlock()
+		ErrorFieldConflictPolicy = ConflictOverride
+	})
+
+	appErr := testSetLogFieldsErr{e: codeError{code: 7}}
+	for _, tt := range []struct {
+		name   string
+		policy ConflictPolicy
+		err    error
+		want   Fields
+	}{
+		{name: "prefix instance", err: appErr, want: Fields{FieldErrorMessage: "testErr", "set_log_fields": true, "lib.code": 7}},
+		{name: "no prefix for other instances", err: codeError{code: 8}, want: Fields{FieldErrorMessage: "code error 8", "code": 8}},
+		{name: "collect", policy: ConflictCollect, err: testSetLogFieldsErr{e: testSetLogFieldsErr{e: codeError{code: 8}}}, want: Fields{FieldErrorMessage: "testErr", "set_log_fields": true, "code": 8}},
+		{name: "collect joined", policy: ConflictCollect, err: errors.Join(codeError{code: 8}, codeError{code: 9}), want: Fields{FieldErrorMessage: "code error 8\ncode error 9", "code": CollectedValues{8, 9}}},
+		{name: "override joined", err: errors.Join(codeError{code: 8}, codeError{code: 9}), want: Fields{FieldErrorMessage: "code error 8\ncode error 9", "code": 8}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ErrorFieldConflictPolicy = tt.policy
+			got := Fields{}
+			UnwrapError(tt.err, got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got fields: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorIs(t *testing.T) {
+	err := fmt.Errorf("load user: %w", NewHTTPError(ErrTest, http.StatusNotFound, "user not found"))
+	for _, tt := range []struct {
+		target error
+		want   bool
+	}{
+		{target: StatusNotFound, want: true},
+		{target: StatusClientError, want: true},
+		{target: StatusCode(http.StatusNotFound), want: true},
+		{target: StatusServerError, want: false},
+		{target: StatusBadRequest, want: false},
+		{target: ErrTest, want: true},
+	} {
+		if got := errors.Is(err, tt.target); got != tt.want {
+			t.Errorf("errors.Is(err, %v): got %v, want: %v", tt.target, got, tt.want)
+		}
+	}
+
+	if _, ok := errors.Unwrap(err).(*echo.HTTPError); !ok {
+		t.Errorf("got %T, want *echo.HTTPError", errors.Unwrap(err))
+	}
+	if errors.Is(NotFound(nil), StatusNotFound) {
+		t.Error("errors.Is match an echo.HTTPError without internal error")
+	}
+	if GetInnerHTTPError(err) == nil || GetOuterHTTPError(err).Code != http.StatusNotFound {
+		t.Errorf("got no echo.HTTPError from %T", errors.Unwrap(err))
+	}
+	if StatusClientError.Error() != "4xx" || StatusNotFound.Error() != "404 Not Found" {
+		t.Errorf("got status messages: %q, %q", StatusClientError.Error(), StatusNotFound.Error())
+	}
+}
+
+func TestUnwrapErrorAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations aren't measured with the race detector")
+	}
+	InitDefaultErrorLogging()
+	err := Trace(NewHTTPError(ErrTest, http.StatusNotFound, "user not found"))
+	fields := Fields{}
+	msgAllocs := testing.AllocsPerRun(100, func() {
+		fields[FieldErrorMessage] = err.Error()
+	})
+	allocs := testing.AllocsPerRun(100, func() {
+		UnwrapError(err, fields)
+	})
+	if allocs != msgAllocs {
+		t.Errorf("got %v allocations, want %v (the error message)", allocs, msgAllocs)
+	}
+	if fields[FieldHTTPStatus] != http.StatusNotFound || fields[FieldErrorStack] == nil || fields[FieldErrorOrigin] == nil {
+		t.Errorf("got fields: %v, want http_status, error_stack and error_origin", fields)
+	}
+}
+
+func BenchmarkUnwrapError(b *testing.B) {
+	InitDefaultErrorLogging()
+	err := Trace(NewHTTPError(ErrTest, http.StatusNotFound, "user not found"))
+	fields := make(Fields, 8)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		UnwrapError(err, fields)
+	}
+}
+
+func BenchmarkEntryWithError(b *testing.B) {
+	InitDefaultErrorLogging()
+	err := Trace(NewHTTPError(ErrTest, http.StatusNotFound, "user not found"))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewEntry().WithError(err).Release()
+	}
+}
@@ -0,0 +1,137 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type (
+	// ErrorCode is an application error, declared once with RegisterErrorCode, that hold everything needed to both
+	// respond to the caller and log the error.
+	ErrorCode struct {
+		// Name is the unique name of the code, for example "user.disabled".
+		Name string
+
+		// ID is the unique numeric id of the code, sent to the caller in the error_code field of the ErrorPayload.
+		ID int
+
+		// Status is the HTTP status of the response.
+		Status int
+
+		// MessageKey is sent to the caller in the error_message field of the ErrorPayload, typically a translation key
+		// like "user.error.disabled".
+		MessageKey string
+
+		// Severity is the level of the access log entry of requests that fail with the code. A nil Severity leave
+		// the level to AccessLevelFunc, see Level.Ptr.
+		Severity *Level
+	}
+
+	// ErrorPayload is the response body sent to the caller for errors created by ErrorCode.Err.
+	ErrorPayload struct {
+		ErrorCode    int    `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+
+		// Message is the localized message, if any, see RegisterMessages.
+		Message string `json:"message,omitempty"`
+	}
+
+	// CodedError is the error created by ErrorCode.Err. It's logged with the error_code and error_code_name fields.
+	CodedError struct {
+		code   *ErrorCode
+		err    error
+		params Fields
+	}
+)
+
+var (
+	errorCodesMu sync.RWMutex
+	errorCodes   = map[string]*ErrorCode{}
+	errorCodeIDs = map[int]*ErrorCode{}
+)
+
+// RegisterErrorCode register an error code, and return it so that it can be kept in a variable. It panics if the name
+// or the id is already registered, since codes are meant to be declared once, at init:
+//
+//	var ErrUserDisabled = eal.RegisterErrorCode(eal.ErrorCode{
+//	  Name: "user.disabled", ID: 42, Status: http.StatusForbidden, MessageKey: "user.error.disabled",
+//	})
+//
+//	...
+//	  if usr.Disabled {
+//	    return ErrUserDisabled.Err(nil) // Return 403 {"error_code":42,"error_message":"user.error.disabled"}
+//	  }
+func RegisterErrorCode(code ErrorCode) *ErrorCode {
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+	if _, ok := errorCodes[code.Name]; ok {
+		panic(fmt.Sprintf("eal: error code %q already registered", code.Name))
+	}
+	if c, ok := errorCodeIDs[code.ID]; ok {
+		panic(fmt.Sprintf("eal: error code id %d of %q already registered by %q", code.ID, code.Name, c.Name))
+	}
+	ec := &code
+	errorCodes[code.Name] = ec
+	errorCodeIDs[code.ID] = ec
+	return ec
+}
+
+// LookupErrorCode return the registered error code with the name.
+func LookupErrorCode(name string) (*ErrorCode, bool) {
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
+	ec, ok := errorCodes[name]
+	return ec, ok
+}
+
+// Err return an error that respond to the caller with the status and ErrorPayload of the code, when it's returned from
+// an echo handler. The cause is wrapped by Trace, and can be nil. errors.Is report true for the returned error and the
+// ErrorCode.
+func (ec *ErrorCode) Err(cause error) error {
+	return NewHTTPError(&CodedError{code: ec, err: Trace(cause)}, ec.Status, &ErrorPayload{ErrorCode: ec.ID, ErrorMessage: ec.MessageKey})
+}
+
+// Error return the name of the code, which make it possible to use the code as a target of errors.Is.
+func (ec *ErrorCode) Error() string {
+	return ec.Name
+}
+
+// Error return the name of the code, and the message of the cause.
+func (ce *CodedError) Error() string {
+	if ce.err == nil {
+		return ce.code.Name
+	}
+	return ce.code.Name + ": " + ce.err.Error()
+}
+
+// Unwrap return the cause.
+func (ce *CodedError) Unwrap() error {
+	return ce.err
+}
+
+// Is report if target is the ErrorCode of the error.
+func (ce *CodedError) Is(target error) bool {
+	ec, ok := target.(*ErrorCode)
+	return ok && ec == ce.code
+}
+
+// Code return the ErrorCode of the error.
+func (ce *CodedError) Code() *ErrorCode {
+	return ce.code
+}
+
+// SetLogFields is used by Entry.WithError to populate log fields.
+func (ce *CodedError) SetLogFields(logFields map[string]interface{}) {
+	logFields[FieldErrorCode] = ce.code.ID
+	logFields[FieldErrorCodeName] = ce.code.Name
+}
+
+// codeSeverity return the severity of the ErrorCode in the error chain, if any.
+func codeSeverity(err error) (Level, bool) {
+	var ce *CodedError
+	if errors.As(err, &ce) && ce.code.Severity != nil {
+		return *ce.code.Severity, true
+	}
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package eal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestErrorCode(t *testing.T) {
+	errUserDisabled := RegisterErrorCode(ErrorCode{Name: "user.disabled", ID: 42, Status: http.StatusForbidden, MessageKey: "user.error.disabled"})
+	errPaymentFailed := RegisterErrorCode(ErrorCode{Name: "payment.failed", ID: 43, Status: http.StatusPaymentRequired, MessageKey: "payment.error.failed", Severity: WarnLevel.Ptr()})
+	t.Cleanup(func() {
+		errorCodesMu.Lock()
+		errorCodes = map[string]*ErrorCode{}
+		errorCodeIDs = map[int]*ErrorCode{}
+		errorCodesMu.Unlock()
+	})
+	entries := captureLog(t)
+
+	errDeclined := errors.New("card declined")
+	for _, tt := range []struct {
+		name      string
+		err       error
+		wantBody  string
+		wantCode  int
+		wantLevel string
+	}{
+		{
+			name:      "without cause",
+			err:       errUserDisabled.Err(nil),
+			wantBody:  `{"error_code":42,"error_message":"user.error.disabled"}`,
+			wantCode:  http.StatusForbidden,
+			wantLevel: "info",
+		},
+		{
+			name:      "with cause and severity",
+			err:       errPaymentFailed.Err(errDeclined),
+			wantBody:  `{"error_code":43,"error_message":"payment.error.failed"}`,
+			wantCode:  http.StatusPaymentRequired,
+			wantLevel: "warning",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(CreateLoggerMiddleware(), httptest.NewRequest(http.MethodGet, "/", nil), func(c echo.Context) error {
+				return tt.err
+			})
+			if rec.Code != tt.wantCode || strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("got response: %d %s, want: %d %s", rec.Code, rec.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0][FieldErrorCode] != float64(42) || logged[0][FieldErrorCodeName] != "user.disabled" || logged[0]["level"] != "info" {
+		t.Errorf("got first entry: %v, want error code 42, user.disabled and info level", logged[0])
+	}
+	if logged[1][FieldErrorCode] != float64(43) || logged[1][FieldErrorStack] == nil || logged[1]["level"] != "warning" {
+		t.Errorf("got second entry: %v, want error code 43 with stacktrace and warning level", logged[1])
+	}
+
+	err := errPaymentFailed.Err(errDeclined)
+	if !errors.Is(err, errPaymentFailed) || !errors.Is(err, errDeclined) || errors.Is(err, errUserDisabled) {
+		t.Error("got errors.Is mismatch, want the error to match its code and cause only")
+	}
+	if ec, ok := LookupErrorCode("user.disabled"); !ok || ec != errUserDisabled {
+		t.Errorf("got lookup: %v, %t, want the registered code", ec, ok)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic for duplicate error code id")
+		}
+	}()
+	RegisterErrorCode(ErrorCode{Name: "user.other", ID: 42})
+}
+
+func TestErrorCodeSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		severity *Level
+		want     Level
+		ok       bool
+	}{
+		{severity: nil, ok: false},
+		{severity: PanicLevel.Ptr(), want: PanicLevel, ok: true},
+		{severity: WarnLevel.Ptr(), want: WarnLevel, ok: true},
+	} {
+		code := &ErrorCode{Name: "severity.test", Status: http.StatusBadRequest, Severity: tc.severity}
+		err := code.Err(nil)
+		if level, ok := codeSeverity(err); level != tc.want || ok != tc.ok {
+			t.Errorf("got severity %v, %v, want %v, %v", level, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package eal
+
+// FieldsError is returned by WithErrorFields, and add log fields to the error that it wrap.
+type FieldsError struct {
+	err    error
+	fields Fields
+}
+
+// WithErrorFields wrap the error in a FieldsError that add the fields to the log entry when the error is logged, see
+// UnwrapError. This make it possible to annotate an error with information that is only known at the point of
+// failure, and have it logged in the access log entry of the request:
+//
+//	if err != nil {
+//	  return eal.WithErrorFields(err, eal.Fields{"order_id": order.ID, "user_id": user.ID})
+//	}
+//
+// The fields are copied, and nil is returned if err is nil. The error message isn't changed, and errors.Is and
+// errors.As see the wrapped error.
+func WithErrorFields(err error, fields Fields) error {
+	if err == nil {
+		return nil
+	}
+	fe := &FieldsError{err: err, fields: make(Fields, len(fields))}
+	for k, v := range fields {
+		fe.fields[k] = v
+	}
+	return fe
+}
+
+// Error return the message of the wrapped error.
+func (fe *FieldsError) Error() string {
+	return fe.err.Error()
+}
+
+// Unwrap return the wrapped error.
+func (fe *FieldsError) Unwrap() error {
+	return fe.err
+}
+
+// Fields return a copy of the log fields of the error.
+func (fe *FieldsError) Fields() Fields {
+	fields := make(Fields, len(fe.fields))
+	for k, v := range fe.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// SetLogFields is used by Entry.WithError to populate log fields.
+func (fe *FieldsError) SetLogFields(logFields map[string]interface{}) {
+	for k, v := range fe.fields {
+		logFields[k] = v
+	}
+}
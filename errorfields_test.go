@@ -0,0 +1,49 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestWithErrorFields(t *testing.T) {
+	if WithErrorFields(nil, Fields{"user_id": 1}) != nil {
+		t.Error("got non nil error for nil error")
+	}
+
+	errNotFound := errors.New("not found")
+	fields := Fields{"order_id": 42}
+	err := fmt.Errorf("load order: %w", WithErrorFields(errNotFound, fields))
+	fields["order_id"] = 43
+
+	if !errors.Is(err, errNotFound) {
+		t.Error("errors.Is don't find the wrapped error")
+	}
+	got := Fields{}
+	UnwrapError(err, got)
+	want := Fields{FieldErrorMessage: "load order: not found", "order_id": 42}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got fields: %v, want: %v", got, want)
+	}
+}
+
+func TestWithErrorFieldsAccessLog(t *testing.T) {
+	entries := captureLog(t)
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		return WithErrorFields(errors.New("db down"), Fields{"order_id": "42"})
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0]["order_id"] != "42" {
+		t.Errorf("got entry: %v, want order_id 42", logged[0])
+	}
+}
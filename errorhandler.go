@@ -0,0 +1,67 @@
+package eal
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// handledErrorContextName is the echo context key of the error that the logger middleware pass to the echo error
+// handler, so that HTTPErrorHandler don't log the error a second time.
+const handledErrorContextName = "mfContextHandledError"
+
+// HTTPErrorHandler is an echo.HTTPErrorHandler, configured by DefaultLoggerConfig, that handle errors in the same way
+// as the logger middleware:
+//
+//	e.HTTPErrorHandler = eal.HTTPErrorHandler
+//
+// Errors that are returned by middlewares that run before the logger middleware, or that are returned when the logger
+// middleware isn't used at all, are sent to the caller with the status code and message of the echo.HTTPError in the
+// error chain, including error codes and dev error responses, and are logged with the request fields. Errors that the
+// logger middleware have already logged are only sent to the caller.
+func HTTPErrorHandler(err error, c echo.Context) {
+	HTTPErrorHandlerWithConfig(DefaultLoggerConfig)(err, c)
+}
+
+// HTTPErrorHandlerWithConfig return an echo.HTTPErrorHandler that is configured by the provided LoggerConfig, see
+// HTTPErrorHandler.
+func HTTPErrorHandlerWithConfig(config LoggerConfig) echo.HTTPErrorHandler {
+	deps := Deps{}.withDefaults()
+	return func(err error, c echo.Context) {
+		if handled, ok := c.Get(handledErrorContextName).(*echo.HTTPError); ok && handled == err {
+			c.Echo().DefaultHTTPErrorHandler(err, c)
+			return
+		}
+		if c.Response().Committed {
+			return
+		}
+
+		// Use the request fields of the logger middleware if it have run, otherwise resolve them here
+		var logFields Fields
+		if rf, ok := c.Get(contextName).(*requestFields); ok {
+			logFields = rf.snapshot()
+		} else {
+			logFields = Fields{}
+			DefaultContextLogFunc(c, logFields)
+		}
+
+		errMsg, ok := responseHTTPError(config, err)
+		if !ok {
+			err = errMsg
+		}
+		headers := config.requestHeaders()
+		if id := pageViewID(c.Request(), headers.pageViewID); id != "" {
+			c.Response().Header().Set(headers.pageViewID, id)
+		}
+		c.Echo().DefaultHTTPErrorHandler(errorResponse(config, localizeErrorResponse(c, logFields, errMsg, err), err), c)
+
+		logFields[FieldRouterPath] = routerPath(c)
+		logFields[FieldStatus] = c.Response().Status
+		logEntry := NewEntry().WithFields(logFields).withError(err)
+		writeAccessEntry(c.Request().Context(), deps, config, logEntry, logFields, err, defaultAccessMessage)
+		if c.Response().Status >= http.StatusInternalServerError && isReportable(err) {
+			reportError(err, logEntry.Data)
+		}
+		releaseAccessEntry(deps, logEntry)
+	}
+}
@@ -0,0 +1,63 @@
+package eal
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	auth := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("Authorization") == "" {
+				return fmt.Errorf("auth: %w", echo.ErrUnauthorized)
+			}
+			return next(c)
+		}
+	}
+	handler := func(c echo.Context) error {
+		return fmt.Errorf("load user: %w", NotFound(fmt.Errorf("no rows"), "user not found"))
+	}
+
+	for _, tt := range []struct {
+		name        string
+		middlewares []echo.MiddlewareFunc
+		auth        bool
+		wantCode    int
+		wantBody    string
+	}{
+		{name: "without logger middleware", auth: true, wantCode: http.StatusNotFound, wantBody: "user not found"},
+		{name: "with logger middleware", middlewares: []echo.MiddlewareFunc{CreateLoggerMiddleware()}, auth: true, wantCode: http.StatusNotFound, wantBody: "user not found"},
+		{name: "before logger middleware", middlewares: []echo.MiddlewareFunc{auth, CreateLoggerMiddleware()}, wantCode: http.StatusUnauthorized, wantBody: "Unauthorized"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLog(t)
+			e := echo.New()
+			e.HTTPErrorHandler = HTTPErrorHandler
+			e.Use(tt.middlewares...)
+			e.GET("/users/:id", handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.auth {
+				req.Header.Set("Authorization", "Bearer x")
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got response: %d %s, want: %d %s", rec.Code, rec.Body.String(), tt.wantCode, tt.wantBody)
+			}
+			logged := entries()
+			if len(logged) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(logged))
+			}
+			if logged[0][FieldStatus] != float64(tt.wantCode) || logged[0][FieldRequestID] == nil || logged[0][FieldErrorMessage] == nil {
+				t.Errorf("got entry: %v, want status %d with request and error fields", logged[0], tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package eal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	rateBuckets = 10
+	maxSamples  = 5
+)
+
+type (
+	// ErrorRateMonitor keep track of the ratio of 5xx responses per route in a rolling time window, and write a single
+	// "error_rate_exceeded" log entry when the ratio of a route pass the threshold. A new entry is written for the route
+	// when the ratio have dropped below the threshold, and then pass it again.
+	//
+	// The monitor is hooked into the logger middleware by adding the Observe method to LoggerConfig.ResultLogFuncs:
+	//
+	//	m := &eal.ErrorRateMonitor{Threshold: 0.1, Window: time.Minute}
+	//	e.Use(eal.CreateLoggerMiddlewareWithConfig(eal.LoggerConfig{ResultLogFuncs: []eal.ContextLogFunc{m.Observe}}))
+	ErrorRateMonitor struct {
+		// Threshold is the ratio (0-1) of 5xx responses that trigger the log entry, 0.1 is used if Threshold isn't set.
+		Threshold float64
+
+		// Window is the length of the rolling time window, one minute is used if Window isn't set.
+		Window time.Duration
+
+		// MinRequests is the minimum number of requests that a route must have received in the time window before the
+		// ratio is checked, 20 is used if MinRequests isn't set.
+		MinRequests int
+
+		mu     sync.Mutex
+		routes map[string]*routeWindow
+		now    func() time.Time
+	}
+
+	routeWindow struct {
+		buckets [rateBuckets]rateBucket
+		alerted bool
+	}
+
+	rateBucket struct {
+		start        time.Time
+		total        int
+		errors       int
+		fingerprints []string
+	}
+)
+
+// Observe is a ContextLogFunc that record the result of a request, it should be added to LoggerConfig.ResultLogFuncs.
+func (m *ErrorRateMonitor) Observe(c echo.Context, fields Fields) {
+	status, _ := fields[FieldStatus].(int)
+	route, _ := fields[FieldRouterPath].(string)
+	if route == "" && c != nil {
+		route = c.Path()
+	}
+
+	alert := m.record(route, status >= 500, Fingerprint(fields))
+	if alert != nil {
+		NewEntry().WithFields(alert).Error("error_rate_exceeded")
+	}
+}
+
+// record add a request to the route window, and return the alert log fields if the threshold have been passed.
+func (m *ErrorRateMonitor) record(route string, failed bool, fingerprint string) Fields {
+	window := m.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+	minRequests := m.MinRequests
+	if minRequests <= 0 {
+		minRequests = 20
+	}
+	threshold := m.Threshold
+	if threshold <= 0 {
+		threshold = 0.1
+	}
+	now := time.Now()
+	if m.now != nil {
+		now = m.now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.routes == nil {
+		m.routes = make(map[string]*routeWindow)
+	}
+	rw, ok := m.routes[route]
+	if !ok {
+		rw = &routeWindow{}
+		m.routes[route] = rw
+	}
+
+	bucketSize := window / rateBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	start := now.Truncate(bucketSize)
+	b := &rw.buckets[(start.UnixNano()/int64(bucketSize))%rateBuckets]
+	if !b.start.Equal(start) {
+		*b = rateBucket{start: start}
+	}
+	b.total++
+	if failed {
+		b.errors++
+		if fingerprint != "" && len(b.fingerprints) < maxSamples && !contains(b.fingerprints, fingerprint) {
+			b.fingerprints = append(b.fingerprints, fingerprint)
+		}
+	}
+
+	var total, errs int
+	var samples []string
+	for i := range rw.buckets {
+		if now.Sub(rw.buckets[i].start) >= window {
+			continue
+		}
+		total += rw.buckets[i].total
+		errs += rw.buckets[i].errors
+		for _, fp := range rw.buckets[i].fingerprints {
+			if len(samples) < maxSamples && !contains(samples, fp) {
+				samples = append(samples, fp)
+			}
+		}
+	}
+
+	rate := float64(errs) / float64(total)
+	if errs == 0 || rate < threshold {
+		rw.alerted = false
+		return nil
+	}
+	if rw.alerted || total < minRequests {
+		return nil
+	}
+	rw.alerted = true
+
+	return Fields{
+		FieldRoute:              route,
+		FieldErrorRate:          rate,
+		FieldWindow:             window.String(),
+		FieldRequests:           total,
+		FieldErrors:             errs,
+		FieldSampleFingerprints: samples,
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
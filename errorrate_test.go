@@ -0,0 +1,65 @@
+package eal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorRateMonitor(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 37, 0, 0, time.UTC)
+	m := &ErrorRateMonitor{Threshold: 0.5, Window: time.Minute, MinRequests: 4, now: func() time.Time { return now }}
+
+	// Below MinRequests, no alert even if all requests fail
+	for i := 0; i < 3; i++ {
+		if alert := m.record("/users", true, "fp1"); alert != nil {
+			t.Fatalf("request %d: got alert: %v, want nil", i, alert)
+		}
+	}
+
+	alert := m.record("/users", false, "")
+	if alert == nil {
+		t.Fatal("got no alert, want alert when the error rate pass the threshold")
+	}
+	if alert["route"] != "/users" || alert["requests"] != 4 || alert["errors"] != 3 {
+		t.Errorf("got unexpected alert fields: %v", alert)
+	}
+	if samples, _ := alert["sample_fingerprints"].([]string); len(samples) != 1 || samples[0] != "fp1" {
+		t.Errorf("got sample_fingerprints: %v, want: [fp1]", alert["sample_fingerprints"])
+	}
+
+	// Only a single alert while the rate stay above the threshold
+	if alert = m.record("/users", true, "fp2"); alert != nil {
+		t.Errorf("got a second alert: %v, want nil", alert)
+	}
+
+	// Other routes are tracked separately
+	if alert = m.record("/other", true, ""); alert != nil {
+		t.Errorf("got alert for other route: %v, want nil", alert)
+	}
+
+	// When the window have passed, the old requests are forgotten
+	now = now.Add(2 * time.Minute)
+	if alert = m.record("/users", false, ""); alert != nil {
+		t.Errorf("got alert after window passed: %v, want nil", alert)
+	}
+}
+
+func TestErrorRateMonitorDefaults(t *testing.T) {
+	now := time.Date(2024, 5, 17, 13, 37, 0, 0, time.UTC)
+	m := &ErrorRateMonitor{Window: time.Nanosecond, now: func() time.Time { return now }}
+
+	for i := 0; i < 30; i++ {
+		if alert := m.record("/users", false, ""); alert != nil {
+			t.Fatalf("request %d: got alert: %v, want nil for successful requests", i, alert)
+		}
+	}
+
+	m = &ErrorRateMonitor{MinRequests: 10, now: func() time.Time { return now }}
+	var alert Fields
+	for i := 0; i < 10; i++ {
+		alert = m.record("/users", i%5 == 0, "")
+	}
+	if alert == nil {
+		t.Error("got no alert, want alert when 20% of the requests fail with the default threshold")
+	}
+}
@@ -2,8 +2,13 @@ package eal
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"runtime"
 	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 )
@@ -14,7 +19,10 @@ import (
 // the callstack, is to either walk the chain of errors
 type ErrorStackTrace struct {
 	err   error
-	stack string
+	stack *callStack
+
+	// reported is set when the error have been reported to the ErrorReporters
+	reported atomic.Bool
 }
 
 // LogCallStackDirectly control if an error message should be logged immediately with the callstack
@@ -23,14 +31,20 @@ type ErrorStackTrace struct {
 // immediately.
 var LogCallStackDirectly bool
 
+// ealPackage is the import path of this package, used to skip eal frames when the error origin is resolved.
+const ealPackage = "github.com/modfin/eal"
+
 var (
+	inhibitStacktraceMu       sync.RWMutex
 	inhibitStacktraceForError = make(map[interface{}]struct{})
 )
 
 // InhibitStacktraceForError will add the error types/instances to a map that is checked when Trace is called.
 // If Trace is called with an error type/instance that exist in the map, a callstack won't be generated and Trace
-// will return the error unmodified.
+// will return the error unmodified. InhibitStacktraceForError is safe for concurrent use.
 func InhibitStacktraceForError(err ...error) {
+	inhibitStacktraceMu.Lock()
+	defer inhibitStacktraceMu.Unlock()
 	for _, errItem := range err {
 		t := reflect.ValueOf(errItem)
 		if t.Kind() == reflect.Ptr && t.IsNil() {
@@ -49,17 +63,37 @@ func (st *ErrorStackTrace) Error() string {
 
 // SetLogFields is used by Entry.WithError to populate log fields.
 func (st *ErrorStackTrace) SetLogFields(logFields map[string]interface{}) {
-	logFields[errorStack] = st.stack
+	if stack := st.stack.String(); stack != "" {
+		if StackLogEncoding == StackPlain {
+			logFields[FieldErrorStack] = st.stack.value
+		} else {
+			setStackLogFields(stack, logFields)
+		}
+	}
+	if origin, value := st.stack.origin(); origin != "" {
+		logFields[FieldErrorOrigin] = value
+	}
 }
 
-// Unwrap return the wrapped error.
+// Unwrap return the wrapped error, errors.Is and errors.As use it to match the errors behind an ErrorStackTrace.
 func (st *ErrorStackTrace) Unwrap() error {
 	return st.err
 }
 
-// Stack return the stacktrace to where the ErrorStackTrace first were inserted in the error chain.
+// Stack return the stacktrace to where the ErrorStackTrace first were inserted in the error chain. The stacktrace is
+// empty if stack capture was disabled when Trace was called, see SetStackCapture. The stacktrace start with the
+// caller of Trace, and hold at most StackMaxDepth frames.
 func (st *ErrorStackTrace) Stack() string {
-	return st.stack
+	return st.stack.String()
+}
+
+// Origin return the location where Trace were called, as "<dir>/<file>:<line> <function>". The origin is logged in
+// the error_origin field, which make it possible to group and grep errors without parsing the stacktrace. The origin is
+// resolved from the program counters that were recorded by Trace, the first time it's needed. Like the stacktrace, the
+// origin is empty if stack capture was disabled when Trace was called.
+func (st *ErrorStackTrace) Origin() string {
+	origin, _ := st.stack.origin()
+	return origin
 }
 
 // TypeName return the name of the wrapped error struct.
@@ -72,6 +106,30 @@ func (st *ErrorStackTrace) TypeName() string {
 // the error will be returned as-is and won't be wrapped in a ErrorStackTrace type.
 // If the provided error already is, or contain a wrapped ErrorStackTrace error, the error is also returned as-is.
 func Trace(err error) error {
+	return trace(err)
+}
+
+// Tracef wrap the error with Trace, and then with a message, so that the error message is "<message>: <err>":
+//
+//	return eal.Tracef(err, "loading user %d", id)
+func Tracef(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), trace(err))
+}
+
+// Errorf create a new error with fmt.Errorf, and wrap it with Trace. If the new error wrap an error (with %w) that
+// already contain an ErrorStackTrace, no new stacktrace is captured.
+//
+//	return eal.Errorf("user %d not found", id)
+func Errorf(format string, args ...interface{}) error {
+	return trace(fmt.Errorf(format, args...))
+}
+
+// trace implements Trace, it must be called directly by the exported functions for the stacktrace to start at their
+// caller.
+func trace(err error) error {
 	if err == nil {
 		return nil
 	}
@@ -79,19 +137,14 @@ func Trace(err error) error {
 	// Edge case: if we receive an interface that have a non nil type, but a nil value (interfaces is a tuple with a type pointer and a value pointer)
 	t := reflect.ValueOf(err)
 	if t.Kind() == reflect.Ptr && t.IsNil() {
-		logrus.WithField(errorStack, string(debug.Stack())).Errorf("# NON NIL INTERFACE TYPE DETECTED (error value is nil, error type is %T) #", err)
+		logrus.WithField(FieldErrorStack, string(debug.Stack())).Errorf("# NON NIL INTERFACE TYPE DETECTED (error value is nil, error type is %T) #", err)
 
 		// Since this probably isn't an error per se, we return nil, instead of returning a non nil interface type.
 		return nil
 	}
 
-	if _, ok := inhibitStacktraceForError[err]; ok {
-		// Return the supplied error since we shouldn't generate a stacktrace for this error instance
-		return err
-	}
-
-	if _, ok := inhibitStacktraceForError[reflect.TypeOf(err)]; ok {
-		// Return the supplied error since we shouldn't generate a stacktrace for this error type
+	if stacktraceInhibited(err) {
+		// Return the supplied error since we shouldn't generate a stacktrace for this error instance or type
 		return err
 	}
 
@@ -101,15 +154,42 @@ func Trace(err error) error {
 		return err
 	}
 
-	trace := string(debug.Stack())
+	st = &ErrorStackTrace{
+		err:   err,
+		stack: captureStack(2),
+	}
 	if LogCallStackDirectly {
-		logrus.WithFields(logrus.Fields{errorMessage: err.Error(), errorStack: trace}).Error("ERROR")
+		fields := logrus.Fields{FieldErrorMessage: err.Error()}
+		st.SetLogFields(fields)
+		logrus.WithFields(fields).Error("ERROR")
 	}
 
-	return &ErrorStackTrace{
-		err:   err,
-		stack: trace,
+	return st
+}
+
+// stacktraceInhibited report if the error instance or type have been added with InhibitStacktraceForError.
+func stacktraceInhibited(err error) bool {
+	inhibitStacktraceMu.RLock()
+	defer inhibitStacktraceMu.RUnlock()
+	t := reflect.TypeOf(err)
+	if _, ok := inhibitStacktraceForError[t]; ok {
+		return true
+	}
+	if t.Comparable() {
+		_, ok := inhibitStacktraceForError[err]
+		return ok
+	}
+	return false
+}
+
+func formatOrigin(frame runtime.Frame) string {
+	file := frame.File
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+			file = file[j+1:]
+		}
 	}
+	return fmt.Sprintf("%s:%d %s", file, frame.Line, frame.Function)
 }
 
 // GetErrorStackTrace check if the provided error is, or have a wrapped ErrorStackTrace, and if there is one, it's returned.
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -121,11 +122,11 @@ func TestGetErrorStackTrace(t *testing.T) {
 
 			lf := make(map[string]interface{})
 			err.SetLogFields(lf)
-			st, ok := lf[errorStack]
+			st, ok := lf[FieldErrorStack]
 			if !ok {
-				t.Errorf("SetLogFields() didn't set the %s field", errorStack)
+				t.Errorf("SetLogFields() didn't set the %s field", FieldErrorStack)
 			} else if st == "" {
-				t.Errorf("got an empty %s field, want a callstack", errorStack)
+				t.Errorf("got an empty %s field, want a callstack", FieldErrorStack)
 			}
 
 			uwErr := err.Unwrap()
@@ -138,3 +139,120 @@ func TestGetErrorStackTrace(t *testing.T) {
 		})
 	}
 }
+
+type valueError struct {
+	code int
+}
+
+func (e valueError) Error() string {
+	return fmt.Sprintf("value error %d", e.code)
+}
+
+func TestErrorStackTraceIsAs(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+	}{
+		{name: "direct", err: Trace(valueError{code: 42})},
+		{name: "wrapped_before_trace", err: Trace(fmt.Errorf("wrapped: %w", valueError{code: 42}))},
+		{name: "wrapped_after_trace", err: fmt.Errorf("wrapped: %w", Trace(valueError{code: 42}))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, valueError{code: 42}) {
+				t.Error("errors.Is(err, valueError{42}) = false, want true")
+			}
+			if errors.Is(tt.err, valueError{code: 1}) {
+				t.Error("errors.Is(err, valueError{1}) = true, want false")
+			}
+
+			var ve valueError
+			if !errors.As(tt.err, &ve) {
+				t.Fatal("errors.As(err, *valueError) = false, want true")
+			}
+			if ve.code != 42 {
+				t.Errorf("got code: %d, want: 42", ve.code)
+			}
+		})
+	}
+}
+
+func traceHelper(err error) error {
+	return Trace(err)
+}
+
+func TestErrorOrigin(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "direct", err: Trace(errTest1), want: "errorstacktrace_test.go:"},
+		{name: "helper", err: traceHelper(errTest2), want: "errorstacktrace_test.go:"},
+		{name: "wrapped", err: fmt.Errorf("wrapped: %w", Trace(errTest1)), want: "TestErrorOrigin"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := map[string]interface{}{}
+			UnwrapError(tt.err, fields)
+			origin, _ := fields[FieldErrorOrigin].(string)
+			if !strings.Contains(origin, tt.want) {
+				t.Errorf("got %s: %q, want it to contain %q", FieldErrorOrigin, origin, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorOriginResolvedLazily(t *testing.T) {
+	st, _ := GetErrorStackTrace(Trace(errTest1))
+	if st.stack.originText != "" {
+		t.Fatalf("got origin %q resolved by Trace, want it resolved when needed", st.stack.originText)
+	}
+	if origin := st.Origin(); !strings.Contains(origin, "TestErrorOriginResolvedLazily") {
+		t.Errorf("got origin: %q, want the test function", origin)
+	}
+}
+
+func TestTracefAndErrorf(t *testing.T) {
+	errNotFound := errors.New("not found")
+	traced := Trace(errors.New("db down"))
+
+	for _, tt := range []struct {
+		name    string
+		err     error
+		msg     string
+		is      error
+		sameAs  error
+		wantNil bool
+	}{
+		{name: "Tracef", err: Tracef(errNotFound, "loading user %d", 42), msg: "loading user 42: not found", is: errNotFound},
+		{name: "Tracef traced", err: Tracef(traced, "loading user %d", 42), msg: "loading user 42: db down", sameAs: traced},
+		{name: "Tracef nil", err: Tracef(nil, "loading user %d", 42), wantNil: true},
+		{name: "Errorf", err: Errorf("user %d is disabled", 42), msg: "user 42 is disabled"},
+		{name: "Errorf wrap", err: Errorf("loading user: %w", errNotFound), msg: "loading user: not found", is: errNotFound},
+		{name: "Errorf wrap traced", err: Errorf("loading user: %w", traced), msg: "loading user: db down", sameAs: traced},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantNil {
+				if tt.err != nil {
+					t.Errorf("got %v, want nil", tt.err)
+				}
+				return
+			}
+			if tt.err.Error() != tt.msg {
+				t.Errorf("got message: %q, want: %q", tt.err.Error(), tt.msg)
+			}
+			if tt.is != nil && !errors.Is(tt.err, tt.is) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", tt.err, tt.is)
+			}
+			st, ok := GetErrorStackTrace(tt.err)
+			if !ok {
+				t.Fatal("got no ErrorStackTrace")
+			}
+			if tt.sameAs != nil && st != tt.sameAs {
+				t.Error("got a new ErrorStackTrace, want the existing one")
+			}
+			if tt.sameAs == nil && !strings.Contains(st.Origin(), "TestTracefAndErrorf") {
+				t.Errorf("got origin: %s, want the test function", st.Origin())
+			}
+		})
+	}
+}
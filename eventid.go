@@ -0,0 +1,29 @@
+package eal
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// LogEventID control if all log entries should have an event_id field, with an ID that is unique for each entry.
+// Entries that have a request_id field also get a parent_id field with the request ID. This make it possible to
+// reference an exact log entry, for example in an incident ticket, even when a request write more than one entry,
+// like an error entry and the access entry.
+var LogEventID bool
+
+// EventIDGenerator generate the IDs of the event_id field, see LogEventID.
+var EventIDGenerator IDGenerator = UUIDGenerator{}
+
+// addEventID add the event_id and parent_id fields to the entry, if LogEventID is enabled.
+func addEventID(entry *logrus.Entry) {
+	if !LogEventID {
+		return
+	}
+	if _, ok := entry.Data[FieldEventID]; !ok {
+		entry.Data[FieldEventID] = EventIDGenerator.NewID()
+	}
+	if id, ok := entry.Data[FieldRequestID]; ok {
+		if _, ok := entry.Data[FieldParentID]; !ok {
+			entry.Data[FieldParentID] = id
+		}
+	}
+}
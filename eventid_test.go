@@ -0,0 +1,40 @@
+package eal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestLogEventID(t *testing.T) {
+	entries := captureLog(t)
+	LogEventID = true
+	defer func() { LogEventID = false }()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		NewEntry().WithCtx(c).Warn("slow upstream")
+		return nil
+	})
+	NewEntry().Info("no request")
+
+	logged := entries()
+	if len(logged) != 3 {
+		t.Fatalf("got %d log entries, want 3", len(logged))
+	}
+	seen := map[interface{}]bool{}
+	for i, e := range logged {
+		if id, ok := e[FieldEventID].(string); !ok || id == "" || seen[id] {
+			t.Errorf("entry %d: got %s: %v, want a unique ID", i, FieldEventID, e[FieldEventID])
+		}
+		seen[e[FieldEventID]] = true
+	}
+	for i, want := range []interface{}{"req-1", "req-1", nil} {
+		if got := logged[i][FieldParentID]; got != want {
+			t.Errorf("entry %d: got %s: %v, want: %v", i, FieldParentID, got, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Decision describe a rule that would apply to a log entry, see ExplainEntry.
+type Decision struct {
+	// Stage is the part of the log pipeline that the rule belong to, for example "redact" or "level".
+	Stage string `json:"stage"`
+
+	// Field is the field that the rule apply to, if any.
+	Field string `json:"field,omitempty"`
+
+	// Outcome is what would happen to the entry or field.
+	Outcome string `json:"outcome"`
+
+	// Reason explain why the rule apply.
+	Reason string `json:"reason"`
+}
+
+// ExplainEntry report which global fields, redactors, level rules and filters would apply to a log entry with the
+// provided fields, and why, without writing anything. It makes it possible to predict the effect of configuration
+// changes, in tests or from an admin endpoint (see ExplainHandler). The fields are not modified.
+//
+// The level decision is made as for an access log entry, by AccessLevelFunc and the RouteOptions of the router_path
+// route. The status and http_status fields are used as the response status and the status of an echo.HTTPError, and
+// error_message as the error.
+func ExplainEntry(fields Fields) []Decision {
+	var decisions []Decision
+
+	globalFieldsMu.RLock()
+	for k, v := range globalFields {
+		if _, ok := fields[k]; ok {
+			decisions = append(decisions, Decision{Stage: "global_fields", Field: k, Outcome: "kept", Reason: "the entry already have the field"})
+		} else {
+			decisions = append(decisions, Decision{Stage: "global_fields", Field: k, Outcome: fmt.Sprintf("added: %v", v), Reason: "global field set by SetGlobalFields"})
+		}
+	}
+	globalFieldsMu.RUnlock()
+
+	decisions = append(decisions, explainRedaction(fields)...)
+
+	if StrictFieldNames {
+		for _, k := range UnknownFields(fields) {
+			decisions = append(decisions, Decision{Stage: "strict_field_names", Field: k, Outcome: "listed in " + FieldUnknownFields, Reason: "the field name isn't registered"})
+		}
+	}
+
+	decisions = append(decisions, explainLevel(fields)...)
+
+	if LogEventID {
+		decisions = append(decisions, Decision{Stage: "event_id", Field: FieldEventID, Outcome: "added", Reason: "LogEventID is enabled"})
+	}
+	if LogSequence {
+		decisions = append(decisions, Decision{Stage: "sequence", Field: FieldSeq, Outcome: "added", Reason: "LogSequence is enabled"})
+	}
+
+	for f := logrus.StandardLogger().Formatter; f != nil; {
+		switch t := f.(type) {
+		case *budgetFormatter:
+			decisions = append(decisions, Decision{Stage: "budget", Outcome: "may be dropped", Reason: fmt.Sprintf(
+				"a log budget of %d bytes per minute is set, debug entries are dropped at 80%% and info entries are sampled 1/%d at 100%%",
+				t.budget.BytesPerMinute, t.budget.InfoSampleRate)})
+			f = t.next
+		case *dedupFormatter:
+			if fp := Fingerprint(fields); fp != "" {
+				decisions = append(decisions, Decision{Stage: "dedup", Outcome: "may be dropped", Reason: fmt.Sprintf(
+					"error entries with fingerprint %s are logged once per %s", fp, t.interval)})
+			}
+			f = t.next
+		case *splitOutputFormatter:
+			f = t.next
+		default:
+			f = nil
+		}
+	}
+
+	return decisions
+}
+
+// ExplainHandler return an echo handler for admin endpoints, that decode a JSON object of log fields from the request
+// body, and respond with the decisions from ExplainEntry.
+func ExplainHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		fields := Fields{}
+		if err := c.Bind(&fields); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, ExplainEntry(fields))
+	}
+}
+
+func explainRedaction(fields Fields) []Decision {
+	redactorsMu.RLock()
+	defer redactorsMu.RUnlock()
+
+	var decisions []Decision
+	for k, v := range fields {
+		for i, r := range redactors {
+			if rv := r(k, v); !reflect.DeepEqual(rv, v) {
+				decisions = append(decisions, Decision{Stage: "redact", Field: k, Outcome: fmt.Sprintf("logged as: %v", rv), Reason: fmt.Sprintf("redactor %d (in registration order) changed the value", i+1)})
+				v = rv
+			}
+		}
+	}
+	return decisions
+}
+
+func explainLevel(fields Fields) []Decision {
+	status := toInt(fields[FieldStatus])
+	var err error
+	if msg, ok := fields[FieldErrorMessage]; ok {
+		err = errors.New(fmt.Sprint(msg))
+		if code := toInt(fields[FieldHTTPStatus]); code > 0 {
+			err = NewHTTPError(err, code)
+		}
+	}
+
+	level := AccessLevelFunc(status, err, fields)
+	var reason string
+	switch {
+	case err == nil:
+		reason = "the entry don't have an error"
+	case GetInnerHTTPError(err) != nil:
+		reason = fmt.Sprintf("the entry have an error with http_status %d, and status %d", GetInnerHTTPError(err).Code, status)
+	default:
+		reason = fmt.Sprintf("the entry have an error without http_status, and status %d", status)
+	}
+	decisions := []Decision{{Stage: "level", Outcome: level.String(), Reason: reason + ", as decided by AccessLevelFunc"}}
+
+	path, _ := fields[FieldRouterPath].(string)
+	ro, ok := routeOptionsFor(path)
+	if !ok || path == "" {
+		return decisions
+	}
+	if ro.Level != nil && level == InfoLevel {
+		decisions = append(decisions, Decision{Stage: "route", Field: FieldRouterPath, Outcome: "level " + ro.Level.String(), Reason: "the route have a level set by SetRouteOptions"})
+	}
+	failed := err != nil || status >= http.StatusBadRequest
+	switch {
+	case failed:
+	case ro.Skip:
+		decisions = append(decisions, Decision{Stage: "route", Field: FieldRouterPath, Outcome: "dropped", Reason: "the route is skipped by SetRouteOptions"})
+	case ro.SampleRate > 1:
+		decisions = append(decisions, Decision{Stage: "route", Field: FieldRouterPath, Outcome: fmt.Sprintf("sampled 1/%d", ro.SampleRate), Reason: "the route have a sample rate set by SetRouteOptions"})
+	}
+	return decisions
+}
+
+// toInt return the value as an int, for values of integer types and float64 (as decoded from JSON).
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
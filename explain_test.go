@@ -0,0 +1,72 @@
+package eal
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestExplainEntry(t *testing.T) {
+	RegisterRedactor(RedactKeys(regexp.MustCompile(`(?i)password`)))
+	SetGlobalFields(Fields{"service": "api"})
+	StrictFieldNames = true
+	t.Cleanup(func() {
+		redactorsMu.Lock()
+		redactors = nil
+		redactorsMu.Unlock()
+		SetGlobalFields(nil)
+		StrictFieldNames = false
+	})
+
+	for _, tt := range []struct {
+		name   string
+		fields Fields
+		want   map[string]string
+	}{
+		{
+			name:   "ok request",
+			fields: Fields{FieldStatus: http.StatusOK, "password": "hunter2"},
+			want: map[string]string{
+				"global_fields/service":       "added: api",
+				"redact/password":             "logged as: " + RedactedValue,
+				"strict_field_names/password": "listed in " + FieldUnknownFields,
+				"level/":                      "info",
+			},
+		},
+		{
+			name:   "client error",
+			fields: Fields{FieldStatus: float64(http.StatusNotFound), FieldErrorMessage: "no such user", FieldHTTPStatus: float64(http.StatusNotFound), "service": "web"},
+			want: map[string]string{
+				"global_fields/service":      "kept",
+				"strict_field_names/service": "listed in " + FieldUnknownFields,
+				"level/":                     "info",
+			},
+		},
+		{
+			name:   "server error",
+			fields: Fields{FieldStatus: http.StatusInternalServerError, FieldErrorMessage: "db down"},
+			want: map[string]string{
+				"global_fields/service": "added: api",
+				"level/":                "error",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			for _, d := range ExplainEntry(tt.fields) {
+				if d.Reason == "" {
+					t.Errorf("got decision without reason: %+v", d)
+				}
+				got[d.Stage+"/"+d.Field] = d.Outcome
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got decisions: %v, want: %v", got, tt.want)
+			}
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("got %s outcome: %q, want: %q", k, got[k], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package eal
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the log fields written by eal.
+const (
+	// Request fields, added by DefaultContextLogFunc and the logger middlewares
+	FieldRequestID  = "request_id"
+	FieldRemoteAddr = "remote_addr"
+	FieldHost       = "host"
+	FieldMethod     = "method"
+	FieldURI        = "uri"
+	FieldRouterPath = "router_path"
+	FieldLatencyMs  = "latency_ms"
+	FieldLatencyNs  = "latency_ns"
+	FieldStatus     = "status"
+	FieldPageViewID = "page_view_id"
+	FieldLocale     = "locale"
+	FieldBytesIn    = "bytes_in"
+	FieldBytesOut   = "bytes_out"
+
+	// FieldClientAborted is set to true for requests that the client aborted, by closing the connection.
+	FieldClientAborted = "client_aborted"
+
+	// Error fields, added by Entry.WithError and UnwrapError
+	FieldErrorMessage  = "error_message"
+	FieldErrorStack    = "error_stack"
+	FieldErrorStackID  = "error_stack_id"
+	FieldErrorOrigin   = "error_origin"
+	FieldErrorType     = "error_type"
+	FieldHTTPMessage   = "http_message"
+	FieldHTTPStatus    = "http_status"
+	FieldGroupErrors   = "group_errors"
+	FieldCancelCause   = "cancel_cause"
+	FieldSpawnStack    = "spawn_stack"
+	FieldErrorCode     = "error_code"
+	FieldErrorCodeName = "error_code_name"
+
+	// Optional middleware fields
+	FieldFailedStage           = "failed_stage"
+	FieldStagesMs              = "stages_ms"
+	FieldLatencyBucket         = "latency_bucket"
+	FieldLatencyHuman          = "latency_human"
+	FieldRequestCost           = "request_cost"
+	FieldLockWaitMs            = "lock_wait_ms"
+	FieldLockHoldMs            = "lock_hold_ms"
+	FieldAllocBytesDelta       = "alloc_bytes_delta"
+	FieldGCCyclesDelta         = "gc_cycles_delta"
+	FieldReadMs                = "read_ms"
+	FieldHandleMs              = "handle_ms"
+	FieldWriteMs               = "write_ms"
+	FieldUpstreamAddr          = "upstream_addr"
+	FieldUpstreamStatus        = "upstream_status"
+	FieldUpstreamLatencyMs     = "upstream_latency_ms"
+	FieldUpstreamRetries       = "upstream_retries"
+	FieldUpstreamError         = "upstream_error"
+	FieldChaosInjected         = "chaos_injected"
+	FieldChaosDelayMs          = "chaos_delay_ms"
+	FieldChaosStatus           = "chaos_status"
+	FieldSampleRate            = "sample_rate"
+	FieldCacheStatus           = "cache_status"
+	FieldCacheTTL              = "cache_ttl"
+	FieldRequestBody           = "request_body"
+	FieldRequestBodyTruncated  = "request_body_truncated"
+	FieldResponseBody          = "response_body"
+	FieldResponseBodyTruncated = "response_body_truncated"
+	FieldGeoCountry            = "geo_country"
+	FieldGeoCity               = "geo_city"
+	FieldGeoASN                = "geo_asn"
+	FieldGeoASOrg              = "geo_as_org"
+
+	// Tracing fields
+	FieldTraceID      = "trace_id"
+	FieldSpanID       = "span_id"
+	FieldParentSpanID = "parent_span_id"
+	FieldTraceFlags   = "trace_flags"
+	FieldTraceState   = "trace_state"
+
+	// Other fields
+	FieldSeq                  = "seq"
+	FieldEventID              = "event_id"
+	FieldParentID             = "parent_id"
+	FieldInstanceID           = "instance_id"
+	FieldServerErrorKind      = "server_error_kind"
+	FieldIntegrityBinary      = "integrity_binary_sha256"
+	FieldIntegrityFiles       = "integrity_files_sha256"
+	FieldIntegrityOK          = "integrity_ok"
+	FieldIntegrityMismatches  = "integrity_mismatches"
+	FieldFile                 = "file"
+	FieldSegment              = "segment"
+	FieldErrorLogger          = "error_logger"
+	FieldRepeatCount          = "repeat_count"
+	FieldHealthCheck          = "health_check"
+	FieldHealthCheckLatencyMs = "health_check_latency_ms"
+
+	// Build and runtime fields, see BuildInfoFields
+	FieldHostname      = "hostname"
+	FieldPID           = "pid"
+	FieldGoVersion     = "go_version"
+	FieldModule        = "module"
+	FieldModuleVersion = "module_version"
+	FieldVCSRevision   = "vcs_revision"
+	FieldVCSTime       = "vcs_time"
+	FieldVCSModified   = "vcs_modified"
+
+	// Fields of the command entries written by Command
+	FieldCommand    = "command"
+	FieldExitCode   = "exit_code"
+	FieldDurationMs = "duration_ms"
+	FieldStderr     = "stderr"
+
+	// Template error fields, added by the error log functions registered by InitTemplateErrorLogging
+	FieldTemplateName       = "template_name"
+	FieldTemplateLine       = "template_line"
+	FieldTemplateAction     = "template_action"
+	FieldTemplateMissingKey = "template_missing_key"
+
+	// Fields of the error_rate_exceeded entries written by ErrorRateMonitor
+	FieldRoute              = "route"
+	FieldErrorRate          = "error_rate"
+	FieldWindow             = "window"
+	FieldRequests           = "requests"
+	FieldErrors             = "errors"
+	FieldSampleFingerprints = "sample_fingerprints"
+
+	// FieldAlertFingerprint is the fingerprint of the error of an alert that couldn't be posted, see Alerter.
+	FieldAlertFingerprint = "alert_fingerprint"
+
+	// Fields of the field_conflict entries written when LogFieldConflicts is enabled
+	FieldConflictField    = "conflict_field"
+	FieldConflictOldValue = "conflict_old_value"
+	FieldConflictNewValue = "conflict_new_value"
+	FieldConflictStoredAs = "conflict_stored_as"
+
+	// Fields of the log_budget_exceeded entries written when a LogBudget is set
+	FieldBudgetBytesPerMinute = "budget_bytes_per_minute"
+	FieldDroppedEntries       = "dropped_entries"
+	FieldDroppedBytes         = "dropped_bytes"
+
+	// FieldStacksSuppressed is the number of stacktraces that weren't captured, see StackGovernor.
+	FieldStacksSuppressed = "stacks_suppressed"
+
+	// FieldUnknownFields is added to log entries by strict mode, see StrictFieldNames.
+	FieldUnknownFields = "unknown_fields"
+)
+
+// StrictFieldNames enable strict mode, where each log entry is checked for field names that isn't one of the eal
+// field names or registered with RegisterFieldNames. The names of any unknown fields are added to the log entry in
+// the unknown_fields field. It's intended to be enabled in tests and development environments, to catch misspelled
+// field names and magic strings.
+var StrictFieldNames bool
+
+var (
+	knownFieldsMu sync.RWMutex
+	knownFields   = map[string]struct{}{}
+)
+
+func init() {
+	RegisterFieldNames(
+		FieldRequestID, FieldRemoteAddr, FieldHost, FieldMethod, FieldURI, FieldRouterPath, FieldLatencyMs, FieldLatencyNs,
+		FieldStatus, FieldPageViewID, FieldLocale, FieldBytesIn, FieldBytesOut, FieldClientAborted, FieldErrorMessage,
+		FieldErrorStack, FieldErrorStackID, FieldErrorOrigin, FieldErrorType, FieldHTTPMessage, FieldHTTPStatus,
+		FieldGroupErrors, FieldCancelCause, FieldSpawnStack, FieldErrorCode, FieldErrorCodeName, FieldFailedStage,
+		FieldStagesMs, FieldLatencyBucket, FieldLatencyHuman, FieldRequestCost, FieldLockWaitMs, FieldLockHoldMs,
+		FieldAllocBytesDelta, FieldGCCyclesDelta, FieldReadMs, FieldHandleMs, FieldWriteMs, FieldUpstreamAddr,
+		FieldUpstreamStatus, FieldUpstreamLatencyMs, FieldUpstreamRetries, FieldUpstreamError, FieldChaosInjected,
+		FieldChaosDelayMs, FieldChaosStatus, FieldSampleRate, FieldCacheStatus, FieldCacheTTL, FieldRequestBody,
+		FieldRequestBodyTruncated, FieldResponseBody, FieldResponseBodyTruncated, FieldGeoCountry, FieldGeoCity,
+		FieldGeoASN, FieldGeoASOrg, FieldTraceID, FieldSpanID, FieldTraceFlags, FieldTraceState, FieldSeq, FieldEventID,
+		FieldParentID, FieldInstanceID, FieldServerErrorKind, FieldIntegrityBinary, FieldIntegrityFiles, FieldIntegrityOK,
+		FieldIntegrityMismatches, FieldErrorLogger, FieldRepeatCount, FieldHealthCheck, FieldHealthCheckLatencyMs,
+		FieldHostname, FieldPID, FieldGoVersion, FieldModule, FieldModuleVersion, FieldVCSRevision, FieldVCSTime,
+		FieldVCSModified, FieldCommand, FieldExitCode, FieldDurationMs, FieldStderr, FieldTemplateName, FieldTemplateLine,
+		FieldTemplateAction, FieldTemplateMissingKey, FieldRoute, FieldErrorRate, FieldWindow, FieldRequests, FieldErrors,
+		FieldSampleFingerprints, FieldAlertFingerprint, FieldConflictField, FieldConflictOldValue, FieldConflictNewValue,
+		FieldConflictStoredAs, FieldBudgetBytesPerMinute, FieldDroppedEntries, FieldDroppedBytes, FieldStacksSuppressed,
+		FieldUnknownFields, FieldParentSpanID, FieldFile, FieldSegment,
+	)
+}
+
+// RegisterFieldNames add application specific field names to the set of known field names, used by strict mode.
+func RegisterFieldNames(names ...string) {
+	knownFieldsMu.Lock()
+	defer knownFieldsMu.Unlock()
+	for _, name := range names {
+		knownFields[name] = struct{}{}
+	}
+}
+
+// UnknownFields return the sorted names of the fields that isn't known eal field names, or registered with
+// RegisterFieldNames. Field names written by FieldHeaderPrefix headers and by ConflictSuffix are reported as unknown.
+func UnknownFields(fields map[string]interface{}) []string {
+	knownFieldsMu.RLock()
+	defer knownFieldsMu.RUnlock()
+
+	var unknown []string
+	for k := range fields {
+		if _, ok := knownFields[k]; !ok && (!LegacyControlFields || !strings.HasPrefix(k, "_")) {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,23 @@
+package eal
+
+import (
+	"testing"
+)
+
+func TestStrictFieldNames(t *testing.T) {
+	entries := captureLog(t)
+	StrictFieldNames = true
+	defer func() { StrictFieldNames = false }()
+	RegisterFieldNames("user_id")
+
+	NewEntry().WithFields(Fields{FieldStatus: 200, "user_id": 1, "usr_id": 1}).Info("strict")
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	unknown, _ := logged[0][FieldUnknownFields].([]interface{})
+	if len(unknown) != 1 || unknown[0] != "usr_id" {
+		t.Errorf("got %s: %v, want: [usr_id]", FieldUnknownFields, logged[0][FieldUnknownFields])
+	}
+}
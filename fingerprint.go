@@ -0,0 +1,29 @@
+package eal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Fingerprint return a short, stable identifier for the error in the log fields, based on the error_type and
+// error_message fields. Digits in the error message are ignored, so that errors that only differ in IDs or counters
+// get the same fingerprint. An empty string is returned if the fields don't contain an error.
+func Fingerprint(fields map[string]interface{}) string {
+	msg, ok := fields[FieldErrorMessage]
+	if !ok {
+		return ""
+	}
+
+	normalized := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return '0'
+		}
+		return r
+	}, fmt.Sprint(msg))
+
+	sum := sha256.Sum256([]byte(fmt.Sprint(fields[FieldErrorType]) + "\x00" + normalized))
+	return hex.EncodeToString(sum[:8])
+}
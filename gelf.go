@@ -0,0 +1,235 @@
+package eal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// GELFChunkSizeWAN is the recommended maximum UDP datagram size when GELF messages are sent over the internet.
+	GELFChunkSizeWAN = 1420
+
+	// GELFChunkSizeLAN is the recommended maximum UDP datagram size when GELF messages are sent within a LAN.
+	GELFChunkSizeLAN = 8154
+
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+var gelfInvalidFieldChars = regexp.MustCompile(`[^\w.\-]`)
+
+type (
+	// GELFFormatter is a logrus.Formatter that encode log entries as GELF 1.1 messages, that can be sent to Graylog
+	// with a GELFWriter. The error_stack field is sent as the full_message, and all other fields are sent as
+	// additional fields.
+	GELFFormatter struct {
+		// Host is the name of the host that sent the message, os.Hostname (resolved once) is used if not set.
+		Host string
+	}
+
+	// GELFWriter send GELF messages to Graylog over UDP or TCP. Each call to Write must contain exactly one message,
+	// as produced by the GELFFormatter.
+	//
+	// UDP messages larger than ChunkSize are split into GELF chunks, so that large stacktraces can be sent. TCP
+	// messages are delimited with a null byte, and the connection is re-established if a write fail.
+	GELFWriter struct {
+		// ChunkSize is the maximum size of the UDP datagrams, GELFChunkSizeWAN is used if not set.
+		ChunkSize int
+
+		// Compress enable gzip compression of UDP messages. TCP messages are never compressed.
+		Compress bool
+
+		mu      sync.Mutex
+		network string
+		addr    string
+		conn    net.Conn
+	}
+)
+
+// InitGELF initialize the logrus logger to send GELF messages to a Graylog input, network is either "udp" or "tcp".
+//
+//	err := eal.InitGELF("udp", "graylog:12201")
+func InitGELF(network, addr string) error {
+	installHook()
+	w, err := NewGELFWriter(network, addr)
+	if err != nil {
+		return err
+	}
+	logrus.SetFormatter(&GELFFormatter{Host: localHostname()})
+	logrus.SetOutput(w)
+	return nil
+}
+
+// NewGELFWriter create a GELFWriter that send messages to addr, network is either "udp" or "tcp".
+func NewGELFWriter(network, addr string) (*GELFWriter, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("unsupported GELF network: %s", network)
+	}
+
+	w := &GELFWriter{network: network, addr: addr}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *GELFFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	host := f.Host
+	if host == "" {
+		host = localHostname()
+	}
+
+	msg := map[string]interface{}{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": entry.Message,
+		"timestamp":     float64(entry.Time.UnixNano()) / float64(time.Second),
+		"level":         syslogSeverity(entry.Level),
+	}
+	for k, v := range entry.Data {
+		if k == FieldErrorStack {
+			if s, ok := v.(string); ok {
+				msg["full_message"] = s
+				continue
+			}
+		}
+		if k == "id" || gelfInvalidFieldChars.MatchString(k) {
+			// _id is reserved by GELF, and Graylog drop fields with other characters than letters, digits, _, . and -
+			k = "field_" + gelfInvalidFieldChars.ReplaceAllString(k, "_")
+		}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		msg["_"+k] = v
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GELF message: %w", err)
+	}
+	return b, nil
+}
+
+// Write implements the io.Writer interface.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		if err := w.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	var err error
+	if w.isUDP() {
+		err = w.writeUDP(p)
+	} else {
+		err = w.writeTCP(p)
+		if err != nil {
+			// Reconnect and retry once, the connection may have been closed by Graylog or a load balancer
+			_ = w.conn.Close()
+			if err = w.connect(); err == nil {
+				err = w.writeTCP(p)
+			}
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close close the connection to Graylog.
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *GELFWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.addr, 5*time.Second)
+	if err != nil {
+		w.conn = nil
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *GELFWriter) isUDP() bool {
+	return w.network[:3] == "udp"
+}
+
+func (w *GELFWriter) writeTCP(p []byte) error {
+	msg := make([]byte, 0, len(p)+1)
+	msg = append(msg, bytes.TrimRight(p, "\n")...)
+	_, err := w.conn.Write(append(msg, 0))
+	return err
+}
+
+func (w *GELFWriter) writeUDP(p []byte) error {
+	if w.Compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(p); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		p = buf.Bytes()
+	}
+
+	size := w.ChunkSize
+	if size <= 0 {
+		size = GELFChunkSizeWAN
+	}
+	if len(p) <= size {
+		_, err := w.conn.Write(p)
+		return err
+	}
+
+	dataSize := size - gelfChunkHeaderSize
+	count := (len(p) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("GELF message too large: %d bytes require %d chunks (max %d)", len(p), count, gelfMaxChunks)
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, size)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(p) {
+			end = len(p)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, p[i*dataSize:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
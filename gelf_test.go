@@ -0,0 +1,152 @@
+package eal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func gelfEntry(stack string) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Time = time.Unix(1715947200, 500000000)
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "access"
+	entry.Data = logrus.Fields{FieldStatus: 500, FieldErrorStack: stack, "id": "x", "user id": "u1"}
+	return entry
+}
+
+func TestGELFFormatter(t *testing.T) {
+	b, err := (&GELFFormatter{Host: "api-1"}).Format(gelfEntry("goroutine 1 [running]"))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	var got map[string]interface{}
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to decode message: %v", err)
+	}
+
+	for k, want := range map[string]interface{}{
+		"version":        "1.1",
+		"host":           "api-1",
+		"short_message":  "access",
+		"full_message":   "goroutine 1 [running]",
+		"timestamp":      1715947200.5,
+		"level":          float64(3),
+		"_status":        float64(500),
+		"_field_id":      "x",
+		"_field_user_id": "u1",
+	} {
+		if got[k] != want {
+			t.Errorf("got %s: %v, want: %v", k, got[k], want)
+		}
+	}
+}
+
+func TestGELFWriterUDPChunking(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	w, err := NewGELFWriter("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("NewGELFWriter failed: %v", err)
+	}
+	defer w.Close()
+	w.ChunkSize = 512
+	w.Compress = true
+
+	stack := make([]byte, 10000)
+	_, _ = rand.Read(stack)
+	msg, _ := (&GELFFormatter{}).Format(gelfEntry(hex.EncodeToString(stack)))
+	if _, err = w.Write(msg); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Reassemble the chunks
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var chunks [][]byte
+	buf := make([]byte, 1024)
+	for count := -1; len(chunks) != count; {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("failed to read chunk: %v", err)
+		}
+		if n > 512 || buf[0] != 0x1e || buf[1] != 0x0f {
+			t.Fatalf("got invalid chunk, size %d, magic %x", n, buf[:2])
+		}
+		if count < 0 {
+			count = int(buf[11])
+			chunks = make([][]byte, 0, count)
+		}
+		if int(buf[10]) != len(chunks) {
+			t.Fatalf("got chunk %d, want chunk %d", buf[10], len(chunks))
+		}
+		chunks = append(chunks, append([]byte(nil), buf[gelfChunkHeaderSize:n]...))
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	if err != nil {
+		t.Fatalf("failed to decompress message: %v", err)
+	}
+	got, _ := io.ReadAll(zr)
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got reassembled message of %d bytes, want %d bytes", len(got), len(msg))
+	}
+}
+
+func TestGELFWriterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			received <- strings.TrimSuffix(msg, "\x00")
+		}
+	}()
+
+	w, err := NewGELFWriter("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("NewGELFWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	for _, msg := range []string{`{"short_message":"one"}`, `{"short_message":"two"}`} {
+		if _, err = w.Write([]byte(msg)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		select {
+		case got := <-received:
+			if got != msg {
+				t.Errorf("got message: %s, want: %s", got, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for message")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package eal
+
+import (
+	"net"
+	"strings"
+)
+
+type (
+	// GeoIPLocation is the location of an IP address, as resolved by a GeoIPResolver. Empty or zero fields are not
+	// logged.
+	GeoIPLocation struct {
+		CountryCode    string // ISO 3166-1 country code, for example "SE"
+		City           string // English city name
+		ASN            uint32 // Autonomous system number
+		ASOrganization string // Organization of the autonomous system
+	}
+
+	// GeoIPResolver resolve IP addresses to locations, see LoggerConfig.GeoIP. The ealmaxmind module provide a
+	// GeoIPResolver for the MaxMind GeoIP2 and GeoLite2 databases.
+	GeoIPResolver interface {
+		// LookupIP return the location of the IP address, and false if the location isn't known.
+		LookupIP(ip net.IP) (GeoIPLocation, bool)
+	}
+)
+
+// setGeoIPFields add the geo_country, geo_city, geo_asn and geo_as_org fields for the remote_addr field.
+func setGeoIPFields(resolver GeoIPResolver, fields Fields) {
+	addr, _ := fields[FieldRemoteAddr].(string)
+	ip := remoteIP(addr)
+	if ip == nil {
+		return
+	}
+	loc, ok := resolver.LookupIP(ip)
+	if !ok {
+		return
+	}
+	if loc.CountryCode != "" {
+		fields[FieldGeoCountry] = loc.CountryCode
+	}
+	if loc.City != "" {
+		fields[FieldGeoCity] = loc.City
+	}
+	if loc.ASN != 0 {
+		fields[FieldGeoASN] = loc.ASN
+	}
+	if loc.ASOrganization != "" {
+		fields[FieldGeoASOrg] = loc.ASOrganization
+	}
+}
+
+// remoteIP return the client IP of a remote_addr field, that is either "ip:port", or the value of an X-Forwarded-For
+// header where the first address is the client.
+func remoteIP(addr string) net.IP {
+	addr, _, _ = strings.Cut(addr, ",")
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
@@ -0,0 +1,40 @@
+package eal
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// staticGeoIP is a GeoIPResolver that resolve the addresses in the map.
+type staticGeoIP map[string]GeoIPLocation
+
+func (g staticGeoIP) LookupIP(ip net.IP) (GeoIPLocation, bool) {
+	loc, ok := g[ip.String()]
+	return loc, ok
+}
+
+func TestGeoIPFields(t *testing.T) {
+	entries := captureLog(t)
+	geo := staticGeoIP{"81.2.3.4": {CountryCode: "SE", ASN: 3301}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-For", "81.2.3.4, 10.0.0.1")
+	serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{GeoIP: geo}), req, func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	got := map[string]interface{}{FieldGeoCountry: logged[0][FieldGeoCountry], FieldGeoCity: logged[0][FieldGeoCity], FieldGeoASN: logged[0][FieldGeoASN]}
+	want := map[string]interface{}{FieldGeoCountry: "SE", FieldGeoCity: nil, FieldGeoASN: float64(3301)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got geo fields: %v, want: %v", got, want)
+	}
+}
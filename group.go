@@ -0,0 +1,114 @@
+package eal
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type (
+	// ErrGroup wrap an errgroup.Group, a collection of goroutines working on subtasks of a common task. Unlike
+	// errgroup.Group, all errors returned by the goroutines are kept, and each error get a stacktrace from the
+	// goroutine where it was returned.
+	ErrGroup struct {
+		group *errgroup.Group
+		mu    sync.Mutex
+		errs  []error
+	}
+
+	// GroupError is returned by ErrGroup.Wait when more than one goroutine in the group returned an error.
+	GroupError struct {
+		errs []error
+	}
+)
+
+// Group return a new ErrGroup and an associated context derived from ctx, see errgroup.WithContext. The derived
+// context is canceled the first time a goroutine in the group return an error, with the error as the cancellation
+// cause, or when Wait returns. A zero ErrGroup is valid, has no limit on the number of active goroutines and does not
+// cancel on error.
+func Group(ctx context.Context) (*ErrGroup, context.Context) {
+	g, ctx := errgroup.WithContext(ctx)
+	return &ErrGroup{group: g}, ctx
+}
+
+// Go calls the provided function in a new goroutine, see errgroup.Group.Go. If the function return an error, it's
+// wrapped by Trace when the function return, so the stacktrace show the goroutine of the group that the function ran
+// in, not where in the function the error was created. Errors that already are traced by the function keep their
+// stacktrace.
+func (g *ErrGroup) Go(f func() error) {
+	g.errGroup().Go(g.wrap(f))
+}
+
+// TryGo calls the provided function in a new goroutine only if the number of active goroutines in the group is
+// currently below the configured limit, and report whether the goroutine was started, see errgroup.Group.TryGo.
+func (g *ErrGroup) TryGo(f func() error) bool {
+	return g.errGroup().TryGo(g.wrap(f))
+}
+
+// SetLimit limits the number of active goroutines in the group to at most n, a negative value indicates no limit,
+// see errgroup.Group.SetLimit.
+func (g *ErrGroup) SetLimit(n int) {
+	g.errGroup().SetLimit(n)
+}
+
+// errGroup return the wrapped errgroup.Group, a new group is created for zero ErrGroups.
+func (g *ErrGroup) errGroup() *errgroup.Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.group == nil {
+		g.group = &errgroup.Group{}
+	}
+	return g.group
+}
+
+// wrap return a function that call f, and trace and keep the error returned by f.
+func (g *ErrGroup) wrap(f func() error) func() error {
+	return func() error {
+		err := Trace(f())
+		if err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+		}
+		return err
+	}
+}
+
+// Wait blocks until all goroutines in the group have returned. If a single goroutine returned an error, that error is
+// returned. If several goroutines returned errors, a GroupError that hold all errors is returned.
+func (g *ErrGroup) Wait() error {
+	_ = g.errGroup().Wait()
+
+	switch len(g.errs) {
+	case 0:
+		return nil
+	case 1:
+		return g.errs[0]
+	default:
+		return &GroupError{errs: g.errs}
+	}
+}
+
+// Error return the error messages of all errors in the group, separated by newlines.
+func (ge *GroupError) Error() string {
+	return errors.Join(ge.errs...).Error()
+}
+
+// Unwrap return all errors in the group.
+func (ge *GroupError) Unwrap() []error {
+	return ge.errs
+}
+
+// SetLogFields is used by Entry.WithError to populate log fields. The log fields for each error in the group, including
+// the stacktrace, is added as a separate item in the group_errors log field.
+func (ge *GroupError) SetLogFields(logFields map[string]interface{}) {
+	list := make([]map[string]interface{}, 0, len(ge.errs))
+	for _, err := range ge.errs {
+		f := make(map[string]interface{})
+		UnwrapError(err, f)
+		list = append(list, f)
+	}
+	logFields[FieldGroupErrors] = list
+}
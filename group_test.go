@@ -0,0 +1,121 @@
+package eal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestGroup(t *testing.T) {
+	errA := errors.New("error a")
+	errB := errors.New("error b")
+
+	g, ctx := Group(context.Background())
+	g.Go(func() error { return errA })
+	g.Go(func() error { return errB })
+	g.Go(func() error { return nil })
+	err := g.Wait()
+
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("got error: %v, want both errA and errB", err)
+	}
+	if cause := context.Cause(ctx); !errors.Is(cause, errA) && !errors.Is(cause, errB) {
+		t.Errorf("got context cause: %v, want errA or errB", cause)
+	}
+
+	fields := map[string]interface{}{}
+	UnwrapError(err, fields)
+	list, ok := fields[FieldGroupErrors].([]map[string]interface{})
+	if !ok || len(list) != 2 {
+		t.Fatalf("got %s: %v, want two items", FieldGroupErrors, fields[FieldGroupErrors])
+	}
+	for i, f := range list {
+		if f[FieldErrorStack] == nil || f[FieldErrorStack] == "" {
+			t.Errorf("item %d: got no %s field", i, FieldErrorStack)
+		}
+	}
+}
+
+func TestGroupSingleError(t *testing.T) {
+	g, _ := Group(context.Background())
+	g.Go(func() error { return errTest1 })
+	g.Go(func() error { return nil })
+	err := g.Wait()
+
+	if _, ok := GetErrorStackTrace(err); !ok {
+		t.Errorf("got error: %T, want a traced error", err)
+	}
+	if !errors.Is(err, errTest1) {
+		t.Errorf("got error: %v, want errTest1", err)
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	var g ErrGroup
+	g.SetLimit(1)
+	release := make(chan struct{})
+	g.Go(func() error {
+		<-release
+		return errTest1
+	})
+	if g.TryGo(func() error { return nil }) {
+		t.Error("got TryGo: true, want false when the limit is reached")
+	}
+	close(release)
+	if err := g.Wait(); !errors.Is(err, errTest1) {
+		t.Errorf("got error: %v, want errTest1", err)
+	}
+	if !g.TryGo(func() error { return nil }) {
+		t.Error("got TryGo: false, want true after Wait")
+	}
+}
+
+// entryChanHook send the fields of each log entry to a channel.
+type entryChanHook chan logrus.Fields
+
+func (h entryChanHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h entryChanHook) Fire(entry *logrus.Entry) error {
+	h <- entry.Data
+	return nil
+}
+
+func spawnTask(ctx context.Context) {
+	Go(ctx, func(ctx context.Context) error {
+		return errTest1
+	})
+}
+
+func TestGo(t *testing.T) {
+	logged := make(entryChanHook, 1)
+	hooks := logrus.LevelHooks{}
+	for l, hs := range logrus.StandardLogger().Hooks {
+		hooks[l] = append([]logrus.Hook(nil), hs...)
+	}
+	logrus.AddHook(logged)
+	t.Cleanup(func() { logrus.StandardLogger().ReplaceHooks(hooks) })
+
+	spawnTask(WithFields(context.Background(), Fields{"tenant": "t1"}))
+
+	var fields logrus.Fields
+	select {
+	case fields = <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for log entry")
+	}
+	if fields[FieldErrorMessage] != errTest1.Error() || fields["tenant"] != "t1" {
+		t.Errorf("got unexpected log entry: %v", fields)
+	}
+	if stack, _ := fields[FieldSpawnStack].(string); !strings.Contains(stack, "eal.spawnTask(") {
+		t.Errorf("got %s: %q, want it to contain the spawning function", FieldSpawnStack, stack)
+	}
+	if _, ok := fields[FieldErrorStack]; !ok {
+		t.Errorf("got no %s", FieldErrorStack)
+	}
+}
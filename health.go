@@ -0,0 +1,119 @@
+package eal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultHealthCheckTimeout is the timeout of health checks that don't have a Timeout set.
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+type (
+	// Check is a health check of a dependency, for example a database or a downstream service, see HealthHandler.
+	Check struct {
+		Name string
+		Func func(ctx context.Context) error
+
+		// Timeout is the maximum time the check may run, DefaultHealthCheckTimeout is used if not set.
+		Timeout time.Duration
+	}
+
+	// HealthStatus is the response document of the HealthHandler.
+	HealthStatus struct {
+		Status string        `json:"status"`
+		Checks []CheckStatus `json:"checks"`
+	}
+
+	// CheckStatus is the result of a single health check.
+	CheckStatus struct {
+		Name      string `json:"name"`
+		Status    string `json:"status"`
+		LatencyMs int64  `json:"latency_ms"`
+	}
+)
+
+// Health check statuses.
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// HealthHandler return an echo handler that run the checks concurrently, each with its own timeout, and respond with a
+// HealthStatus document. The response status is 200 if all checks succeed, and 503 otherwise:
+//
+//	e.GET("/health", eal.HealthHandler(
+//	  eal.Check{Name: "db", Func: db.PingContext},
+//	  eal.Check{Name: "cache", Func: cache.Ping, Timeout: time.Second},
+//	))
+//
+// Failed checks are logged with the error fields produced by UnwrapError, and the health_check and
+// health_check_latency_ms fields. The errors are not included in the response, since health endpoints often are
+// reachable without authentication.
+func HealthHandler(checks ...Check) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		status := HealthStatus{Status: HealthOK, Checks: make([]CheckStatus, len(checks))}
+
+		var wg sync.WaitGroup
+		for i, check := range checks {
+			wg.Add(1)
+			go func(i int, check Check) {
+				defer wg.Done()
+				status.Checks[i] = runCheck(ctx, check)
+			}(i, check)
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		for _, cs := range status.Checks {
+			if cs.Status != HealthOK {
+				status.Status = HealthFail
+				code = http.StatusServiceUnavailable
+			}
+		}
+		return c.JSON(code, status)
+	}
+}
+
+func runCheck(ctx context.Context, check Check) CheckStatus {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("health check panicked: %v", r)
+			}
+		}()
+		done <- check.Func(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// The check don't respect the context, so it's left running in the background
+		err = fmt.Errorf("health check timed out after %s: %w", timeout, ctx.Err())
+	}
+
+	cs := CheckStatus{Name: check.Name, Status: HealthOK, LatencyMs: int64(time.Since(start) / time.Millisecond)}
+	if err != nil {
+		cs.Status = HealthFail
+		NewEntry().
+			WithFields(Fields{FieldHealthCheck: check.Name, FieldHealthCheckLatencyMs: cs.LatencyMs}).
+			withError(err).
+			Error("health check failed")
+	}
+	return cs
+}
@@ -0,0 +1,72 @@
+package eal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestHealthHandler(t *testing.T) {
+	errDown := errors.New("connection refused")
+	ok := Check{Name: "db", Func: func(ctx context.Context) error { return nil }}
+	down := Check{Name: "cache", Func: func(ctx context.Context) error { return Trace(errDown) }}
+	slow := Check{Name: "search", Timeout: 10 * time.Millisecond, Func: func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}}
+
+	for _, tt := range []struct {
+		name       string
+		checks     []Check
+		wantCode   int
+		wantStatus map[string]string
+	}{
+		{name: "healthy", checks: []Check{ok}, wantCode: http.StatusOK, wantStatus: map[string]string{"db": HealthOK}},
+		{name: "failing", checks: []Check{ok, down}, wantCode: http.StatusServiceUnavailable, wantStatus: map[string]string{"db": HealthOK, "cache": HealthFail}},
+		{name: "timeout", checks: []Check{slow}, wantCode: http.StatusServiceUnavailable, wantStatus: map[string]string{"search": HealthFail}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLog(t)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/health", nil), rec)
+			if err := HealthHandler(tt.checks...)(c); err != nil {
+				t.Fatalf("HealthHandler failed: %v", err)
+			}
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("got status: %d, want: %d", rec.Code, tt.wantCode)
+			}
+			var status HealthStatus
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			failed := 0
+			for _, cs := range status.Checks {
+				if cs.Status != tt.wantStatus[cs.Name] {
+					t.Errorf("got %s status: %s, want: %s", cs.Name, cs.Status, tt.wantStatus[cs.Name])
+				}
+				if cs.Status == HealthFail {
+					failed++
+				}
+			}
+
+			logged := entries()
+			if len(logged) != failed {
+				t.Fatalf("got %d log entries, want %d", len(logged), failed)
+			}
+			for _, l := range logged {
+				if l[FieldHealthCheck] == nil || l[FieldErrorMessage] == nil {
+					t.Errorf("got log entry: %v, want health_check and error fields", l)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package eal
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// HTTPContextLogFunc can be implemented to be able to add log fields from a net/http request.
+	HTTPContextLogFunc func(r *http.Request, fields Fields)
+
+	errorContextKey struct{}
+
+	requestError struct {
+		mu  sync.Mutex
+		err error
+	}
+
+	// httpLoggerMiddleware run a net/http handler in the pipeline of the echo logger middleware.
+	httpLoggerMiddleware struct {
+		echo    *echo.Echo
+		handler echo.HandlerFunc
+	}
+
+	// httpResponse is the http.ResponseWriter that is passed to the net/http handler. It implements FlushError, so
+	// that http.NewResponseController report that flushing isn't supported, instead of calling echo.Response.Flush,
+	// which panics.
+	httpResponse struct {
+		*echo.Response
+	}
+)
+
+// CreateHTTPLoggerMiddleware return a net/http middleware that handle access and error logging of the call, in the
+// same way as CreateLoggerMiddleware does for echo, configured by DefaultLoggerConfig. It can be used with plain
+// net/http servers, and routers that use standard http.Handler middlewares, like chi and gorilla/mux:
+//
+//	http.ListenAndServe(":8080", eal.CreateHTTPLoggerMiddleware(mux))
+//
+// Since net/http handlers don't return errors, handlers can use ReportError to have an error logged with the access
+// log entry. If no response have been written when the handler return, the status code and message of the earliest
+// echo.HTTPError in the error chain (or 500 Internal Server Error) is sent to the caller.
+//
+// The request context hold the log fields, so WithFields, AddCost and Lock can be used by the handlers. The
+// http.ResponseWriter passed to the handler always implement http.Flusher and http.Hijacker. If the underlying writer
+// don't support them, Flush do nothing and Hijack return an error, use http.NewResponseController to detect it:
+//
+//	if err := http.NewResponseController(w).Flush(); errors.Is(err, http.ErrNotSupported) {
+//	  // Buffer the response instead of streaming it
+//	}
+func CreateHTTPLoggerMiddleware(next http.Handler, logFunctions ...HTTPContextLogFunc) http.Handler {
+	config := DefaultLoggerConfig
+	funcs := []ContextLogFunc{httpRequestLogFunc}
+	if len(config.ContextLogFuncs) > 0 {
+		funcs = append([]ContextLogFunc{}, config.ContextLogFuncs...)
+	}
+	for _, f := range logFunctions {
+		f := f
+		funcs = append(funcs, func(c echo.Context, fields Fields) { f(c.Request(), fields) })
+	}
+	config.ContextLogFuncs = funcs
+	return CreateHTTPLoggerMiddlewareWithConfig(next, config)
+}
+
+// CreateHTTPLoggerMiddlewareWithConfig return a net/http middleware in the same way as CreateHTTPLoggerMiddleware, but
+// configured by the provided LoggerConfig. The ContextLogFuncs are called with an echo.Context that wrap the net/http
+// request and response, echo.Context.Path always return "" since the request isn't routed by echo.
+func CreateHTTPLoggerMiddlewareWithConfig(next http.Handler, config LoggerConfig) http.Handler {
+	return NewHTTPLoggerMiddleware(Deps{}, config, next)
+}
+
+// NewHTTPLoggerMiddleware return a net/http middleware in the same way as CreateHTTPLoggerMiddlewareWithConfig, but
+// with the request ID generator, clock and emitter provided by deps, see NewLoggerMiddleware.
+func NewHTTPLoggerMiddleware(deps Deps, config LoggerConfig, next http.Handler) http.Handler {
+	if len(config.ContextLogFuncs) == 0 {
+		config.ContextLogFuncs = []ContextLogFunc{httpRequestLogFunc}
+	}
+	e := echo.New()
+	e.HTTPErrorHandler = httpErrorHandler
+	return &httpLoggerMiddleware{
+		echo: e,
+		handler: NewLoggerMiddleware(deps, config)(func(c echo.Context) error {
+			reqErr := &requestError{}
+			ctx := context.WithValue(c.Request().Context(), errorContextKey{}, reqErr)
+			next.ServeHTTP(httpResponse{c.Response()}, c.Request().WithContext(ctx))
+			reqErr.mu.Lock()
+			defer reqErr.mu.Unlock()
+			return reqErr.err
+		}),
+	}
+}
+
+func (m *httpLoggerMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := m.echo.AcquireContext()
+	c.Reset(r, w)
+	_ = m.handler(c)
+	m.echo.ReleaseContext(c)
+}
+
+// Flush implements the http.Flusher interface, it do nothing if the underlying writer can't flush.
+func (r httpResponse) Flush() {
+	_ = r.FlushError()
+}
+
+// FlushError flush the underlying writer, or return an error that wrap http.ErrNotSupported if it can't flush.
+func (r httpResponse) FlushError() error {
+	return http.NewResponseController(r.Writer).Flush()
+}
+
+// httpRequestLogFunc is the default ContextLogFunc of the net/http middleware, it log the same fields as
+// DefaultContextLogFunc, except router_path.
+func httpRequestLogFunc(c echo.Context, fields Fields) {
+	setRequestFields(c.Request(), c.Response().Header(), fields, contextRequestHeaders(c), contextIDGenerator(c))
+}
+
+// httpErrorHandler send the message of the error returned by the net/http middleware as plain text, unless the handler
+// already have written a response.
+func httpErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	he, ok := err.(*echo.HTTPError)
+	if !ok {
+		he = echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	if msg, ok := he.Message.(string); ok {
+		http.Error(c.Response(), msg, he.Code)
+		return
+	}
+	_ = c.JSON(he.Code, he.Message)
+}
+
+// ReportError set the error that should be logged by the CreateHTTPLoggerMiddleware for the request that ctx belong
+// to. If ReportError is called more than once for a request, the last error is logged.
+func ReportError(ctx context.Context, err error) {
+	if ctx == nil {
+		return
+	}
+	re, ok := ctx.Value(errorContextKey{}).(*requestError)
+	if !ok {
+		return
+	}
+	re.mu.Lock()
+	re.err = err
+	re.mu.Unlock()
+}
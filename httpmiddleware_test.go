@@ -0,0 +1,133 @@
+package eal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateHTTPLoggerMiddleware(t *testing.T) {
+	entries := captureLog(t)
+	errNotFound := NewHTTPError(errors.New("no such user"), http.StatusNotFound, "user not found")
+
+	h := CreateHTTPLoggerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			ReportError(r.Context(), errNotFound)
+			return
+		}
+		WithFields(r.Context(), Fields{"tenant": "acme"})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if rec.Header().Get("X-Request-Id") == "" {
+		t.Error("got no X-Request-Id response header")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status: %d, want: %d", rec.Code, http.StatusNotFound)
+	}
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0]["status"] != float64(http.StatusAccepted) || logged[0]["tenant"] != "acme" || logged[0]["level"] != "info" {
+		t.Errorf("got first entry: %v, want status 202, tenant acme and info level", logged[0])
+	}
+	if logged[1]["status"] != float64(http.StatusNotFound) || logged[1][FieldErrorMessage] == nil || logged[1]["level"] != "info" {
+		t.Errorf("got second entry: %v, want status 404 with error fields and info level", logged[1])
+	}
+}
+
+func TestCreateHTTPLoggerMiddlewareSize(t *testing.T) {
+	entries := captureLog(t)
+	h := CreateHTTPLoggerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", strings.NewReader("abc")))
+
+	logged := entries()
+	if len(logged) != 1 || logged[0][FieldBytesIn] != float64(3) || logged[0][FieldBytesOut] != float64(5) {
+		t.Errorf("got entries: %v, want bytes_in 3 and bytes_out 5", logged)
+	}
+}
+
+func TestCreateHTTPLoggerMiddlewareInterfaces(t *testing.T) {
+	captureLog(t)
+	srv := httptest.NewServer(CreateHTTPLoggerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flush" {
+			_, _ = w.Write([]byte("hello"))
+			w.(http.Flusher).Flush()
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("got hijack error: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+		_ = buf.Flush()
+	})))
+	defer srv.Close()
+
+	for path, want := range map[string]int{"/flush": http.StatusOK, "/hijack": http.StatusNoContent} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Errorf("got status for %s: %d, want: %d", path, res.StatusCode, want)
+		}
+	}
+}
+
+// plainResponseWriter hide the optional interfaces of the wrapped http.ResponseWriter.
+type plainResponseWriter struct {
+	http.ResponseWriter
+}
+
+func TestCreateHTTPLoggerMiddlewareUnsupportedFlush(t *testing.T) {
+	captureLog(t)
+	h := CreateHTTPLoggerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+		if err := http.NewResponseController(w).Flush(); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("got flush error: %v, want: %v", err, http.ErrNotSupported)
+		}
+		w.(http.Flusher).Flush()
+		if _, _, err := w.(http.Hijacker).Hijack(); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("got hijack error: %v, want: %v", err, http.ErrNotSupported)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(plainResponseWriter{rec}, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Body.String() != "hello" {
+		t.Errorf("got body: %q", rec.Body.String())
+	}
+}
+
+func TestNewHTTPLoggerMiddlewareConfig(t *testing.T) {
+	entries := captureLog(t)
+	h := NewHTTPLoggerMiddleware(Deps{IDGenerator: fakeIDGenerator("abc")}, LoggerConfig{
+		RequestIDHeader: "X-Correlation-Id",
+		FieldNames:      map[string]string{FieldStatus: "http.status_code"},
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("X-Correlation-Id"); got != "abc" {
+		t.Errorf("got X-Correlation-Id: %q, want: %q", got, "abc")
+	}
+	logged := entries()
+	if len(logged) != 1 || logged[0][FieldRequestID] != "abc" || logged[0]["http.status_code"] != float64(http.StatusOK) {
+		t.Errorf("got entries: %v, want request_id abc and http.status_code 200", logged)
+	}
+}
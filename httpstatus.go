@@ -0,0 +1,58 @@
+package eal
+
+import "net/http"
+
+// BadRequest wrap the error with Trace, and then in an echo.HTTPError with status 400 and the optional message, see
+// NewHTTPError. The error can be nil, to create an echo.HTTPError without an internal error:
+//
+//	if err := c.Bind(&req); err != nil {
+//	  return eal.BadRequest(err, "invalid request body")
+//	}
+func BadRequest(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusBadRequest, msg...)
+}
+
+// Unauthorized wrap the error with Trace, and then in an echo.HTTPError with status 401, see BadRequest.
+func Unauthorized(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusUnauthorized, msg...)
+}
+
+// Forbidden wrap the error with Trace, and then in an echo.HTTPError with status 403, see BadRequest.
+func Forbidden(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusForbidden, msg...)
+}
+
+// NotFound wrap the error with Trace, and then in an echo.HTTPError with status 404, see BadRequest.
+func NotFound(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusNotFound, msg...)
+}
+
+// Conflict wrap the error with Trace, and then in an echo.HTTPError with status 409, see BadRequest.
+func Conflict(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusConflict, msg...)
+}
+
+// UnprocessableEntity wrap the error with Trace, and then in an echo.HTTPError with status 422, see BadRequest.
+func UnprocessableEntity(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusUnprocessableEntity, msg...)
+}
+
+// TooManyRequests wrap the error with Trace, and then in an echo.HTTPError with status 429, see BadRequest.
+func TooManyRequests(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusTooManyRequests, msg...)
+}
+
+// Internal wrap the error with Trace, and then in an echo.HTTPError with status 500, see BadRequest.
+func Internal(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusInternalServerError, msg...)
+}
+
+// BadGateway wrap the error with Trace, and then in an echo.HTTPError with status 502, see BadRequest.
+func BadGateway(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusBadGateway, msg...)
+}
+
+// ServiceUnavailable wrap the error with Trace, and then in an echo.HTTPError with status 503, see BadRequest.
+func ServiceUnavailable(err error, msg ...interface{}) error {
+	return NewHTTPError(trace(err), http.StatusServiceUnavailable, msg...)
+}
@@ -0,0 +1,53 @@
+package eal
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStatusConstructors(t *testing.T) {
+	errDB := errors.New("db down")
+	for _, tt := range []struct {
+		name     string
+		f        func(error, ...interface{}) error
+		wantCode int
+	}{
+		{name: "BadRequest", f: BadRequest, wantCode: http.StatusBadRequest},
+		{name: "Unauthorized", f: Unauthorized, wantCode: http.StatusUnauthorized},
+		{name: "Forbidden", f: Forbidden, wantCode: http.StatusForbidden},
+		{name: "NotFound", f: NotFound, wantCode: http.StatusNotFound},
+		{name: "Conflict", f: Conflict, wantCode: http.StatusConflict},
+		{name: "UnprocessableEntity", f: UnprocessableEntity, wantCode: http.StatusUnprocessableEntity},
+		{name: "TooManyRequests", f: TooManyRequests, wantCode: http.StatusTooManyRequests},
+		{name: "Internal", f: Internal, wantCode: http.StatusInternalServerError},
+		{name: "BadGateway", f: BadGateway, wantCode: http.StatusBadGateway},
+		{name: "ServiceUnavailable", f: ServiceUnavailable, wantCode: http.StatusServiceUnavailable},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.f(errDB, "try again")
+			he := GetInnerHTTPError(err)
+			if he == nil || he.Code != tt.wantCode || he.Message != "try again" {
+				t.Fatalf("got http error: %v, want code %d with message", he, tt.wantCode)
+			}
+			st, ok := GetErrorStackTrace(err)
+			if !ok {
+				t.Fatal("got no stacktrace")
+			}
+			if !strings.Contains(st.Origin(), "httpstatus_test.go") {
+				t.Errorf("got origin: %q, want the caller", st.Origin())
+			}
+			if first := strings.SplitN(st.Stack(), "\n", 3)[1]; !strings.Contains(first, "TestStatusConstructors") {
+				t.Errorf("got first frame: %q, want the caller", first)
+			}
+			if !errors.Is(err, errDB) {
+				t.Error("errors.Is don't find the wrapped error")
+			}
+		})
+	}
+
+	if he := GetInnerHTTPError(NotFound(nil)); he == nil || he.Internal != nil || he.Message != http.StatusText(http.StatusNotFound) {
+		t.Errorf("got http error: %v, want 404 without internal error", he)
+	}
+}
@@ -0,0 +1,130 @@
+package eal
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultLanguage is the language of the localized error messages that is used when none of the languages requested
+// by the caller have messages registered, see RegisterMessages.
+var DefaultLanguage = "en"
+
+var (
+	messagesMu sync.RWMutex
+	messages   = map[string]map[string]*messageTemplate{}
+)
+
+// RegisterMessages register localized message templates for a language, keyed by the name of the ErrorCode. Values
+// in braces are replaced by the params of ErrorCode.ErrWithParams, for example "User {user} is disabled". Missing
+// params are rendered as "-".
+//
+// When a request fail with an ErrorCode, the logger middleware pick the language from the locale log field (which can
+// be set by a ContextLogFunc), or from the Accept-Language header, and set the localized message in the message field
+// of the ErrorPayload:
+//
+//	eal.RegisterMessages("en", map[string]string{"user.disabled": "The user {user} is disabled"})
+//	eal.RegisterMessages("sv", map[string]string{"user.disabled": "Användaren {user} är avstängd"})
+func RegisterMessages(lang string, msgs map[string]string) {
+	lang = strings.ToLower(lang)
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	if messages[lang] == nil {
+		messages[lang] = map[string]*messageTemplate{}
+	}
+	for name, tmpl := range msgs {
+		messages[lang][name] = parseMessageTemplate(tmpl)
+	}
+}
+
+// Localize return the localized message of the error code in the language, with the params inserted. If the language
+// is a regional variant, like "sv-SE", the messages of the base language are used if the variant don't have any.
+func Localize(code *ErrorCode, lang string, params Fields) (string, bool) {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	lang = strings.ToLower(lang)
+	for _, l := range []string{lang, baseLanguage(lang)} {
+		if mt, ok := messages[l][code.Name]; ok && mt != nil {
+			return mt.render(params), true
+		}
+	}
+	return "", false
+}
+
+// ErrWithParams return an error in the same way as Err, with params that are inserted in the localized message, see
+// RegisterMessages. The params aren't logged.
+func (ec *ErrorCode) ErrWithParams(cause error, params Fields) error {
+	err := ec.Err(cause)
+	var ce *CodedError
+	if errors.As(err, &ce) {
+		ce.params = params
+	}
+	return err
+}
+
+// localizeErrorResponse return the echo.HTTPError with the localized message set in the ErrorPayload, if the error is
+// an ErrorCode with a localized message.
+func localizeErrorResponse(c echo.Context, fields Fields, he *echo.HTTPError, err error) *echo.HTTPError {
+	payload, ok := he.Message.(*ErrorPayload)
+	var ce *CodedError
+	if !ok || !errors.As(err, &ce) {
+		return he
+	}
+
+	locale, _ := fields[FieldLocale].(string)
+	for _, lang := range requestLanguages(locale, c.Request().Header.Get("Accept-Language")) {
+		if msg, ok := Localize(ce.code, lang, ce.params); ok {
+			p := *payload
+			p.Message = msg
+			return &echo.HTTPError{Code: he.Code, Message: &p, Internal: he.Internal}
+		}
+	}
+	return he
+}
+
+// requestLanguages return the languages to try, in order: the locale, the languages of the Accept-Language header by
+// quality, and DefaultLanguage.
+func requestLanguages(locale, acceptLanguage string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			langs = append(langs, weighted{lang: tag, q: q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	result := make([]string, 0, len(langs)+2)
+	if locale != "" {
+		result = append(result, locale)
+	}
+	for _, l := range langs {
+		result = append(result, l.lang)
+	}
+	return append(result, DefaultLanguage)
+}
+
+func baseLanguage(lang string) string {
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		return lang[:i]
+	}
+	return lang
+}
@@ -0,0 +1,62 @@
+package eal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestLocalizedErrorResponse(t *testing.T) {
+	errUserDisabled := RegisterErrorCode(ErrorCode{Name: "user.disabled", ID: 42, Status: http.StatusForbidden, MessageKey: "user.error.disabled"})
+	RegisterMessages("en", map[string]string{"user.disabled": "The user {user} is disabled"})
+	RegisterMessages("sv", map[string]string{"user.disabled": "Användaren {user} är avstängd"})
+	t.Cleanup(func() {
+		errorCodesMu.Lock()
+		errorCodes = map[string]*ErrorCode{}
+		errorCodeIDs = map[int]*ErrorCode{}
+		errorCodesMu.Unlock()
+		messagesMu.Lock()
+		messages = map[string]map[string]*messageTemplate{}
+		messagesMu.Unlock()
+	})
+
+	localeFunc := func(c echo.Context, fields Fields) {
+		if l := c.QueryParam("locale"); l != "" {
+			fields[FieldLocale] = l
+		}
+	}
+	for _, tt := range []struct {
+		name           string
+		acceptLanguage string
+		url            string
+		wantMessage    string
+	}{
+		{name: "regional variant", acceptLanguage: "sv-SE,sv;q=0.9,en;q=0.8", url: "/", wantMessage: "Användaren bob är avstängd"},
+		{name: "quality order", acceptLanguage: "de;q=0.9,en;q=0.5,sv;q=0.7", url: "/", wantMessage: "Användaren bob är avstängd"},
+		{name: "default language", acceptLanguage: "fi", url: "/", wantMessage: "The user bob is disabled"},
+		{name: "locale field", acceptLanguage: "en", url: "/?locale=sv", wantMessage: "Användaren bob är avstängd"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rec := serve(CreateLoggerMiddleware(DefaultContextLogFunc, localeFunc), req, func(c echo.Context) error {
+				return errUserDisabled.ErrWithParams(nil, Fields{"user": "bob"})
+			})
+			want := `{"error_code":42,"error_message":"user.error.disabled","message":"` + tt.wantMessage + `"}`
+			if got := strings.TrimSpace(rec.Body.String()); got != want {
+				t.Errorf("got body: %s, want: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRequestLanguages(t *testing.T) {
+	got := requestLanguages("nb", "da, en-GB;q=0.8, *;q=0.5, fr;q=0")
+	if want := []string{"nb", "da", "en-GB", DefaultLanguage}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package eal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrIntegrityMismatch is returned by LogIntegrity when IntegrityConfig.Enforce is set, and a calculated hash don't
+// match the expected hash.
+var ErrIntegrityMismatch = errors.New("eal: integrity hash mismatch")
+
+// IntegrityConfig configure which files LogIntegrity should calculate hashes for, and optionally the expected hashes.
+type IntegrityConfig struct {
+	// Files is a list of additional files, for example configuration files, to calculate hashes for.
+	Files []string
+
+	// ExpectedBinary is the expected hex encoded SHA-256 hash of the running binary. Not checked if empty.
+	ExpectedBinary string
+
+	// ExpectedFiles map file names (as listed in Files) to the expected hex encoded SHA-256 hash of the file.
+	ExpectedFiles map[string]string
+
+	// Enforce make LogIntegrity return ErrIntegrityMismatch if any hash don't match the expected hash.
+	Enforce bool
+}
+
+// LogIntegrity calculate the SHA-256 hash of the running binary and the configured files, and write a log entry with
+// the hashes, so that log events can be tied to a verified build. If expected hashes are configured and don't match,
+// the log entry is written with error level, and if config.Enforce is set, an error is returned. This can be used to
+// refuse to start:
+//
+//	if err := eal.LogIntegrity(cfg); err != nil {
+//		os.Exit(1)
+//	}
+func LogIntegrity(config IntegrityConfig) error {
+	fields := Fields{}
+	var mismatches []string
+
+	exe, err := os.Executable()
+	if err == nil {
+		var sum string
+		if sum, err = fileSHA256(exe); err == nil {
+			fields[FieldIntegrityBinary] = sum
+			if config.ExpectedBinary != "" && !strings.EqualFold(config.ExpectedBinary, sum) {
+				mismatches = append(mismatches, exe)
+			}
+		}
+	}
+	if err != nil {
+		NewEntry().withError(err).Error("failed to calculate hash of running binary")
+		if config.ExpectedBinary != "" {
+			mismatches = append(mismatches, "<binary>")
+		}
+	}
+
+	files := make(map[string]string, len(config.Files))
+	for _, name := range config.Files {
+		sum, err := fileSHA256(name)
+		if err != nil {
+			NewEntry().withError(err).WithFields(Fields{FieldFile: name}).Error("failed to calculate hash of file")
+			mismatches = append(mismatches, name)
+			continue
+		}
+		files[name] = sum
+		if want, ok := config.ExpectedFiles[name]; ok && !strings.EqualFold(want, sum) {
+			mismatches = append(mismatches, name)
+		}
+	}
+	if len(files) > 0 {
+		fields[FieldIntegrityFiles] = files
+	}
+
+	fields[FieldIntegrityOK] = len(mismatches) == 0
+	if len(mismatches) == 0 {
+		NewEntry().WithFields(fields).Info("integrity")
+		return nil
+	}
+
+	fields[FieldIntegrityMismatches] = mismatches
+	NewEntry().WithFields(fields).Error("integrity")
+	if config.Enforce {
+		return fmt.Errorf("%w: %s", ErrIntegrityMismatch, strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+func fileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
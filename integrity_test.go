@@ -0,0 +1,41 @@
+package eal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogIntegrity(t *testing.T) {
+	entries := captureLog(t)
+
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, []byte("level: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := fileSHA256(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = LogIntegrity(IntegrityConfig{Files: []string{cfg}, ExpectedFiles: map[string]string{cfg: sum}, Enforce: true}); err != nil {
+		t.Errorf("got error: %v, want nil", err)
+	}
+
+	err = LogIntegrity(IntegrityConfig{Files: []string{cfg}, ExpectedFiles: map[string]string{cfg: "00"}, Enforce: true})
+	if !errors.Is(err, ErrIntegrityMismatch) {
+		t.Errorf("got error: %v, want ErrIntegrityMismatch", err)
+	}
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0][FieldIntegrityOK] != true || logged[0][FieldIntegrityBinary] == nil {
+		t.Errorf("got first entry: %v, want integrity_ok=true and a binary hash", logged[0])
+	}
+	if logged[1][FieldIntegrityOK] != false || logged[1]["level"] != "error" {
+		t.Errorf("got second entry: %v, want integrity_ok=false with error level", logged[1])
+	}
+}
@@ -0,0 +1,96 @@
+package eal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencyUnit select the unit and precision of the latency field of the access log entries, see
+// LoggerConfig.LatencyUnit.
+type LatencyUnit int
+
+const (
+	// LatencyMilliseconds log the latency in whole milliseconds, in the latency_ms field. This is the default unit.
+	LatencyMilliseconds LatencyUnit = iota
+
+	// LatencyMillisecondsFloat log the latency in milliseconds with microsecond precision, in the latency_ms field.
+	LatencyMillisecondsFloat
+
+	// LatencyNanoseconds log the latency in nanoseconds, in the latency_ns field.
+	LatencyNanoseconds
+)
+
+// setLatencyFields add the latency field in the unit, and the latency_human field if human is set.
+func setLatencyFields(fields Fields, d time.Duration, unit LatencyUnit, human bool) {
+	switch unit {
+	case LatencyMillisecondsFloat:
+		fields[FieldLatencyMs] = math.Round(float64(d)/float64(time.Microsecond)) / 1000
+	case LatencyNanoseconds:
+		fields[FieldLatencyNs] = int64(d)
+	default:
+		fields[FieldLatencyMs] = int64(d / time.Millisecond)
+	}
+	if human {
+		fields[FieldLatencyHuman] = d.String()
+	}
+}
+
+// latencyBuckets hold the sorted bucket limits and the precomputed bucket labels.
+type latencyBuckets struct {
+	limits []time.Duration
+	labels []string
+}
+
+// newLatencyBuckets create the bucket labels for the provided bucket limits. With the limits 10ms, 50ms and 1s, the
+// labels will be: lt_10ms, 10_50ms, 50ms_1s and gte_1s.
+func newLatencyBuckets(limits []time.Duration) *latencyBuckets {
+	if len(limits) == 0 {
+		return nil
+	}
+
+	lb := &latencyBuckets{limits: append([]time.Duration(nil), limits...)}
+	sort.Slice(lb.limits, func(i, j int) bool { return lb.limits[i] < lb.limits[j] })
+
+	lb.labels = append(lb.labels, "lt_"+durationLabel(lb.limits[0]))
+	for i := 1; i < len(lb.limits); i++ {
+		lb.labels = append(lb.labels, rangeLabel(lb.limits[i-1], lb.limits[i]))
+	}
+	lb.labels = append(lb.labels, "gte_"+durationLabel(lb.limits[len(lb.limits)-1]))
+	return lb
+}
+
+// bucket return the label of the bucket that d belong to.
+func (lb *latencyBuckets) bucket(d time.Duration) string {
+	i := sort.Search(len(lb.limits), func(i int) bool { return d < lb.limits[i] })
+	return lb.labels[i]
+}
+
+func rangeLabel(from, to time.Duration) string {
+	fv, fu := durationUnit(from)
+	tv, tu := durationUnit(to)
+	if fu == tu {
+		return fmt.Sprintf("%d_%d%s", fv, tv, tu)
+	}
+	return fmt.Sprintf("%d%s_%d%s", fv, fu, tv, tu)
+}
+
+func durationLabel(d time.Duration) string {
+	v, u := durationUnit(d)
+	return fmt.Sprintf("%d%s", v, u)
+}
+
+// durationUnit return d as an integer in the largest unit that can represent d without truncation.
+func durationUnit(d time.Duration) (int64, string) {
+	switch {
+	case d%time.Second == 0:
+		return int64(d / time.Second), "s"
+	case d%time.Millisecond == 0:
+		return int64(d / time.Millisecond), "ms"
+	case d%time.Microsecond == 0:
+		return int64(d / time.Microsecond), "us"
+	default:
+		return int64(d), "ns"
+	}
+}
@@ -0,0 +1,54 @@
+package eal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLatencyBuckets(t *testing.T) {
+	lb := newLatencyBuckets([]time.Duration{time.Second, 10 * time.Millisecond, 50 * time.Millisecond})
+	for _, tt := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "lt_10ms"},
+		{d: 9 * time.Millisecond, want: "lt_10ms"},
+		{d: 10 * time.Millisecond, want: "10_50ms"},
+		{d: 50 * time.Millisecond, want: "50ms_1s"},
+		{d: 999 * time.Millisecond, want: "50ms_1s"},
+		{d: time.Second, want: "gte_1s"},
+		{d: time.Hour, want: "gte_1s"},
+	} {
+		if got := lb.bucket(tt.d); got != tt.want {
+			t.Errorf("bucket(%s): got: %s, want: %s", tt.d, got, tt.want)
+		}
+	}
+
+	if newLatencyBuckets(nil) != nil {
+		t.Error("newLatencyBuckets(nil): got non nil, want nil")
+	}
+}
+
+func TestSetLatencyFields(t *testing.T) {
+	d := 1234567 * time.Nanosecond
+	for _, tt := range []struct {
+		unit         LatencyUnit
+		human        bool
+		want         Fields
+		wantDuration int64
+	}{
+		{unit: LatencyMilliseconds, want: Fields{FieldLatencyMs: int64(1)}, wantDuration: 1000000},
+		{unit: LatencyMillisecondsFloat, want: Fields{FieldLatencyMs: 1.235}, wantDuration: 1235000},
+		{unit: LatencyNanoseconds, human: true, want: Fields{FieldLatencyNs: int64(1234567), FieldLatencyHuman: "1.234567ms"}, wantDuration: 1234567},
+	} {
+		got := Fields{}
+		setLatencyFields(got, d, tt.unit, tt.human)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unit %d: got fields: %v, want: %v", tt.unit, got, tt.want)
+		}
+		if ecs := ecsFields(got); ecs["event.duration"] != tt.wantDuration {
+			t.Errorf("unit %d: got event.duration: %v, want: %d", tt.unit, ecs["event.duration"], tt.wantDuration)
+		}
+	}
+}
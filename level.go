@@ -0,0 +1,139 @@
+package eal
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	levelMu  sync.RWMutex
+	levelSet bool
+	logLevel Level
+)
+
+// SetLevel change the minimum level of the log entries that are written, at runtime. It apply both to the standard
+// logrus logger and to the Sink set by SetSink. The level change is logged with warn level.
+func SetLevel(level Level) {
+	installHook()
+	old := GetLevel()
+
+	levelMu.Lock()
+	levelSet = true
+	logLevel = level
+	levelMu.Unlock()
+
+	hook.mu.RLock()
+	sink := hook.sink
+	hook.mu.RUnlock()
+	if sink == nil {
+		logrus.SetLevel(logrus.Level(level))
+	}
+
+	if old != level {
+		NewEntry().Warnf("log level changed from %s to %s", old, level)
+	}
+}
+
+// GetLevel return the minimum level of the log entries that are written.
+func GetLevel() Level {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	if levelSet {
+		return logLevel
+	}
+	return Level(logrus.GetLevel())
+}
+
+// ParseLevel return the level with the provided name, for example "debug" or "info".
+func ParseLevel(name string) (Level, error) {
+	l, err := logrus.ParseLevel(name)
+	return Level(l), err
+}
+
+// setLevel return the level set by SetLevel, if it have been called.
+func setLevel() (Level, bool) {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	return logLevel, levelSet
+}
+
+// sinkLevelEnabled report if entries with the level should be forwarded to the Sink.
+func sinkLevelEnabled(level Level) bool {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	return !levelSet || level <= logLevel
+}
+
+// LevelHandler return an echo handler that let operators read and change the log level at runtime. GET respond with
+// the current level, and PUT or POST change the level to the one in the "level" query parameter, form value or JSON
+// body field:
+//
+//	admin.GET("/log/level", eal.LevelHandler())
+//	admin.PUT("/log/level", eal.LevelHandler())
+//
+//	curl -X PUT 'localhost:8080/admin/log/level?level=debug'
+func LevelHandler() echo.HandlerFunc {
+	type levelDoc struct {
+		Level string `json:"level" form:"level"`
+	}
+	return func(c echo.Context) error {
+		if c.Request().Method == http.MethodPut || c.Request().Method == http.MethodPost {
+			doc := levelDoc{Level: c.QueryParam("level")}
+			if doc.Level == "" {
+				if err := c.Bind(&doc); err != nil {
+					return err
+				}
+			}
+			level, err := ParseLevel(doc.Level)
+			if err != nil {
+				return NewHTTPError(err, http.StatusBadRequest, err.Error())
+			}
+			SetLevel(level)
+		}
+		return c.JSON(http.StatusOK, levelDoc{Level: GetLevel().String()})
+	}
+}
+
+// ToggleLevelOnSignal switch between the current log level and the provided level each time the process receive the
+// signal, for example syscall.SIGUSR1, so that debug logging can be enabled without restarting the service:
+//
+//	stop := eal.ToggleLevelOnSignal(syscall.SIGUSR1, eal.DebugLevel)
+//	defer stop()
+//
+// The returned function stop listening for the signal.
+func ToggleLevelOnSignal(sig os.Signal, level Level) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig)
+
+	go func() {
+		other := GetLevel()
+		for {
+			select {
+			case <-ch:
+				current := GetLevel()
+				if current == level {
+					SetLevel(other)
+				} else {
+					other = current
+					SetLevel(level)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package eal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLevelHandler(t *testing.T) {
+	level := logrus.GetLevel()
+	t.Cleanup(func() {
+		levelMu.Lock()
+		levelSet = false
+		levelMu.Unlock()
+		logrus.SetLevel(level)
+	})
+	SetLevel(InfoLevel)
+	entries := captureLog(t)
+
+	e := echo.New()
+	e.GET("/level", LevelHandler())
+	e.PUT("/level", LevelHandler())
+
+	for _, tt := range []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "get",
+			req:        httptest.NewRequest(http.MethodGet, "/level", nil),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"level":"info"}`,
+		},
+		{
+			name:       "put query",
+			req:        httptest.NewRequest(http.MethodPut, "/level?level=debug", nil),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"level":"debug"}`,
+		},
+		{
+			name: "put json",
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"warning"}`))
+				r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				return r
+			}(),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"level":"warning"}`,
+		},
+		{
+			name:       "invalid level",
+			req:        httptest.NewRequest(http.MethodPut, "/level?level=loud", nil),
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, tt.req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status: %d, want: %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("got body: %s, want: %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if GetLevel() != WarnLevel || logrus.GetLevel() != logrus.WarnLevel {
+		t.Errorf("got level: %s (logrus %s), want warning", GetLevel(), logrus.GetLevel())
+	}
+	logged := entries()
+	if len(logged) != 2 || logged[0]["msg"] != "log level changed from info to debug" {
+		t.Errorf("got log entries: %v, want two level change entries", logged)
+	}
+}
@@ -0,0 +1,106 @@
+package eal
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// Semaphore is implemented by weighted semaphores, for example golang.org/x/sync/semaphore.Weighted.
+	Semaphore interface {
+		Acquire(ctx context.Context, n int64) error
+		Release(n int64)
+	}
+
+	lockContextKey struct{}
+
+	// lockStats accumulate the wait and hold time per lock name, for a single request.
+	lockStats struct {
+		mu   sync.Mutex
+		wait map[string]time.Duration
+		hold map[string]time.Duration
+	}
+)
+
+// Lock acquire the lock l, and record the time spent waiting for the lock. The returned function must be called to
+// release the lock, and record the time the lock was held. If ctx is derived from a request context set up by the
+// CreateLoggerMiddleware, the accumulated wait and hold time per lock name is logged in the lock_wait_ms and
+// lock_hold_ms fields of the access log entry, which make lock contention visible:
+//
+//	unlock := eal.Lock(ctx, "account_cache", &s.mu)
+//	defer unlock()
+func Lock(ctx context.Context, name string, l sync.Locker) (unlock func()) {
+	start := time.Now()
+	l.Lock()
+	acquired := time.Now()
+	ls := contextLockStats(ctx)
+	ls.add(ls.wait, name, acquired.Sub(start))
+
+	return func() {
+		l.Unlock()
+		ls.add(ls.hold, name, time.Since(acquired))
+	}
+}
+
+// AcquireSemaphore acquire n units of the semaphore, and record the wait and hold time in the same way as Lock. If
+// the semaphore can't be acquired, the error from Acquire is returned, and the release function is nil.
+func AcquireSemaphore(ctx context.Context, name string, sem Semaphore, n int64) (release func(), err error) {
+	start := time.Now()
+	if err = sem.Acquire(ctx, n); err != nil {
+		return nil, err
+	}
+	acquired := time.Now()
+	ls := contextLockStats(ctx)
+	ls.add(ls.wait, name, acquired.Sub(start))
+
+	return func() {
+		sem.Release(n)
+		ls.add(ls.hold, name, time.Since(acquired))
+	}, nil
+}
+
+func withLockStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, lockContextKey{}, &lockStats{wait: map[string]time.Duration{}, hold: map[string]time.Duration{}})
+}
+
+func contextLockStats(ctx context.Context) *lockStats {
+	if ctx == nil {
+		return nil
+	}
+	ls, _ := ctx.Value(lockContextKey{}).(*lockStats)
+	return ls
+}
+
+func (ls *lockStats) add(m map[string]time.Duration, name string, d time.Duration) {
+	if ls == nil {
+		return
+	}
+	ls.mu.Lock()
+	m[name] += d
+	ls.mu.Unlock()
+}
+
+// setLockFields add the lock_wait_ms and lock_hold_ms fields, if any locks have been acquired during the request.
+func setLockFields(ctx context.Context, fields Fields) {
+	ls := contextLockStats(ctx)
+	if ls == nil {
+		return
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if len(ls.wait) == 0 {
+		return
+	}
+	fields[FieldLockWaitMs] = durationsMs(ls.wait)
+	fields[FieldLockHoldMs] = durationsMs(ls.hold)
+}
+
+func durationsMs(m map[string]time.Duration) map[string]float64 {
+	ms := make(map[string]float64, len(m))
+	for k, d := range m {
+		ms[k] = float64(d.Microseconds()) / 1000
+	}
+	return ms
+}
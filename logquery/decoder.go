@@ -0,0 +1,243 @@
+// Package logquery contain helpers for reading log streams produced by the eal package.
+package logquery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// maxPrealloc is the largest length read from the stream that is allocated up front, larger values are grown as
+// the data is read.
+const maxPrealloc = 64 << 10
+
+// ErrNotAMap is returned by Decoder.Decode if the next value in the stream isn't a MessagePack map.
+var ErrNotAMap = errors.New("logquery: log entry isn't a map")
+
+// Decoder read MessagePack encoded log entries, as written by eal.MsgpackFormatter, from an input stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// Ext hold the raw data of a MessagePack extension type that the Decoder don't know how to decode.
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// NewDecoder return a new Decoder that read from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode read the next log entry from the input stream. Timestamps are decoded as time.Time, integers as int64 or
+// uint64, floats as float64, and nested maps as map[string]interface{}.
+// At the end of the input stream, Decode return io.EOF.
+func (d *Decoder) Decode() (map[string]interface{}, error) {
+	v, err := d.decodeValue()
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrNotAMap
+	}
+	return m, nil
+}
+
+func (d *Decoder) decodeValue() (interface{}, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.readString(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.readArray(int(c & 0x0f))
+	case c&0xf0 == 0x80:
+		return d.readMap(int(c & 0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, nil
+	case 0xd0:
+		u, err := d.readUint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.readUint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.readUint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.readUint(8)
+		return int64(u), err
+	case 0xca:
+		u, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.readUint(8)
+		return math.Float64frombits(u), err
+	case 0xd9, 0xda, 0xdb:
+		l, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(int(l))
+	case 0xc4, 0xc5, 0xc6:
+		l, err := d.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.readBytes(int(l))
+	case 0xdc, 0xdd:
+		l, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.readArray(int(l))
+	case 0xde, 0xdf:
+		l, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.readMap(int(l))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.readExt(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		l, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.readExt(int(l))
+	}
+
+	return nil, fmt.Errorf("logquery: unsupported MessagePack type 0x%02x", c)
+}
+
+func (d *Decoder) readUint(n int) (uint64, error) {
+	b, err := d.readBytes(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *Decoder) readBytes(n int) ([]byte, error) {
+	if n <= maxPrealloc {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(d.r, b); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return b, nil
+	}
+
+	// Don't trust the length of large values, a corrupt stream could otherwise make the decoder allocate gigabytes
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *Decoder) readString(n int) (string, error) {
+	b, err := d.readBytes(n)
+	return string(b), err
+}
+
+func (d *Decoder) readArray(n int) ([]interface{}, error) {
+	a := make([]interface{}, 0, min(n, maxPrealloc))
+	for i := 0; i < n; i++ {
+		v, err := d.decodeValue()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (d *Decoder) readMap(n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, min(n, maxPrealloc))
+	for i := 0; i < n; i++ {
+		k, err := d.decodeValue()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		v, err := d.decodeValue()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
+
+func (d *Decoder) readExt(n int) (interface{}, error) {
+	t, err := d.r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	data, err := d.readBytes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(t) != -1 {
+		return Ext{Type: int8(t), Data: data}, nil
+	}
+
+	// Timestamp extension type
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(v&0x3ffffffff), int64(v>>34)), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data[:4]))), nil
+	}
+	return nil, fmt.Errorf("logquery: invalid timestamp length: %d", n)
+}
+
+// unexpectedEOF convert io.EOF to io.ErrUnexpectedEOF, since EOF is only expected between log entries.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package logquery
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/modfin/eal"
+	"github.com/sirupsen/logrus"
+)
+
+type frontendMessage struct {
+	ErrorCode    int    `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+type nilError struct{ msg string }
+
+func (e *nilError) Error() string { return e.msg }
+
+func TestDecoder(t *testing.T) {
+	ts := time.Date(2024, 5, 17, 13, 37, 42, 123456789, time.UTC)
+	entries := []*logrus.Entry{
+		{
+			Time:    ts,
+			Level:   logrus.InfoLevel,
+			Message: "access",
+			Data:    logrus.Fields{"status": 200, "latency_ms": int64(12), "uri": "/ping"},
+		},
+		{
+			Time:    ts,
+			Level:   logrus.ErrorLevel,
+			Message: "access",
+			Data: logrus.Fields{
+				"status":       -1,
+				"ratio":        0.5,
+				"ok":           false,
+				"nothing":      nil,
+				"http_message": &frontendMessage{ErrorCode: 42, ErrorMessage: "common.error.some_message"},
+				"error":        errors.New("some error"),
+				"long":         string(bytes.Repeat([]byte("x"), 300)),
+				"msg":          "user message",
+				"level":        "user level",
+				"fields.level": "literal field",
+				"nil_error":    (*nilError)(nil),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &eal.MsgpackFormatter{}
+	for _, e := range entries {
+		b, err := f.Format(e)
+		if err != nil {
+			t.Fatalf("Format() error: %v", err)
+		}
+		buf.Write(b)
+	}
+
+	want := []map[string]interface{}{
+		{"time": ts, "level": "info", "msg": "access", "status": int64(200), "latency_ms": int64(12), "uri": "/ping"},
+		{
+			"time": ts, "level": "error", "msg": "access", "status": int64(-1), "ratio": 0.5, "ok": false, "nothing": nil,
+			"http_message":        map[string]interface{}{"error_code": int64(42), "error_message": "common.error.some_message"},
+			"error":               "some error",
+			"long":                string(bytes.Repeat([]byte("x"), 300)),
+			"fields.msg":          "user message",
+			"fields.fields.level": "user level",
+			"fields.level":        "literal field",
+			"nil_error":           nil,
+		},
+	}
+
+	d := NewDecoder(&buf)
+	for i, w := range want {
+		got, err := d.Decode()
+		if err != nil {
+			t.Fatalf("entry %d: Decode() error: %v", i, err)
+		}
+		if gt, ok := got["time"].(time.Time); ok {
+			got["time"] = gt.UTC()
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("entry %d:\n got: %v,\nwant: %v", i, got, w)
+		}
+	}
+
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("got err: %v, want: io.EOF", err)
+	}
+}
+
+func TestDecoderCorruptLength(t *testing.T) {
+	for _, stream := range [][]byte{
+		{0x81, 0xa1, 'k', 0xc6, 0xff, 0xff, 0xff, 0xff, 'x'},
+		{0x81, 0xa1, 'k', 0xdd, 0xff, 0xff, 0xff, 0xff, 0x01},
+		{0xdf, 0xff, 0xff, 0xff, 0xff, 0xa1, 'k'},
+	} {
+		if _, err := NewDecoder(bytes.NewReader(stream)).Decode(); err != io.ErrUnexpectedEOF {
+			t.Errorf("stream %x: got err: %v, want: %v", stream, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
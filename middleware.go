@@ -1,128 +1,497 @@
 package eal
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 const (
 	contextName = "mfContextLogFields"
+
+	// FieldHeaderPrefix is the prefix of response headers that the CreateLoggerMiddleware convert to log fields. The
+	// rest of the header name is lower-cased, and dashes are replaced with underscores, to form the field name, i.e.
+	// the header "X-Eal-Field-Cache-Status: hit" is logged as cache_status=hit. The headers are removed from the
+	// response before it's sent to the caller. Headers can't set the request fields, like request_id and status, or
+	// overwrite fields that the handler already have set.
+	FieldHeaderPrefix = "X-Eal-Field-"
+
+	maxPageViewIDLength = 128
+)
+
+type (
+	// requestHeaders hold the names of the request ID and page view ID headers of a logger middleware. They are set in
+	// both the echo context and the request context, so that Entry.WithRequest use the same headers.
+	requestHeaders struct {
+		requestID  string
+		pageViewID string
+	}
+
+	requestHeadersContextKey struct{}
 )
 
 // ContextLogFunc can be implemented to be able to add log fields from an echo context.
 type ContextLogFunc func(c echo.Context, fields Fields)
 
 var DefaultContextLogFunc = func(c echo.Context, fields Fields) {
-	req := c.Request()
-	res := c.Response()
+	setRequestFields(c.Request(), c.Response().Header(), fields, contextRequestHeaders(c), contextIDGenerator(c))
+	fields[FieldRouterPath] = c.Path()
+}
 
-	// Check if we have X-Host or X-Forwarded-Host header
+// setRequestFields add the request fields logged by DefaultContextLogFunc and httpRequestLogFunc.
+func setRequestFields(req *http.Request, resHeader http.Header, fields Fields, headers requestHeaders, ids IDGenerator) {
+	host := requestHost(req)
+	if host != "" && req.Header.Get("X-Host") == "" {
+		req.Header.Set("X-Host", host)
+	}
+
+	// Generate Request ID if it's missing, and use the ID set in the response by the echo RequestID middleware if there
+	// is one
+	id := req.Header.Get(headers.requestID)
+	if id == "" {
+		id = resHeader.Get(headers.requestID)
+		if id == "" {
+			id = ids.NewID()
+			resHeader.Set(headers.requestID, id)
+		}
+		req.Header.Set(headers.requestID, id)
+	}
+
+	fields[FieldRequestID] = id
+	fields[FieldRemoteAddr] = clientAddr(req)
+	fields[FieldHost] = host
+	fields[FieldMethod] = req.Method
+	fields[FieldURI] = req.RequestURI
+	if id := pageViewID(req, headers.pageViewID); id != "" {
+		fields[FieldPageViewID] = id
+	}
+}
+
+// requestHost return the host of the X-Host or X-Forwarded-Host header, without port.
+func requestHost(req *http.Request) string {
 	host := req.Header.Get("X-Host")
 	if host == "" {
-		alt := req.Header.Get("X-Forwarded-Host")
-		if alt != "" {
+		if alt := req.Header.Get("X-Forwarded-Host"); alt != "" {
 			host = strings.Split(alt, ":")[0]
-			req.Header.Set("X-Host", host)
 		}
 	}
+	return host
+}
 
-	// Generate Request ID if it's missing
-	id := req.Header.Get("X-Request-Id")
-	if id == "" {
-		id = uuid.New().String()
-		req.Header.Set("X-Request-Id", id)
-		res.Header().Set("X-Request-Id", id)
-	}
-
-	// Attempt to get remote address of the client
-	var remoteAddr string
+// clientAddr attempt to get the remote address of the client, from the proxy headers or the connection.
+func clientAddr(req *http.Request) string {
 	for _, h := range []string{"X-Forwarded-For", "X-Real-Ip", "X-Remote-Addr"} {
-		remoteAddr = req.Header.Get(h)
-		if remoteAddr != "" {
-			break
+		if addr := req.Header.Get(h); addr != "" {
+			return addr
 		}
 	}
-	if remoteAddr == "" {
-		remoteAddr = req.RemoteAddr
+	return req.RemoteAddr
+}
+
+// pageViewID return the page view ID in the header of the request, or an empty string if the request don't have a
+// valid ID.
+func pageViewID(req *http.Request, header string) string {
+	id := req.Header.Get(header)
+	if len(id) > maxPageViewIDLength {
+		return ""
+	}
+	for _, r := range id {
+		if r <= ' ' || r >= 127 {
+			return ""
+		}
 	}
+	return id
+}
+
+// LoggerConfig defines the config for the logger middleware, see CreateLoggerMiddlewareWithConfig.
+type LoggerConfig struct {
+	// ContextLogFuncs is called before the request is handled, to add log fields from the echo context.
+	// If no functions are provided, DefaultContextLogFunc is used.
+	ContextLogFuncs []ContextLogFunc
+
+	// ResultLogFuncs is called after the request have been handled, just before the access log entry is written.
+	// The fields passed to the functions contain all fields that is about to be logged, including status, latency_ms
+	// and any error fields, and the functions can both inspect and add fields. The fields must not be retained after the
+	// function return.
+	ResultLogFuncs []ContextLogFunc
 
-	fields["request_id"] = id
-	fields["remote_addr"] = remoteAddr
-	fields["host"] = host
-	fields["method"] = req.Method
-	fields["uri"] = req.RequestURI
-	fields["router_path"] = c.Path()
+	// LatencyBuckets enable the latency_bucket field when set. The field hold a label for the latency range that the
+	// request belong to, for example: with the buckets 10ms, 50ms and 1s, the field is set to one of: lt_10ms, 10_50ms,
+	// 50ms_1s or gte_1s. This make it cheap to aggregate latency in log-based dashboards.
+	LatencyBuckets []time.Duration
+
+	// LatencyUnit set the unit and precision of the latency field, the default is whole milliseconds in the latency_ms
+	// field.
+	LatencyUnit LatencyUnit
+
+	// LatencyHuman enable the latency_human field, that hold the latency formatted by time.Duration.String, for example
+	// "1.234567ms".
+	LatencyHuman bool
+
+	// AllocSampleRate is the ratio (0-1) of requests that get the alloc_bytes_delta and gc_cycles_delta fields, holding
+	// the number of bytes allocated on the heap and the number of completed GC cycles while the request was handled.
+	// The counters are process wide, so allocations done by concurrent requests are included in the values, but
+	// endpoints that allocate a lot still stand out when the values are aggregated. Disabled if zero.
+	AllocSampleRate float64
+
+	// BeforeRouting must be set when the middleware is registered with echo.Echo.Pre instead of echo.Echo.Use, see
+	// CreateLoggerMiddlewarePre.
+	BeforeRouting bool
+
+	// LogPhases enable the read_ms, handle_ms and write_ms fields, that show how much of the total latency was spent
+	// reading the request body, handling the request, and writing the response. For large uploads and downloads, the
+	// total latency say little about where the time was spent.
+	LogPhases bool
+
+	// MessageTemplate set the message of the access log entries, with field values inserted where the field name is
+	// written within braces, for example "{method} {router_path} -> {status}". Missing fields are rendered as "-".
+	// The default message is "access". The message can also be set per request with SetAccessMessage.
+	MessageTemplate string
+
+	// ECS map the field names of the access log entries to Elastic Common Schema names, see ECSFieldNames. Use
+	// InitECS instead to map the field names of all log entries.
+	ECS bool
+
+	// DevErrorResponses add the error chain and the stacktrace to the response body of server errors (5xx), so that
+	// frontend developers can see the cause of backend failures directly in the network tab. It only has effect in a
+	// development environment, marked by SetDevEnvironment(true), and is ignored otherwise, so that error details are
+	// never exposed in production.
+	DevErrorResponses bool
+
+	// BodyLog enable logging of request and response bodies, in the request_body and response_body fields.
+	BodyLog BodyLogConfig
+
+	// Sampling enable sampling of the access log entries of successful requests, for high traffic endpoints where
+	// most entries are identical. Sampled entries have the sample_rate field set to the sampling rate, so that the
+	// number of requests can be estimated.
+	Sampling SamplingConfig
+
+	// RecoverPanics make the middleware recover panics in the handlers (and the middlewares registered after it). The
+	// panic is handled as if the handler had returned a PanicError, i.e. the caller get a 500 response, and the
+	// access log entry is written with the panic value and the stacktrace of the panicking goroutine. Handlers that
+	// panic with http.ErrAbortHandler are logged as aborted requests, and the panic is resumed, whether RecoverPanics
+	// is set or not.
+	RecoverPanics bool
+
+	// OuterHTTPError make the middleware use the outer/latest echo.HTTPError in the error chain for the response,
+	// instead of the inner/earliest, see GetOuterHTTPError. This is useful when handlers wrap errors from other
+	// services in a sanitized echo.HTTPError that should be sent to the caller. The error is passed to the echo error
+	// handler without its internal error, but the complete error chain is still logged.
+	OuterHTTPError bool
+
+	// RequestIDHeader is the name of the request header that hold the request ID, echo.HeaderXRequestID
+	// ("X-Request-Id") is used if it isn't set. Requests that don't have the header get a generated ID, that is set in
+	// the header of both the request and the response. If the echo RequestID middleware run before the logger
+	// middleware, the ID that it set in the response header is used.
+	RequestIDHeader string
+
+	// PageViewIDHeader is the name of the request header that hold the ID of the frontend page view that triggered the
+	// request, "X-Page-View-Id" is used if it isn't set. The ID is logged in the page_view_id field, and is set in the
+	// same header of error responses, so that RUM tooling can join a user's page view with all backend requests it
+	// triggered, and the errors they caused.
+	PageViewIDHeader string
+
+	// RequestIDGenerator generate the request IDs, for example UUIDv7s or ULIDs, UUIDGenerator is used if it isn't set.
+	// The IDGenerator of the Deps passed to NewLoggerMiddleware take precedence.
+	RequestIDGenerator IDGenerator
+
+	// FieldNames rename fields of the access log entries, to match the log schema of the deployment, for example:
+	//
+	//	FieldNames: map[string]string{eal.FieldStatus: "http.status_code", eal.FieldURI: "url.path"}
+	//
+	// Fields are renamed when the entry is written, after ResultLogFuncs and MessageTemplate, which use the eal field
+	// names. The new names are registered with RegisterFieldNames.
+	FieldNames map[string]string
+
+	// GeoIP enable the geo_country, geo_city, geo_asn and geo_as_org fields, resolved from the remote_addr field by the
+	// GeoIPResolver, for example the GeoIP of the ealmaxmind module.
+	GeoIP GeoIPResolver
 }
 
-// CreateLoggerMiddleware return an echo middleware method that handle access and error logging of the call.
+// DefaultLoggerConfig is the config used by CreateLoggerMiddleware and CreateLoggerMiddlewarePre. It can be changed
+// before the middlewares are created, and is set by InitFromEnv.
+var DefaultLoggerConfig = LoggerConfig{}
+
+// CreateLoggerMiddleware return an echo middleware method that handle access and error logging of the call, configured
+// by DefaultLoggerConfig.
 //
 // If an error is returned from the handlerFunc, the middleware will look at the complete error-chain to find the
-// earliest echo.HTTPError, and return the status code and message from that to the frontend.
+// earliest echo.HTTPError (or the latest, see LoggerConfig.OuterHTTPError), and return the status code and message
+// from that to the frontend.
 // If the error-chain don't contain an echo.HTTPError, a new echo.HTTPError will be created that wrap the returned error.
+//
+// Response headers prefixed with FieldHeaderPrefix are converted to log fields and removed from the response.
 func CreateLoggerMiddleware(logFunctions ...ContextLogFunc) echo.MiddlewareFunc {
+	config := DefaultLoggerConfig
+	if len(logFunctions) > 0 {
+		config.ContextLogFuncs = logFunctions
+	}
+	return CreateLoggerMiddlewareWithConfig(config)
+}
+
+// CreateLoggerMiddlewarePre return an echo middleware that handle access and error logging in the same way as
+// CreateLoggerMiddleware, but that is intended to be registered with echo.Echo.Pre:
+//
+//	e.Pre(eal.CreateLoggerMiddlewarePre())
+//
+// Middlewares registered with Pre run before the request is routed, which mean that also requests that never reach
+// the router, for example requests that are rejected by other Pre middlewares, are logged. Since the route isn't
+// known when the ContextLogFuncs are called, the router_path field is updated after the request have been handled,
+// and is set to "unrouted" if the request never were routed.
+//
+// The Pre middleware should be registered before other Pre middlewares, so that it can log their results.
+func CreateLoggerMiddlewarePre(logFunctions ...ContextLogFunc) echo.MiddlewareFunc {
+	config := DefaultLoggerConfig
+	if len(logFunctions) > 0 {
+		config.ContextLogFuncs = logFunctions
+	}
+	config.BeforeRouting = true
+	return CreateLoggerMiddlewareWithConfig(config)
+}
+
+// CreateLoggerMiddlewareWithConfig return an echo middleware method that handle access and error logging of the call,
+// configured by the provided LoggerConfig. See CreateLoggerMiddleware for more information.
+func CreateLoggerMiddlewareWithConfig(config LoggerConfig) echo.MiddlewareFunc {
+	return NewLoggerMiddleware(Deps{}, config)
+}
+
+// NewLoggerMiddleware return an echo middleware in the same way as CreateLoggerMiddlewareWithConfig, but with the
+// request ID generator, clock and emitter provided by deps, which make it possible to inject fakes in unit tests:
+//
+//	mw := eal.NewLoggerMiddleware(eal.Deps{Clock: fakeClock, Emitter: recorder}, eal.LoggerConfig{})
+func NewLoggerMiddleware(deps Deps, config LoggerConfig) echo.MiddlewareFunc {
+	if deps.IDGenerator == nil {
+		deps.IDGenerator = config.RequestIDGenerator
+	}
+	deps = deps.withDefaults()
+	for _, name := range config.FieldNames {
+		RegisterFieldNames(name)
+	}
+	headers := config.requestHeaders()
+	if len(config.ContextLogFuncs) == 0 {
+		config.ContextLogFuncs = []ContextLogFunc{DefaultContextLogFunc}
+	}
+	buckets := newLatencyBuckets(config.LatencyBuckets)
+	msgTemplate := parseMessageTemplate(config.MessageTemplate)
+	sampler := newSampler(config.Sampling)
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) (err error) {
 			// Init
-			if len(logFunctions) == 0 {
-				logFunctions = []ContextLogFunc{DefaultContextLogFunc}
-			}
+			c.Set(idGeneratorContextName, deps.IDGenerator)
+			c.Set(requestHeadersContextName, headers)
 			logFields := Fields{}
-			for _, f := range logFunctions {
+			baggageFields(c.Request().Header, logFields)
+			for _, f := range config.ContextLogFuncs {
 				f(c, logFields)
 			}
+			if config.GeoIP != nil {
+				setGeoIPFields(config.GeoIP, logFields)
+			}
 
 			// Setup logging context
-			c.Set(contextName, logFields)
-			// TODO: Look into also setting logFields on c.Request().Context()?
+			rf := &requestFields{fields: logFields, clock: deps.Clock}
+			c.Set(contextName, rf)
+			ctx := context.WithValue(c.Request().Context(), fieldsContextKey{}, rf)
+			ctx = context.WithValue(ctx, requestHeadersContextKey{}, headers)
+			c.SetRequest(c.Request().WithContext(withAccessOptions(withLockStats(withCostCounter(ctx)))))
+
+			// Convert log field headers, set by handlers or reverse-proxied upstreams, before the response is sent
+			c.Response().Before(func() {
+				rf.update(func(fields Fields) { headerFields(c.Response().Header(), fields) })
+			})
+
+			// Measure time spent reading the request and writing the response
+			var tr *timedReader
+			var tw *timedWriter
+			if config.LogPhases {
+				if body := c.Request().Body; body != nil && body != http.NoBody {
+					tr = &timedReader{ReadCloser: body}
+					c.Request().Body = tr
+				}
+				tw = &timedWriter{ResponseWriter: c.Response().Writer}
+				c.Response().Writer = tw
+			}
+
+			// Count the request body if it don't have a Content-Length
+			br := countRequestBody(c.Request())
+
+			// Capture request and response bodies
+			var cr *capturingReader
+			var cw *capturingWriter
+			if config.BodyLog.Request {
+				if body := c.Request().Body; body != nil && body != http.NoBody {
+					cr = &capturingReader{ReadCloser: body, bodyCapture: config.BodyLog.newCapture(FieldRequestBody)}
+					c.Request().Body = cr
+				}
+			}
+			if config.BodyLog.Response {
+				cw = &capturingWriter{ResponseWriter: c.Response().Writer, bodyCapture: config.BodyLog.newCapture(FieldResponseBody)}
+				c.Response().Writer = cw
+			}
 
 			// Run other middlewares/handlers
-			start := time.Now()
-			err = next(c)
-			stop := time.Now()
+			alloc := startAllocSample(config.AllocSampleRate)
+			start := deps.Clock.Now()
+			err = callHandler(next, c, config.RecoverPanics)
+			stop := deps.Clock.Now()
+			logFields = rf.close()
+			if alloc != nil {
+				alloc.setFields(logFields)
+			}
+			setStageFields(rf, logFields, err != nil, stop)
+			if config.BeforeRouting {
+				logFields[FieldRouterPath] = routerPath(c)
+			}
 
 			// Handle request/response errors
-			if err != nil {
-				errMsg := GetInnerHTTPError(err)
-				if errMsg != nil {
-					c.Error(errMsg)
-				} else {
-					err = &echo.HTTPError{Code: http.StatusInternalServerError, Message: http.StatusText(http.StatusInternalServerError), Internal: err}
-					c.Error(err)
+			aborted := isClientAbort(c.Request().Context(), err)
+			if err != nil && !aborted {
+				errMsg, ok := responseHTTPError(config, err)
+				if !ok {
+					err = errMsg
+				}
+				if id := pageViewID(c.Request(), headers.pageViewID); id != "" {
+					c.Response().Header().Set(headers.pageViewID, id)
 				}
+				errMsg = errorResponse(config, localizeErrorResponse(c, logFields, errMsg, err), err)
+				c.Set(handledErrorContextName, errMsg)
+				c.Error(errMsg)
 			}
 
 			// Log request result
-			latency := int64(stop.Sub(start) / time.Millisecond)
-			logFields["latency_ms"] = latency
-			logFields["status"] = c.Response().Status
+			headerFields(c.Response().Header(), logFields)
+			setLatencyFields(logFields, stop.Sub(start), config.LatencyUnit, config.LatencyHuman)
+			if buckets != nil {
+				logFields[FieldLatencyBucket] = buckets.bucket(stop.Sub(start))
+			}
+			if tw != nil {
+				setPhaseFields(logFields, deps.Clock.Now().Sub(start), tr, tw)
+			}
+			logFields[FieldStatus] = c.Response().Status
+			setSizeFields(logFields, c.Request(), br, c.Response().Size)
+			if aborted {
+				setClientAbortFields(logFields, c.Response().Committed)
+			}
+			if cr != nil {
+				config.BodyLog.setBodyField(logFields, c.Request().Header.Get(echo.HeaderContentType), &cr.bodyCapture)
+			}
+			if cw != nil {
+				config.BodyLog.setBodyField(logFields, c.Response().Header().Get(echo.HeaderContentType), &cw.bodyCapture)
+			}
+			setCancelCauseField(c.Request().Context(), logFields)
+			setCostField(c.Request().Context(), c.Path(), logFields)
+			setLockFields(c.Request().Context(), logFields)
+			setSpanFields(c.Request().Context(), logFields)
 
 			// Create log entry
 			logEntry := NewEntry()
 			logEntry = logEntry.WithFields(logFields)
 			if err != nil {
-				logEntry = logEntry.WithError(err)
+				logEntry = logEntry.withError(err)
+			}
+			for _, f := range config.ResultLogFuncs {
+				f(c, Fields(logEntry.Data))
 			}
 
-			msg, ok := logFields["_msg"]
-			if !ok {
-				msg = "access"
+			// Write log entry
+			status, handlerErr := c.Response().Status, err
+			write := func(ctx context.Context, sampled bool, rate int) {
+				if !sampled {
+					logEntry.Release()
+					return
+				}
+				if rate > 0 {
+					logEntry.Data[FieldSampleRate] = rate
+				}
+				msg := defaultAccessMessage
+				if msgTemplate != nil {
+					msg = msgTemplate.render(logEntry.Data)
+				}
+				writeAccessEntry(ctx, deps, config, logEntry, logFields, handlerErr, msg)
+				if status >= http.StatusInternalServerError && !aborted && isReportable(handlerErr) {
+					reportError(handlerErr, logEntry.Data)
+				}
+				releaseAccessEntry(deps, logEntry)
 			}
 
-			if _, ok := logEntry.Data[errorMessage]; ok {
-				logEntry.Error(msg)
-			} else {
-				logEntry.Info(msg)
+			requestID, _ := logFields[FieldRequestID].(string)
+			sampled, rate, shared := sampler.sample(c.Path(), requestID, status, err != nil)
+			logCtx := context.WithoutCancel(c.Request().Context())
+			if !shared || !sampler.share(logCtx, requestID, sampled, func(sampled bool) { write(logCtx, sampled, rate) }) {
+				write(c.Request().Context(), sampled, rate)
 			}
 
+			var ap *abortPanic
+			if errors.As(err, &ap) {
+				panic(ap.value)
+			}
 			return nil
 		}
 	}
 }
 
+// responseHTTPError return the echo.HTTPError in the error chain that is sent to the caller, see
+// LoggerConfig.OuterHTTPError. If the error chain don't contain an echo.HTTPError, an internal server error that wrap
+// err is returned, and ok is false.
+func responseHTTPError(config LoggerConfig, err error) (errMsg *echo.HTTPError, ok bool) {
+	if he := GetOuterHTTPError(err); config.OuterHTTPError && he != nil {
+		// Drop the internal error, the echo error handler otherwise respond with a wrapped echo.HTTPError
+		return &echo.HTTPError{Code: he.Code, Message: he.Message}, true
+	}
+	if he := GetInnerHTTPError(err); he != nil {
+		return he, true
+	}
+	return &echo.HTTPError{Code: http.StatusInternalServerError, Message: http.StatusText(http.StatusInternalServerError), Internal: err}, false
+}
+
+// requestHeaders return the request ID and page view ID headers of the config, with the defaults for unset headers.
+func (config LoggerConfig) requestHeaders() requestHeaders {
+	h := requestHeaders{requestID: config.RequestIDHeader, pageViewID: config.PageViewIDHeader}
+	if h.requestID == "" {
+		h.requestID = echo.HeaderXRequestID
+	}
+	if h.pageViewID == "" {
+		h.pageViewID = "X-Page-View-Id"
+	}
+	return h
+}
+
+// routerPath return the route of the request, or "unrouted" if the request haven't been routed.
+func routerPath(c echo.Context) string {
+	if p := c.Path(); p != "" {
+		return p
+	}
+	return "unrouted"
+}
+
+// headerReservedFields are the fields that are set by the logger middleware, and that log field headers can't set.
+var headerReservedFields = map[string]bool{
+	FieldRequestID: true, FieldRemoteAddr: true, FieldHost: true, FieldMethod: true, FieldURI: true,
+	FieldRouterPath: true, FieldLatencyMs: true, FieldLatencyNs: true, FieldStatus: true, FieldPageViewID: true,
+	FieldBytesIn: true, FieldBytesOut: true, FieldClientAborted: true, FieldSampleRate: true,
+}
+
+// headerFields move all headers with the FieldHeaderPrefix from the header to the log fields. Headers don't overwrite
+// fields that already are set, or the fields that the middleware set, see headerReservedFields.
+func headerFields(h http.Header, fields Fields) {
+	for k, v := range h {
+		if !strings.HasPrefix(k, FieldHeaderPrefix) {
+			continue
+		}
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(k, FieldHeaderPrefix)), "-", "_")
+		_, set := fields[name]
+		if !set && len(v) > 0 && name != "" && !strings.HasPrefix(name, "_") && !headerReservedFields[name] {
+			fields[name] = v[0]
+		}
+		h.Del(k)
+	}
+}
+
 // AddContextFields add the fields to the log context, fields added to the context is included in logging done by the
 // CreateLoggerMiddleware. The fields added by this method can also be logged elsewhere by using Entry.WithCtx
 // method.
@@ -131,13 +500,14 @@ func AddContextFields(c echo.Context, fields Fields) {
 		return
 	}
 
-	lc := c.Get(contextName)
-	logFields, ok := lc.(Fields)
-	if !ok || logFields == nil {
+	rf, ok := c.Get(contextName).(*requestFields)
+	if !ok {
 		return
 	}
 
-	for k, v := range fields {
-		logFields[k] = v
-	}
+	rf.update(func(logFields Fields) {
+		for k, v := range fields {
+			setField(logFields, k, v)
+		}
+	})
 }
@@ -0,0 +1,671 @@
+package eal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// captureLog redirect the logrus output to a buffer while the test is running, and return a function that parse the
+// logged JSON entries.
+func captureLog(t *testing.T) func() []map[string]interface{} {
+	t.Helper()
+	installHook()
+	var buf bytes.Buffer
+	out, formatter := logrus.StandardLogger().Out, logrus.StandardLogger().Formatter
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	t.Cleanup(func() {
+		logrus.SetOutput(out)
+		logrus.SetFormatter(formatter)
+	})
+
+	return func() []map[string]interface{} {
+		var entries []map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+		for d.More() {
+			var e map[string]interface{}
+			if err := d.Decode(&e); err != nil {
+				t.Fatalf("failed to decode log entry: %v", err)
+			}
+			entries = append(entries, e)
+		}
+		return entries
+	}
+}
+
+// serve run a single request through an echo instance with the provided middleware and handler.
+func serve(mw echo.MiddlewareFunc, req *http.Request, h echo.HandlerFunc) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Use(mw)
+	e.Any("/*", h)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCreateLoggerMiddlewareCancelCause(t *testing.T) {
+	entries := captureLog(t)
+	errShutdown := errors.New("server shutting down")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		cancel(Trace(errShutdown))
+		return c.Request().Context().Err()
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	cause, ok := logged[0][FieldCancelCause].(map[string]interface{})
+	if !ok {
+		t.Fatalf("got %s: %v, want a map", FieldCancelCause, logged[0][FieldCancelCause])
+	}
+	if cause[FieldErrorMessage] != errShutdown.Error() {
+		t.Errorf("got cause message: %v, want: %s", cause[FieldErrorMessage], errShutdown.Error())
+	}
+	if cause[FieldErrorStack] == nil {
+		t.Errorf("got no %s in cancel cause", FieldErrorStack)
+	}
+}
+
+func TestCreateLoggerMiddlewareStages(t *testing.T) {
+	entries := captureLog(t)
+	errDenied := errors.New("denied")
+
+	req := httptest.NewRequest(http.MethodGet, "/stages", nil)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		Stage(c.Request().Context(), "authenticate")
+		Stage(c.Request().Context(), "authorize")
+		return NewHTTPError(errDenied, http.StatusForbidden)
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0][FieldFailedStage] != "authorize" {
+		t.Errorf("got %s: %v, want: authorize", FieldFailedStage, logged[0][FieldFailedStage])
+	}
+	stages, ok := logged[0][FieldStagesMs].(map[string]interface{})
+	if !ok || len(stages) != 2 {
+		t.Errorf("got %s: %v, want two stages", FieldStagesMs, logged[0][FieldStagesMs])
+	}
+}
+
+func TestStageClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)}
+	emitter := &recordingEmitter{}
+
+	req := httptest.NewRequest(http.MethodGet, "/stages", nil)
+	serve(NewLoggerMiddleware(Deps{Clock: clock, Emitter: emitter}, LoggerConfig{}), req, func(c echo.Context) error {
+		ctx := WithFields(c.Request().Context(), Fields{"tenant": "acme"})
+		Stage(ctx, "authenticate")
+		Stage(ctx, "load_user")
+		Stage(ctx, "authenticate")
+		return nil
+	})
+
+	// The fake clock advance a second each time it's read
+	want := map[string]int64{"authenticate": 2000, "load_user": 1000}
+	if got := emitter.records[0].Fields[FieldStagesMs]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %s: %v, want: %v", FieldStagesMs, got, want)
+	}
+	Stage(context.Background(), "ignored")
+}
+
+func TestCreateLoggerMiddlewareHeaderFields(t *testing.T) {
+	entries := captureLog(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/cached", nil)
+	rec := serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		AddContextFields(c, Fields{"user_id": "u1"})
+		c.Response().Header().Set(FieldHeaderPrefix+"Cache-Status", "hit")
+		c.Response().Header().Set(FieldHeaderPrefix+"Request-Id", "spoofed")
+		c.Response().Header().Set(FieldHeaderPrefix+"Status", "200")
+		c.Response().Header().Set(FieldHeaderPrefix+"User-Id", "spoofed")
+		return c.String(http.StatusOK, "ok")
+	})
+
+	if h := rec.Header().Get(FieldHeaderPrefix + "Cache-Status"); h != "" {
+		t.Errorf("got response header: %s, want it to be removed", h)
+	}
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0]["cache_status"] != "hit" {
+		t.Errorf("got cache_status: %v, want: hit", logged[0]["cache_status"])
+	}
+	if logged[0][FieldRequestID] == "spoofed" || logged[0][FieldStatus] != float64(http.StatusOK) || logged[0]["user_id"] != "u1" {
+		t.Errorf("got entry: %v, want request_id, status and user_id not to be overwritten by headers", logged[0])
+	}
+}
+
+func TestProxyTransport(t *testing.T) {
+	entries := captureLog(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		preq, _ := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, upstream.URL, nil)
+		res, err := ProxyTransport(nil).RoundTrip(preq)
+		if err != nil {
+			return err
+		}
+		_ = res.Body.Close()
+		return c.NoContent(res.StatusCode)
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0][FieldUpstreamStatus] != float64(http.StatusTeapot) {
+		t.Errorf("got %s: %v, want: %d", FieldUpstreamStatus, logged[0][FieldUpstreamStatus], http.StatusTeapot)
+	}
+	if logged[0][FieldUpstreamAddr] != upstream.Listener.Addr().String() {
+		t.Errorf("got %s: %v, want: %s", FieldUpstreamAddr, logged[0][FieldUpstreamAddr], upstream.Listener.Addr())
+	}
+	if logged[0][FieldUpstreamRetries] != float64(0) {
+		t.Errorf("got %s: %v, want: 0", FieldUpstreamRetries, logged[0][FieldUpstreamRetries])
+	}
+}
+
+func TestCreateLoggerMiddlewareCost(t *testing.T) {
+	entries := captureLog(t)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/report", nil)
+		serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+			AddCost(c.Request().Context(), 1.5)
+			AddCost(c.Request().Context(), 2)
+			return c.NoContent(http.StatusOK)
+		})
+	}
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0][FieldRequestCost] != 3.5 {
+		t.Errorf("got %s: %v, want: 3.5", FieldRequestCost, logged[0][FieldRequestCost])
+	}
+
+	var found bool
+	for _, rc := range RouteCosts() {
+		if rc.Route == "/*" {
+			found = true
+			if rc.Requests < 2 || rc.Total < 7 {
+				t.Errorf("got route cost: %+v, want at least 2 requests and total 7", rc)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("route /* not found in RouteCosts(): %v", RouteCosts())
+	}
+}
+
+func TestCreateChaosMiddleware(t *testing.T) {
+	entries := captureLog(t)
+
+	e := echo.New()
+	e.Use(CreateLoggerMiddleware(DefaultContextLogFunc, func(c echo.Context, fields Fields) {
+		fields["user"] = c.Request().Header.Get("X-User")
+	}))
+	e.Use(CreateChaosMiddleware(ChaosConfig{
+		Enabled: true,
+		Rules:   []ChaosRule{{Route: "/users", Fields: Fields{"user": "chaos"}, Status: http.StatusServiceUnavailable}},
+	}))
+	e.GET("/users", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	for _, user := range []string{"normal", "chaos"} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("X-User", user)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0][FieldChaosInjected] != nil || logged[0]["status"] != float64(http.StatusOK) {
+		t.Errorf("normal request: got chaos_injected: %v, status: %v, want: nil, 200", logged[0][FieldChaosInjected], logged[0]["status"])
+	}
+	if logged[1][FieldChaosInjected] != true || logged[1]["status"] != float64(http.StatusServiceUnavailable) {
+		t.Errorf("chaos request: got chaos_injected: %v, status: %v, want: true, 503", logged[1][FieldChaosInjected], logged[1]["status"])
+	}
+}
+
+func TestCreateLoggerMiddlewareAllocSample(t *testing.T) {
+	entries := captureLog(t)
+
+	var sink []byte
+	req := httptest.NewRequest(http.MethodGet, "/alloc", nil)
+	serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{AllocSampleRate: 1}), req, func(c echo.Context) error {
+		sink = make([]byte, 1<<20)
+		return c.NoContent(http.StatusOK)
+	})
+	_ = sink
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if delta, _ := logged[0][FieldAllocBytesDelta].(float64); delta < 1<<20 {
+		t.Errorf("got %s: %v, want at least 1MiB", FieldAllocBytesDelta, logged[0][FieldAllocBytesDelta])
+	}
+	if _, ok := logged[0][FieldGCCyclesDelta]; !ok {
+		t.Errorf("got no %s field", FieldGCCyclesDelta)
+	}
+}
+
+func TestCreateLoggerMiddlewareLock(t *testing.T) {
+	entries := captureLog(t)
+
+	var mu sync.Mutex
+	req := httptest.NewRequest(http.MethodGet, "/lock", nil)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		unlock := Lock(c.Request().Context(), "cache", &mu)
+		time.Sleep(2 * time.Millisecond)
+		unlock()
+		return c.NoContent(http.StatusOK)
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	hold, _ := logged[0][FieldLockHoldMs].(map[string]interface{})
+	if ms, _ := hold["cache"].(float64); ms < 2 {
+		t.Errorf("got %s: %v, want cache >= 2ms", FieldLockHoldMs, logged[0][FieldLockHoldMs])
+	}
+	if wait, _ := logged[0][FieldLockWaitMs].(map[string]interface{}); wait["cache"] == nil {
+		t.Errorf("got %s: %v, want a cache entry", FieldLockWaitMs, logged[0][FieldLockWaitMs])
+	}
+}
+
+func TestCreateLoggerMiddlewarePre(t *testing.T) {
+	entries := captureLog(t)
+
+	e := echo.New()
+	e.Pre(CreateLoggerMiddlewarePre())
+	e.Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("X-Reject") != "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "rejected before routing")
+			}
+			return next(c)
+		}
+	})
+	e.GET("/users/:id", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Reject", "1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0]["router_path"] != "/users/:id" {
+		t.Errorf("got router_path: %v, want: /users/:id", logged[0]["router_path"])
+	}
+	if logged[1]["router_path"] != "unrouted" || logged[1]["status"] != float64(http.StatusBadRequest) {
+		t.Errorf("got router_path: %v, status: %v, want: unrouted, 400", logged[1]["router_path"], logged[1]["status"])
+	}
+}
+
+func TestCreateLoggerMiddlewarePhases(t *testing.T) {
+	entries := captureLog(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewBufferString("payload"))
+	serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{LogPhases: true}), req, func(c echo.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		return c.String(http.StatusOK, "done")
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	for _, k := range []string{FieldReadMs, FieldWriteMs} {
+		if _, ok := logged[0][k]; !ok {
+			t.Errorf("got no %s field", k)
+		}
+	}
+	if ms, _ := logged[0][FieldHandleMs].(float64); ms < 5 {
+		t.Errorf("got %s: %v, want >= 5", FieldHandleMs, logged[0][FieldHandleMs])
+	}
+}
+
+func TestSetAccessMessageAndLevel(t *testing.T) {
+	entries := captureLog(t)
+
+	mw := CreateLoggerMiddleware()
+	serve(mw, httptest.NewRequest(http.MethodGet, "/legacy", nil), func(c echo.Context) error {
+		AddContextFields(c, Fields{"_msg": "legacy message"})
+		return c.NoContent(http.StatusOK)
+	})
+	serve(mw, httptest.NewRequest(http.MethodGet, "/options", nil), func(c echo.Context) error {
+		SetAccessMessage(c, "user lookup")
+		SetAccessLevel(c, WarnLevel)
+		return NewHTTPError(errors.New("no such user"), http.StatusNotFound)
+	})
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	if logged[0]["msg"] != "legacy message" || logged[0]["_msg"] != nil {
+		t.Errorf("got first entry: %v, want legacy message", logged[0])
+	}
+	if logged[1]["msg"] != "user lookup" || logged[1]["level"] != "warning" {
+		t.Errorf("got second entry: %v, want message 'user lookup' with warning level", logged[1])
+	}
+}
+
+func TestLegacyControlFieldsDisabled(t *testing.T) {
+	entries := captureLog(t)
+	LegacyControlFields = false
+	t.Cleanup(func() { LegacyControlFields = true })
+
+	serve(CreateLoggerMiddleware(), httptest.NewRequest(http.MethodGet, "/", nil), func(c echo.Context) error {
+		AddContextFields(c, Fields{"_msg": "legacy message", "_internal": 1})
+		return c.NoContent(http.StatusOK)
+	})
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0]["msg"] != defaultAccessMessage || logged[0]["_msg"] != "legacy message" || logged[0]["_internal"] != float64(1) {
+		t.Errorf("got entry: %v, want the default message and the _ fields logged", logged[0])
+	}
+}
+
+func TestPageViewID(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		config     LoggerConfig
+		header     string
+		err        error
+		wantField  interface{}
+		wantHeader string
+	}{
+		{name: "error", header: "pv-123", err: echo.ErrNotFound, wantField: "pv-123", wantHeader: "pv-123"},
+		{name: "custom header", config: LoggerConfig{PageViewIDHeader: "X-View-Id"}, header: "pv-123", err: echo.ErrNotFound, wantField: "pv-123", wantHeader: "pv-123"},
+		{name: "success", header: "pv-123", wantField: "pv-123"},
+		{name: "invalid", header: "pv 123", err: echo.ErrNotFound},
+		{name: "missing", err: echo.ErrNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLog(t)
+			header := tt.config.requestHeaders().pageViewID
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(header, tt.header)
+			}
+			rec := serve(CreateLoggerMiddlewareWithConfig(tt.config), req, func(c echo.Context) error {
+				if tt.err != nil {
+					return tt.err
+				}
+				return c.NoContent(http.StatusOK)
+			})
+
+			if got := rec.Header().Get(header); got != tt.wantHeader {
+				t.Errorf("got %s response header: %q, want: %q", header, got, tt.wantHeader)
+			}
+			if got := entries()[0][FieldPageViewID]; got != tt.wantField {
+				t.Errorf("got %s: %v, want: %v", FieldPageViewID, got, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	entries := captureLog(t)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{RecoverPanics: true}), req, func(c echo.Context) error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("got status: %d, want: %d", rec.Code, http.StatusInternalServerError)
+	}
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	if logged[0][FieldStatus] != float64(http.StatusInternalServerError) || logged[0]["level"] != "error" {
+		t.Errorf("got entry: %v, want status 500 with error level", logged[0])
+	}
+	if msg, _ := logged[0][FieldErrorMessage].(string); !strings.Contains(msg, "panic: assignment to entry in nil map") {
+		t.Errorf("got %s: %q, want the panic value", FieldErrorMessage, msg)
+	}
+	if stack, _ := logged[0][FieldErrorStack].(string); !strings.Contains(stack, "TestRecoverPanics") {
+		t.Errorf("got %s: %q, want the stack of the panicking goroutine", FieldErrorStack, stack)
+	}
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("got panic: %v, want it resumed without RecoverPanics", r)
+		}
+	}()
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		panic("boom")
+	})
+}
+
+func TestMarkCache(t *testing.T) {
+	entries := captureLog(t)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		MarkCache(c, CacheHit, 90*time.Second)
+		return c.NoContent(http.StatusOK)
+	})
+
+	logged := entries()
+	if logged[0][FieldCacheStatus] != CacheHit || logged[0][FieldCacheTTL] != float64(90) {
+		t.Errorf("got %s: %v, %s: %v, want hit and 90", FieldCacheStatus, logged[0][FieldCacheStatus], FieldCacheTTL, logged[0][FieldCacheTTL])
+	}
+}
+
+func TestClientAborted(t *testing.T) {
+	errShutdown := errors.New("server shutting down")
+	for _, tt := range []struct {
+		name        string
+		cancel      func(cancel context.CancelCauseFunc)
+		handler     echo.HandlerFunc
+		noRecover   bool
+		wantPanic   bool
+		wantAborted bool
+		wantStatus  float64
+		wantLevel   string
+	}{
+		{
+			name: "broken pipe",
+			handler: func(c echo.Context) error {
+				return fmt.Errorf("write response: %w", syscall.EPIPE)
+			},
+			wantAborted: true,
+			wantStatus:  StatusClientClosedRequest,
+			wantLevel:   "info",
+		},
+		{
+			name: "connection reset after response",
+			handler: func(c echo.Context) error {
+				c.Response().WriteHeader(http.StatusOK)
+				return Trace(syscall.ECONNRESET)
+			},
+			wantAborted: true,
+			wantStatus:  http.StatusOK,
+			wantLevel:   "info",
+		},
+		{
+			name:   "client gone",
+			cancel: func(cancel context.CancelCauseFunc) { cancel(nil) },
+			handler: func(c echo.Context) error {
+				return c.Request().Context().Err()
+			},
+			wantAborted: true,
+			wantStatus:  StatusClientClosedRequest,
+			wantLevel:   "info",
+		},
+		{
+			name:   "canceled by application",
+			cancel: func(cancel context.CancelCauseFunc) { cancel(errShutdown) },
+			handler: func(c echo.Context) error {
+				return c.Request().Context().Err()
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantLevel:  "error",
+		},
+		{
+			name: "abort handler panic",
+			handler: func(c echo.Context) error {
+				panic(http.ErrAbortHandler)
+			},
+			wantPanic:   true,
+			wantAborted: true,
+			wantStatus:  StatusClientClosedRequest,
+			wantLevel:   "info",
+		},
+		{
+			name: "abort handler panic without recover",
+			handler: func(c echo.Context) error {
+				panic(http.ErrAbortHandler)
+			},
+			noRecover:   true,
+			wantPanic:   true,
+			wantAborted: true,
+			wantStatus:  StatusClientClosedRequest,
+			wantLevel:   "info",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLog(t)
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			if tt.cancel != nil {
+				tt.cancel(cancel)
+			}
+
+			func() {
+				defer func() {
+					if r := recover(); (r == http.ErrAbortHandler) != tt.wantPanic {
+						t.Errorf("got panic: %v, want http.ErrAbortHandler panic: %t", r, tt.wantPanic)
+					}
+				}()
+				req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+				serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{RecoverPanics: !tt.noRecover}), req, tt.handler)
+			}()
+
+			logged := entries()
+			if len(logged) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(logged))
+			}
+			if aborted, _ := logged[0][FieldClientAborted].(bool); aborted != tt.wantAborted {
+				t.Errorf("got %s: %t, want: %t", FieldClientAborted, aborted, tt.wantAborted)
+			}
+			if logged[0][FieldStatus] != tt.wantStatus || logged[0]["level"] != tt.wantLevel {
+				t.Errorf("got status: %v, level: %v, want: %v, %s", logged[0][FieldStatus], logged[0]["level"], tt.wantStatus, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestOuterHTTPError(t *testing.T) {
+	captureLog(t)
+	handler := func(c echo.Context) error {
+		inner := echo.NewHTTPError(http.StatusConflict, "row version mismatch")
+		return NewHTTPError(inner, http.StatusBadRequest, "could not save")
+	}
+
+	for _, tt := range []struct {
+		outer    bool
+		wantCode int
+	}{
+		{outer: false, wantCode: http.StatusConflict},
+		{outer: true, wantCode: http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		rec := serve(CreateLoggerMiddlewareWithConfig(LoggerConfig{OuterHTTPError: tt.outer}), req, handler)
+		if rec.Code != tt.wantCode {
+			t.Errorf("OuterHTTPError %v: got status: %d, want: %d", tt.outer, rec.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestSizeFields(t *testing.T) {
+	handler := func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, strings.ToUpper(string(body)))
+	}
+
+	for _, tt := range []struct {
+		name          string
+		contentLength int64
+	}{
+		{name: "content length", contentLength: 11},
+		{name: "chunked", contentLength: -1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLog(t)
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world"))
+			req.ContentLength = tt.contentLength
+			serve(CreateLoggerMiddleware(), req, handler)
+
+			logged := entries()
+			if len(logged) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(logged))
+			}
+			if logged[0][FieldBytesIn] != float64(11) || logged[0][FieldBytesOut] != float64(11) {
+				t.Errorf("got bytes_in: %v, bytes_out: %v, want 11 and 11", logged[0][FieldBytesIn], logged[0][FieldBytesOut])
+			}
+		})
+	}
+}
+
+func BenchmarkCreateLoggerMiddleware(b *testing.B) {
+	out := logrus.StandardLogger().Out
+	logrus.SetOutput(io.Discard)
+	defer logrus.SetOutput(out)
+
+	e := echo.New()
+	e.Use(CreateLoggerMiddleware())
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
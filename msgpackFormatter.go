@@ -0,0 +1,323 @@
+package eal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MsgpackFormatter is a logrus.Formatter that encode each log entry as a MessagePack map. The output is a lot more
+// compact than the JSON output, and is intended for environments where logs are shipped over metered or slow links.
+//
+// Each entry is a self-delimiting MessagePack map, so a log stream is simply a sequence of maps. The time field is
+// encoded using the MessagePack timestamp extension type. The logquery package contain a decoder for the format.
+type MsgpackFormatter struct{}
+
+// InitMsgpack initialize the logrus logger to output MessagePack encoded log entries to STDOUT.
+func InitMsgpack() {
+	installHook()
+	logrus.SetFormatter(&MsgpackFormatter{})
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *MsgpackFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	writeMsgpackMapHeader(b, len(keys)+3)
+	writeMsgpackString(b, logrus.FieldKeyTime)
+	writeMsgpackTime(b, entry.Time)
+	writeMsgpackString(b, logrus.FieldKeyLevel)
+	writeMsgpackString(b, entry.Level.String())
+	writeMsgpackString(b, logrus.FieldKeyMsg)
+	writeMsgpackString(b, entry.Message)
+	for _, k := range keys {
+		name := k
+		switch k {
+		case logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg:
+			// Clashing fields are prefixed, in the same way as by logrus.JSONFormatter, and prefixed again if the
+			// entry already has a field with the prefixed name, so that the map never has duplicate keys
+			name = "fields." + k
+			for _, exists := entry.Data[name]; exists; _, exists = entry.Data[name] {
+				name = "fields." + name
+			}
+		}
+		writeMsgpackString(b, name)
+		if err := writeMsgpackValue(b, entry.Data[k]); err != nil {
+			return nil, fmt.Errorf("failed to encode field %s: %w", k, err)
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeMsgpackValue(b *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case bool:
+		if v {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case int:
+		writeMsgpackInt(b, int64(v))
+	case int8:
+		writeMsgpackInt(b, int64(v))
+	case int16:
+		writeMsgpackInt(b, int64(v))
+	case int32:
+		writeMsgpackInt(b, int64(v))
+	case int64:
+		writeMsgpackInt(b, v)
+	case uint:
+		writeMsgpackUint(b, uint64(v))
+	case uint8:
+		writeMsgpackUint(b, uint64(v))
+	case uint16:
+		writeMsgpackUint(b, uint64(v))
+	case uint32:
+		writeMsgpackUint(b, uint64(v))
+	case uint64:
+		writeMsgpackUint(b, v)
+	case float32:
+		b.WriteByte(0xca)
+		_ = binary.Write(b, binary.BigEndian, math.Float32bits(v))
+	case float64:
+		b.WriteByte(0xcb)
+		_ = binary.Write(b, binary.BigEndian, math.Float64bits(v))
+	case string:
+		writeMsgpackString(b, v)
+	case []byte:
+		writeMsgpackBinary(b, v)
+	case time.Time:
+		writeMsgpackTime(b, v)
+	case time.Duration:
+		writeMsgpackInt(b, int64(v))
+	case error:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			b.WriteByte(0xc0)
+			return nil
+		}
+		writeMsgpackString(b, v.Error())
+	case map[string]interface{}:
+		return writeMsgpackMap(b, v)
+	case Fields:
+		return writeMsgpackMap(b, v)
+	case []interface{}:
+		writeMsgpackArrayHeader(b, len(v))
+		for _, item := range v {
+			if err := writeMsgpackValue(b, item); err != nil {
+				return err
+			}
+		}
+	case []string:
+		writeMsgpackArrayHeader(b, len(v))
+		for _, item := range v {
+			writeMsgpackString(b, item)
+		}
+	case fmt.Stringer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			b.WriteByte(0xc0)
+			return nil
+		}
+		writeMsgpackString(b, v.String())
+	default:
+		// Use the JSON representation of other types (structs, typed maps, slices), to get the same field names as
+		// the JSON formatter would produce.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		if err = d.Decode(&generic); err != nil {
+			return err
+		}
+		return writeMsgpackJSONValue(b, generic)
+	}
+	return nil
+}
+
+// writeMsgpackJSONValue encode a value decoded by encoding/json, where numbers are represented by json.Number.
+func writeMsgpackJSONValue(b *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			writeMsgpackInt(b, i)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return writeMsgpackValue(b, f)
+	case map[string]interface{}:
+		writeMsgpackMapHeader(b, len(v))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeMsgpackString(b, k)
+			if err := writeMsgpackJSONValue(b, v[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		writeMsgpackArrayHeader(b, len(v))
+		for _, item := range v {
+			if err := writeMsgpackJSONValue(b, item); err != nil {
+				return err
+			}
+		}
+	default:
+		return writeMsgpackValue(b, v)
+	}
+	return nil
+}
+
+func writeMsgpackMap(b *bytes.Buffer, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	writeMsgpackMapHeader(b, len(keys))
+	for _, k := range keys {
+		writeMsgpackString(b, k)
+		if err := writeMsgpackValue(b, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMsgpackInt(b *bytes.Buffer, v int64) {
+	switch {
+	case v >= 0:
+		writeMsgpackUint(b, uint64(v))
+	case v >= -32:
+		b.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		b.WriteByte(0xd0)
+		b.WriteByte(byte(v))
+	case v >= math.MinInt16:
+		b.WriteByte(0xd1)
+		_ = binary.Write(b, binary.BigEndian, int16(v))
+	case v >= math.MinInt32:
+		b.WriteByte(0xd2)
+		_ = binary.Write(b, binary.BigEndian, int32(v))
+	default:
+		b.WriteByte(0xd3)
+		_ = binary.Write(b, binary.BigEndian, v)
+	}
+}
+
+func writeMsgpackUint(b *bytes.Buffer, v uint64) {
+	switch {
+	case v <= 0x7f:
+		b.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		b.WriteByte(0xcc)
+		b.WriteByte(byte(v))
+	case v <= math.MaxUint16:
+		b.WriteByte(0xcd)
+		_ = binary.Write(b, binary.BigEndian, uint16(v))
+	case v <= math.MaxUint32:
+		b.WriteByte(0xce)
+		_ = binary.Write(b, binary.BigEndian, uint32(v))
+	default:
+		b.WriteByte(0xcf)
+		_ = binary.Write(b, binary.BigEndian, v)
+	}
+}
+
+func writeMsgpackString(b *bytes.Buffer, s string) {
+	l := len(s)
+	switch {
+	case l < 32:
+		b.WriteByte(0xa0 | byte(l))
+	case l <= math.MaxUint8:
+		b.WriteByte(0xd9)
+		b.WriteByte(byte(l))
+	case l <= math.MaxUint16:
+		b.WriteByte(0xda)
+		_ = binary.Write(b, binary.BigEndian, uint16(l))
+	default:
+		b.WriteByte(0xdb)
+		_ = binary.Write(b, binary.BigEndian, uint32(l))
+	}
+	b.WriteString(s)
+}
+
+func writeMsgpackBinary(b *bytes.Buffer, data []byte) {
+	l := len(data)
+	switch {
+	case l <= math.MaxUint8:
+		b.WriteByte(0xc4)
+		b.WriteByte(byte(l))
+	case l <= math.MaxUint16:
+		b.WriteByte(0xc5)
+		_ = binary.Write(b, binary.BigEndian, uint16(l))
+	default:
+		b.WriteByte(0xc6)
+		_ = binary.Write(b, binary.BigEndian, uint32(l))
+	}
+	b.Write(data)
+}
+
+// writeMsgpackTime encode t using the 96-bit variant of the MessagePack timestamp extension type (-1).
+func writeMsgpackTime(b *bytes.Buffer, t time.Time) {
+	b.WriteByte(0xc7)
+	b.WriteByte(12)
+	b.WriteByte(0xff)
+	_ = binary.Write(b, binary.BigEndian, uint32(t.Nanosecond()))
+	_ = binary.Write(b, binary.BigEndian, t.Unix())
+}
+
+func writeMsgpackArrayHeader(b *bytes.Buffer, l int) {
+	switch {
+	case l < 16:
+		b.WriteByte(0x90 | byte(l))
+	case l <= math.MaxUint16:
+		b.WriteByte(0xdc)
+		_ = binary.Write(b, binary.BigEndian, uint16(l))
+	default:
+		b.WriteByte(0xdd)
+		_ = binary.Write(b, binary.BigEndian, uint32(l))
+	}
+}
+
+func writeMsgpackMapHeader(b *bytes.Buffer, l int) {
+	switch {
+	case l < 16:
+		b.WriteByte(0x80 | byte(l))
+	case l <= math.MaxUint16:
+		b.WriteByte(0xde)
+		_ = binary.Write(b, binary.BigEndian, uint16(l))
+	default:
+		b.WriteByte(0xdf)
+		_ = binary.Write(b, binary.BigEndian, uint32(l))
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package eal
+
+// raceEnabled is set when the tests are run with the race detector, which make allocation counts unreliable.
+const raceEnabled = false
@@ -0,0 +1,399 @@
+package eal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// otlpAttributeNames map eal field names to OpenTelemetry semantic convention attribute names.
+var otlpAttributeNames = map[string]string{
+	FieldMethod:       "http.request.method",
+	FieldStatus:       "http.response.status_code",
+	FieldRouterPath:   "http.route",
+	FieldRemoteAddr:   "client.address",
+	FieldHost:         "server.address",
+	FieldErrorMessage: "exception.message",
+	FieldErrorType:    "exception.type",
+	FieldErrorStack:   "exception.stacktrace",
+}
+
+type (
+	// OTLPConfig configure an OTLPSink.
+	OTLPConfig struct {
+		// Endpoint is the URL of the OTLP/HTTP logs endpoint, for example "http://otel-collector:4318/v1/logs".
+		Endpoint string
+
+		// Headers is added to each export request, for example for authentication.
+		Headers map[string]string
+
+		// Resource is the resource attributes, for example {"service.name": "users"}.
+		Resource map[string]string
+
+		// BatchSize is the maximum number of log records sent in one export request, 512 is used if not set.
+		BatchSize int
+
+		// FlushInterval is the maximum time a log record is buffered before it's exported, 5s is used if not set.
+		FlushInterval time.Duration
+
+		// QueueSize is the maximum number of buffered log records, records are dropped when the queue is full.
+		// 4096 is used if not set.
+		QueueSize int
+
+		// MaxRetries is the number of times a failed export is retried, with exponential backoff. 3 is used if not set.
+		MaxRetries int
+
+		// Client is the HTTP client used for exports, http.DefaultClient is used if not set.
+		Client *http.Client
+	}
+
+	// OTLPSink is a Sink that export log records to an OpenTelemetry collector, using the OTLP/HTTP protocol with JSON
+	// encoding. Records are batched, and exports that fail with a retryable error are retried. OTLPSink don't add any
+	// dependencies to eal, the ealotlp module provide a sink that export log records using OTLP/gRPC.
+	OTLPSink struct {
+		config  OTLPConfig
+		queue   chan Record
+		flush   chan chan struct{}
+		done    chan struct{}
+		once    sync.Once
+		dropped atomic.Uint64
+	}
+
+	otlpValue struct {
+		StringValue *string        `json:"stringValue,omitempty"`
+		BoolValue   *bool          `json:"boolValue,omitempty"`
+		IntValue    *string        `json:"intValue,omitempty"`
+		DoubleValue *float64       `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArray     `json:"arrayValue,omitempty"`
+		KvlistValue *otlpKeyValues `json:"kvlistValue,omitempty"`
+	}
+
+	otlpArray struct {
+		Values []otlpValue `json:"values"`
+	}
+
+	otlpKeyValues struct {
+		Values []otlpKeyValue `json:"values"`
+	}
+
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+
+	otlpLogRecord struct {
+		TimeUnixNano   string         `json:"timeUnixNano"`
+		SeverityNumber int            `json:"severityNumber"`
+		SeverityText   string         `json:"severityText"`
+		Body           otlpValue      `json:"body"`
+		Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+		TraceID        string         `json:"traceId,omitempty"`
+		SpanID         string         `json:"spanId,omitempty"`
+	}
+)
+
+// NewOTLPSink create an OTLPSink and start the background exporter. Close must be called to flush buffered records
+// when the application exit.
+//
+//	s := eal.NewOTLPSink(eal.OTLPConfig{Endpoint: "http://otel-collector:4318/v1/logs", Resource: map[string]string{"service.name": "users"}})
+//	defer s.Close()
+//	eal.SetSink(s)
+func NewOTLPSink(config OTLPConfig) *OTLPSink {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 4096
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	s := &OTLPSink{
+		config: config,
+		queue:  make(chan Record, config.QueueSize),
+		flush:  make(chan chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write implements the Sink interface. The record is queued for export, or dropped if the queue is full.
+func (s *OTLPSink) Write(r Record) error {
+	select {
+	case s.queue <- r:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// Dropped return the number of records that have been dropped because the queue was full, or the export failed.
+func (s *OTLPSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Flush export all queued records, and wait for the export to complete.
+func (s *OTLPSink) Flush() {
+	ch := make(chan struct{})
+	select {
+	case s.flush <- ch:
+		<-ch
+	case <-s.done:
+	}
+}
+
+// Close flush all queued records, and stop the background exporter.
+func (s *OTLPSink) Close() {
+	s.once.Do(func() {
+		s.Flush()
+		close(s.done)
+	})
+}
+
+func (s *OTLPSink) run() {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, s.config.BatchSize)
+	export := func() {
+		if len(batch) > 0 {
+			s.export(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case r := <-s.queue:
+			batch = append(batch, r)
+			if len(batch) >= s.config.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ch := <-s.flush:
+			for len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+				if len(batch) >= s.config.BatchSize {
+					export()
+				}
+			}
+			export()
+			close(ch)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// export send the batch to the collector, retrying retryable failures with exponential backoff.
+func (s *OTLPSink) export(batch []Record) {
+	body, err := json.Marshal(s.payload(batch))
+	if err != nil {
+		s.dropped.Add(uint64(len(batch)))
+		return
+	}
+
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= s.config.MaxRetries {
+			s.dropped.Add(uint64(len(batch)))
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (s *OTLPSink) post(body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := s.config.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = res.Body.Close()
+
+	switch {
+	case res.StatusCode/100 == 2:
+		return false, nil
+	case res.StatusCode == http.StatusTooManyRequests, res.StatusCode == http.StatusBadGateway,
+		res.StatusCode == http.StatusServiceUnavailable, res.StatusCode == http.StatusGatewayTimeout:
+		return true, fmt.Errorf("otlp export failed: %s", res.Status)
+	default:
+		return false, fmt.Errorf("otlp export failed: %s", res.Status)
+	}
+}
+
+func (s *OTLPSink) payload(batch []Record) interface{} {
+	records := make([]otlpLogRecord, 0, len(batch))
+	for _, r := range batch {
+		records = append(records, otlpRecord(r))
+	}
+
+	resource := make([]otlpKeyValue, 0, len(s.config.Resource))
+	for k, v := range s.config.Resource {
+		resource = append(resource, otlpKeyValue{Key: k, Value: otlpAnyValue(v)})
+	}
+	sort.Slice(resource, func(i, j int) bool { return resource[i].Key < resource[j].Key })
+
+	return map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{"attributes": resource},
+				"scopeLogs": []interface{}{
+					map[string]interface{}{
+						"scope":      map[string]interface{}{"name": "github.com/modfin/eal"},
+						"logRecords": records,
+					},
+				},
+			},
+		},
+	}
+}
+
+func otlpRecord(r Record) otlpLogRecord {
+	lr := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(r.Time.UnixNano(), 10),
+		SeverityNumber: OTLPSeverityNumber(r.Level),
+		SeverityText:   r.Level.String(),
+		Body:           otlpAnyValue(r.Message),
+	}
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := r.Fields[k]
+		switch k {
+		case FieldTraceID:
+			lr.TraceID = fmt.Sprint(v)
+			continue
+		case FieldSpanID:
+			lr.SpanID = fmt.Sprint(v)
+			continue
+		}
+		lr.Attributes = append(lr.Attributes, otlpKeyValue{Key: OTLPAttributeName(k), Value: otlpAnyValue(v)})
+	}
+	return lr
+}
+
+// OTLPAttributeName return the OpenTelemetry semantic convention attribute name of an eal field, for example
+// "http.response.status_code" for the status field. Fields that don't have a semantic convention name keep their name.
+func OTLPAttributeName(field string) string {
+	if name, ok := otlpAttributeNames[field]; ok {
+		return name
+	}
+	return field
+}
+
+// OTLPSeverityNumber return the OpenTelemetry severity number of a level.
+func OTLPSeverityNumber(l Level) int {
+	switch l {
+	case TraceLevel:
+		return 1
+	case DebugLevel:
+		return 5
+	case InfoLevel:
+		return 9
+	case WarnLevel:
+		return 13
+	case ErrorLevel:
+		return 17
+	case FatalLevel:
+		return 21
+	default:
+		return 24
+	}
+}
+
+func otlpAnyValue(v interface{}) otlpValue {
+	switch t := v.(type) {
+	case string:
+		return otlpValue{StringValue: &t}
+	case bool:
+		return otlpValue{BoolValue: &t}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(t)
+		return otlpValue{IntValue: &s}
+	case float32:
+		f := float64(t)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &t}
+	case error:
+		s := t.Error()
+		return otlpValue{StringValue: &s}
+	case map[string]interface{}:
+		return otlpKvlist(t)
+	case Fields:
+		return otlpKvlist(t)
+	case []interface{}:
+		arr := &otlpArray{Values: make([]otlpValue, 0, len(t))}
+		for _, item := range t {
+			arr.Values = append(arr.Values, otlpAnyValue(item))
+		}
+		return otlpValue{ArrayValue: arr}
+	case []string:
+		arr := &otlpArray{Values: make([]otlpValue, 0, len(t))}
+		for _, item := range t {
+			arr.Values = append(arr.Values, otlpAnyValue(item))
+		}
+		return otlpValue{ArrayValue: arr}
+	case nil:
+		s := ""
+		return otlpValue{StringValue: &s}
+	default:
+		// Use the JSON representation for other types
+		b, err := json.Marshal(t)
+		s := string(b)
+		if err != nil {
+			s = fmt.Sprint(t)
+		}
+		return otlpValue{StringValue: &s}
+	}
+}
+
+func otlpKvlist(m map[string]interface{}) otlpValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kv := &otlpKeyValues{Values: make([]otlpKeyValue, 0, len(m))}
+	for _, k := range keys {
+		kv.Values = append(kv.Values, otlpKeyValue{Key: k, Value: otlpAnyValue(m[k])})
+	}
+	return otlpValue{KvlistValue: kv}
+}
@@ -0,0 +1,69 @@
+package eal
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTLPSink(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []map[string]interface{}
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			// First export fail with a retryable error
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(b, &body)
+		bodies = append(bodies, body)
+	}))
+	defer srv.Close()
+
+	s := NewOTLPSink(OTLPConfig{Endpoint: srv.URL, Resource: map[string]string{"service.name": "test"}})
+	_ = s.Write(Record{
+		Time:    time.Unix(1, 0),
+		Level:   ErrorLevel,
+		Message: "access",
+		Fields:  Fields{FieldStatus: 500, FieldMethod: "GET", FieldTraceID: "4bf92f3577b34da6a3ce929d0e0e4736", "user_id": "u1"},
+	})
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("got %d successful exports, want 1", len(bodies))
+	}
+	if s.Dropped() != 0 {
+		t.Errorf("got %d dropped records, want 0", s.Dropped())
+	}
+
+	rl := bodies[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	sl := rl["scopeLogs"].([]interface{})[0].(map[string]interface{})
+	rec := sl["logRecords"].([]interface{})[0].(map[string]interface{})
+	if rec["severityNumber"] != float64(17) || rec["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" || rec["timeUnixNano"] != "1000000000" {
+		t.Errorf("got unexpected log record: %v", rec)
+	}
+
+	attrs := map[string]interface{}{}
+	for _, a := range rec["attributes"].([]interface{}) {
+		kv := a.(map[string]interface{})
+		attrs[kv["key"].(string)] = kv["value"]
+	}
+	if v, _ := attrs["http.response.status_code"].(map[string]interface{}); v["intValue"] != "500" {
+		t.Errorf("got status attribute: %v, want intValue 500", attrs["http.response.status_code"])
+	}
+	if v, _ := attrs["user_id"].(map[string]interface{}); v["stringValue"] != "u1" {
+		t.Errorf("got user_id attribute: %v, want stringValue u1", attrs["user_id"])
+	}
+}
@@ -0,0 +1,56 @@
+package eal
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// splitOutputFormatter wrap a logrus.Formatter, and write the formatted log entries to one of two writers, depending
+// on the severity of the log entry. The entries are written by the formatter, so the logrus output is discarded.
+type splitOutputFormatter struct {
+	next   logrus.Formatter
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// InitSplitOutput configure the logrus logger to write Info, Debug and Trace entries to STDOUT, and Warn and more
+// severe entries to STDERR. InitSplitOutput only change where the log entries are written, so it should be called
+// after Init (or InitMsgpack) have been used to select the log format.
+func InitSplitOutput() {
+	SetSplitOutput(os.Stdout, os.Stderr)
+}
+
+// SetSplitOutput is the same as InitSplitOutput, but with configurable writers. Calling SetSplitOutput again replace
+// the writers.
+func SetSplitOutput(stdout, stderr io.Writer) {
+	installHook()
+	f := logrus.StandardLogger().Formatter
+	if sf, ok := f.(*splitOutputFormatter); ok {
+		f = sf.next
+	}
+	logrus.SetOutput(io.Discard)
+	logrus.SetFormatter(&splitOutputFormatter{next: f, stdout: stdout, stderr: stderr})
+	updateEntryDroppers(logrus.StandardLogger().Formatter)
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *splitOutputFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b, err := f.next.Format(entry)
+	if err != nil || len(b) == 0 {
+		return nil, err
+	}
+
+	w := f.stdout
+	if entry.Level <= logrus.WarnLevel {
+		w = f.stderr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = w.Write(b)
+	return nil, err
+}
@@ -0,0 +1,49 @@
+package eal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// countingFormatter count the number of formatted entries.
+type countingFormatter struct {
+	logrus.JSONFormatter
+	n int
+}
+
+func (f *countingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.n++
+	return f.JSONFormatter.Format(entry)
+}
+
+func TestSetSplitOutput(t *testing.T) {
+	captureLog(t)
+	out := logrus.StandardLogger().Out
+	cf := &countingFormatter{}
+	logrus.SetFormatter(cf)
+	t.Cleanup(func() { logrus.SetOutput(out) })
+
+	var stdout, stderr bytes.Buffer
+	SetSplitOutput(&bytes.Buffer{}, &bytes.Buffer{})
+	SetSplitOutput(&stdout, &stderr)
+
+	logrus.Info("info message")
+	logrus.Warn("warn message")
+	logrus.Error("error message")
+
+	if got := stdout.String(); !strings.Contains(got, "info message") || strings.Contains(got, "warn message") {
+		t.Errorf("got stdout: %s, want only the info message", got)
+	}
+	if got := stderr.String(); strings.Count(got, "warn message") != 1 || strings.Count(got, "error message") != 1 || strings.Contains(got, "info message") {
+		t.Errorf("got stderr: %s, want the warn and error messages once", got)
+	}
+	if sf, ok := logrus.StandardLogger().Formatter.(*splitOutputFormatter); !ok || sf.next != cf {
+		t.Errorf("got formatter: %T, want a single split output formatter", logrus.StandardLogger().Formatter)
+	}
+	if cf.n != 3 {
+		t.Errorf("got %d formatted entries, want 3", cf.n)
+	}
+}
@@ -0,0 +1,57 @@
+package eal
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+type (
+	// timedReader measure the time spent reading the request body.
+	timedReader struct {
+		io.ReadCloser
+		elapsed time.Duration
+	}
+
+	// timedWriter measure the time spent writing the response.
+	timedWriter struct {
+		http.ResponseWriter
+		elapsed time.Duration
+	}
+)
+
+func (tr *timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := tr.ReadCloser.Read(p)
+	tr.elapsed += time.Since(start)
+	return n, err
+}
+
+func (tw *timedWriter) Write(b []byte) (int, error) {
+	start := time.Now()
+	n, err := tw.ResponseWriter.Write(b)
+	tw.elapsed += time.Since(start)
+	return n, err
+}
+
+// Unwrap return the wrapped http.ResponseWriter, used by http.ResponseController.
+func (tw *timedWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// setPhaseFields add the read_ms, handle_ms and write_ms fields, where handle_ms is the part of the total latency
+// that wasn't spent reading the request body or writing the response.
+func setPhaseFields(fields Fields, total time.Duration, tr *timedReader, tw *timedWriter) {
+	var read time.Duration
+	if tr != nil {
+		read = tr.elapsed
+	}
+	write := tw.elapsed
+	handle := total - read - write
+	if handle < 0 {
+		handle = 0
+	}
+	fields[FieldReadMs] = int64(read / time.Millisecond)
+	fields[FieldHandleMs] = int64(handle / time.Millisecond)
+	fields[FieldWriteMs] = int64(write / time.Millisecond)
+}
@@ -0,0 +1,66 @@
+package eal
+
+import (
+	"net/http"
+	"time"
+)
+
+type (
+	// fieldsContextKey is the key used to store the log fields in the request context.
+	fieldsContextKey struct{}
+
+	proxyTransport struct {
+		next http.RoundTripper
+	}
+)
+
+// ProxyTransport wrap a http.RoundTripper, and add upstream log fields to the access log entry written by
+// CreateLoggerMiddleware. It's intended to be used as the Transport in echo's middleware.ProxyConfig:
+//
+//	e.Use(eal.CreateLoggerMiddleware())
+//	e.Use(middleware.ProxyWithConfig(middleware.ProxyConfig{
+//		Balancer:  balancer,
+//		Transport: eal.ProxyTransport(nil),
+//	}))
+//
+// The following fields are logged: upstream_addr, upstream_status, upstream_latency_ms, upstream_retries and, if the
+// request to the upstream failed, upstream_error. If the request is retried, the fields describe the last attempt.
+// If next is nil, http.DefaultTransport is used.
+func ProxyTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &proxyTransport{next: next}
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rf := contextRequestFields(req.Context())
+	if rf == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	rf.update(func(fields Fields) {
+		if _, ok := fields[FieldUpstreamAddr]; ok {
+			retries, _ := fields[FieldUpstreamRetries].(int)
+			fields[FieldUpstreamRetries] = retries + 1
+			delete(fields, FieldUpstreamError)
+			delete(fields, FieldUpstreamStatus)
+		} else {
+			fields[FieldUpstreamRetries] = 0
+		}
+		fields[FieldUpstreamAddr] = req.URL.Host
+	})
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	latency := int64(time.Since(start) / time.Millisecond)
+	rf.update(func(fields Fields) {
+		fields[FieldUpstreamLatencyMs] = latency
+		if err != nil {
+			fields[FieldUpstreamError] = err.Error()
+		} else {
+			fields[FieldUpstreamStatus] = res.StatusCode
+		}
+	})
+	return res, err
+}
@@ -0,0 +1,62 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PanicError is the error that the logger middleware return for handlers that panic, when LoggerConfig.RecoverPanics
+// is set. It hold the panic value, and the stacktrace of the goroutine that panicked.
+type PanicError struct {
+	value interface{}
+	stack string
+}
+
+// Error return the panic value, formatted as "panic: <value>".
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.value)
+}
+
+// Unwrap return the panic value if it's an error.
+func (pe *PanicError) Unwrap() error {
+	err, _ := pe.value.(error)
+	return err
+}
+
+// Value return the value that was passed to panic.
+func (pe *PanicError) Value() interface{} {
+	return pe.value
+}
+
+// Stack return the stacktrace of the goroutine that panicked.
+func (pe *PanicError) Stack() string {
+	return pe.stack
+}
+
+// SetLogFields is used by Entry.WithError to populate log fields.
+func (pe *PanicError) SetLogFields(logFields map[string]interface{}) {
+	setStackLogFields(pe.stack, logFields)
+}
+
+// callHandler call the handler, and convert a panic to a PanicError if recoverPanics is set, other panics are resumed.
+// A http.ErrAbortHandler panic is always returned as an abortPanic error, also when recoverPanics isn't set, so that
+// the aborted request is logged. It must be resumed by the caller, since it's used to abort the response.
+func callHandler(next echo.HandlerFunc, c echo.Context, recoverPanics bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok && errors.Is(e, http.ErrAbortHandler) {
+				err = &abortPanic{value: r}
+				return
+			}
+			if !recoverPanics {
+				panic(r)
+			}
+			err = &PanicError{value: r, stack: filterStack(string(debug.Stack()), StackFilter)}
+		}
+	}()
+	return next(c)
+}
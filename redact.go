@@ -0,0 +1,120 @@
+package eal
+
+import (
+	"regexp"
+	"sync"
+)
+
+// RedactedValue replace the values, or the parts of values, that are masked by the redactors created by RedactKeys and
+// RedactValues.
+const RedactedValue = "[REDACTED]"
+
+// Patterns that can be used with RedactValues.
+var (
+	EmailPattern       = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	SSNPattern         = regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)
+	BearerTokenPattern = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`)
+)
+
+// Redactor is called for each log field before a log entry is written, with the field name and value, and return the
+// value that should be logged. The message of the entry is passed with the field name "msg".
+type Redactor func(key string, value interface{}) interface{}
+
+var (
+	redactorsMu sync.RWMutex
+	redactors   []Redactor
+)
+
+// RegisterRedactor add a redactor to the field processing pipeline, that run over the fields of all log entries
+// (Entry, the middlewares, errors logged by Trace etc.) before they are written. Redactors are run in the order they
+// were registered. Values in nested fields, like cancel_cause and group_errors, are also redacted.
+//
+//	eal.RegisterRedactor(eal.RedactKeys(regexp.MustCompile(`(?i)password|secret|token`)))
+//	eal.RegisterRedactor(eal.RedactValues(eal.EmailPattern, eal.BearerTokenPattern))
+func RegisterRedactor(r Redactor) {
+	redactorsMu.Lock()
+	redactors = append(redactors, r)
+	redactorsMu.Unlock()
+}
+
+// RedactKeys return a Redactor that mask the whole value of fields with a name that match the pattern.
+func RedactKeys(pattern *regexp.Regexp) Redactor {
+	return func(key string, value interface{}) interface{} {
+		if pattern.MatchString(key) {
+			return RedactedValue
+		}
+		return value
+	}
+}
+
+// RedactValues return a Redactor that mask the parts of string values (and error messages) that match any of the
+// patterns.
+func RedactValues(patterns ...*regexp.Regexp) Redactor {
+	return func(key string, value interface{}) interface{} {
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case error:
+			s = v.Error()
+		default:
+			return value
+		}
+		for _, p := range patterns {
+			s = p.ReplaceAllString(s, RedactedValue)
+		}
+		return s
+	}
+}
+
+// redact run the registered redactors over the fields and the message, and return the redacted message.
+func redact(fields map[string]interface{}, msg string) string {
+	redactorsMu.RLock()
+	defer redactorsMu.RUnlock()
+	if len(redactors) == 0 {
+		return msg
+	}
+
+	redactMap(fields)
+	for _, r := range redactors {
+		if s, ok := r("msg", msg).(string); ok {
+			msg = s
+		}
+	}
+	return msg
+}
+
+func redactMap(fields map[string]interface{}) {
+	for k, v := range fields {
+		fields[k] = redactValue(k, v)
+	}
+}
+
+// redactCopy return a redacted copy of a nested map, nested maps can be shared with other entries, or the caller, so
+// they are never redacted in place.
+func redactCopy(fields map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		c[k] = redactValue(k, v)
+	}
+	return c
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactCopy(v)
+	case Fields:
+		return Fields(redactCopy(v))
+	case []map[string]interface{}:
+		c := make([]map[string]interface{}, len(v))
+		for i, m := range v {
+			c[i] = redactCopy(m)
+		}
+		return c
+	}
+	for _, r := range redactors {
+		value = r(key, value)
+	}
+	return value
+}
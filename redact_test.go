@@ -0,0 +1,56 @@
+package eal
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestRegisterRedactor(t *testing.T) {
+	RegisterRedactor(RedactKeys(regexp.MustCompile(`(?i)password|token`)))
+	RegisterRedactor(RedactValues(EmailPattern, SSNPattern, BearerTokenPattern))
+	t.Cleanup(func() {
+		redactorsMu.Lock()
+		redactors = nil
+		redactorsMu.Unlock()
+	})
+	entries := captureLog(t)
+
+	cancelCause := map[string]interface{}{FieldErrorMessage: "ssn 123-45-6789 rejected"}
+	NewEntry().
+		WithFields(Fields{
+			"password":     "hunter2",
+			"api_token":    42,
+			"user":         "alice@example.com",
+			"header":       "Authorization: Bearer abc.def-123",
+			"cancel_cause": cancelCause,
+			"count":        3,
+		}).
+		WithError(errors.New("no user with email bob@example.com")).
+		Error("login failed for carol@example.com")
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	for k, want := range map[string]interface{}{
+		"password":        RedactedValue,
+		"api_token":       RedactedValue,
+		"user":            RedactedValue,
+		"header":          "Authorization: " + RedactedValue,
+		FieldErrorMessage: "no user with email " + RedactedValue,
+		"msg":             "login failed for " + RedactedValue,
+		"count":           float64(3),
+	} {
+		if logged[0][k] != want {
+			t.Errorf("got %s: %v, want: %v", k, logged[0][k], want)
+		}
+	}
+	cause, _ := logged[0]["cancel_cause"].(map[string]interface{})
+	if want := "ssn " + RedactedValue + " rejected"; cause[FieldErrorMessage] != want {
+		t.Errorf("got nested error_message: %v, want: %s", cause[FieldErrorMessage], want)
+	}
+	if cancelCause[FieldErrorMessage] != "ssn 123-45-6789 rejected" {
+		t.Errorf("got caller's nested error_message: %v, want it unchanged", cancelCause[FieldErrorMessage])
+	}
+}
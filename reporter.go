@@ -0,0 +1,94 @@
+package eal
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fieldReportError is the entry field that hold the error added with Entry.WithError, until the entry is written and
+// the error is reported. The field is removed by the entry hook, and isn't logged.
+const fieldReportError = "_eal_report_error"
+
+// ErrorReporter is called with server errors and their log fields, see RegisterErrorReporter. The fields must not be
+// modified, and are not used by eal after the call.
+type ErrorReporter func(err error, fields Fields)
+
+var (
+	reportersMu sync.RWMutex
+	reporters   []ErrorReporter
+)
+
+// RegisterErrorReporter add a reporter that is called with server errors, for example to send them to an error
+// tracking service like Sentry (see the ealsentry package). Reporters are called by:
+//   - the logger middlewares, for requests that failed with a 5xx status, after the access log entry is written, with
+//     the fields of the access log entry.
+//   - the entry hook, for entries with an error added by Entry.WithError, that are written with error level or above,
+//     and for errors that aren't echo.HTTPErrors with a status below 500. The reporter get the fields of the written
+//     entry.
+//
+// Errors that declare a level below error level (see RegisterErrorLevel), and requests that the client aborted, are
+// not reported. An error that contain an ErrorStackTrace (see Trace) is only reported once, so a handler error that
+// is logged by the handler, and then returned to the logger middleware, isn't reported twice. Reporters are called
+// synchronously, and should hand off slow work to another goroutine.
+func RegisterErrorReporter(r ErrorReporter) {
+	reportersMu.Lock()
+	reporters = append(reporters, r)
+	reportersMu.Unlock()
+}
+
+// reportError call the registered reporters with a copy of the fields, unless the ErrorStackTrace of the error have
+// already been reported.
+func reportError(err error, fields map[string]interface{}) {
+	reportersMu.RLock()
+	rs := reporters
+	reportersMu.RUnlock()
+	if len(rs) == 0 {
+		return
+	}
+	if st, ok := GetErrorStackTrace(err); ok && !st.reported.CompareAndSwap(false, true) {
+		return
+	}
+
+	for _, r := range rs {
+		f := make(Fields, len(fields))
+		for k, v := range fields {
+			f[k] = v
+		}
+		r(err, f)
+	}
+}
+
+// takeReportError remove the error that was added by Entry.WithError from the fields of an entry, and return it.
+func takeReportError(data logrus.Fields) error {
+	err, ok := data[fieldReportError].(error)
+	if ok {
+		delete(data, fieldReportError)
+	}
+	return err
+}
+
+// reportEntryError report the error of an entry that is written, see RegisterErrorReporter.
+func reportEntryError(entry *logrus.Entry, err error) {
+	if err != nil && Level(entry.Level) <= ErrorLevel && isReportable(err) {
+		reportError(err, entry.Data)
+	}
+}
+
+// isReportable report if the error is a server error that should be reported, see RegisterErrorReporter.
+func isReportable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if he := GetInnerHTTPError(err); he != nil && he.Code < http.StatusInternalServerError {
+		return false
+	}
+	if level, ok := codeSeverity(err); ok && level > ErrorLevel {
+		return false
+	}
+	if level, ok := errorLevel(err); ok && level > ErrorLevel {
+		return false
+	}
+	return true
+}
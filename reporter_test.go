@@ -0,0 +1,74 @@
+package eal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestErrorReporter(t *testing.T) {
+	captureLog(t)
+	var reported []Fields
+	RegisterErrorReporter(func(err error, fields Fields) {
+		reported = append(reported, fields)
+	})
+	t.Cleanup(func() {
+		reportersMu.Lock()
+		reporters = nil
+		reportersMu.Unlock()
+	})
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server error", err: errors.New("db down"), want: true},
+		{name: "http server error", err: NewHTTPError(errors.New("db down"), http.StatusBadGateway), want: true},
+		{name: "client error", err: NewHTTPError(errors.New("invalid id"), http.StatusBadRequest), want: false},
+		{name: "declared level", err: NewHTTPError(leveledError{}, http.StatusServiceUnavailable), want: false},
+		{name: "no error", err: nil, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reported = nil
+			serve(CreateLoggerMiddleware(), httptest.NewRequest(http.MethodGet, "/users/1", nil), func(c echo.Context) error {
+				return tt.err
+			})
+			if got := len(reported) == 1; got != tt.want {
+				t.Fatalf("got %d reports, want reported: %v", len(reported), tt.want)
+			}
+			if tt.want && (reported[0][FieldStatus] == nil || reported[0][FieldRequestID] == nil) {
+				t.Errorf("got fields: %v, want the access log fields", reported[0])
+			}
+		})
+	}
+
+	t.Run("WithError", func(t *testing.T) {
+		reported = nil
+		NewEntry().WithFields(Fields{"tenant": "acme"}).WithError(errors.New("db down")).Error("reindex failed")
+		NewEntry().WithError(NewHTTPError(errors.New("not found"), http.StatusNotFound)).Error("lookup failed")
+		NewEntry().WithError(errors.New("cache miss")).Debug("lookup failed")
+		NewEntry().WithError(errors.New("retrying")).Warn("lookup failed")
+		if len(reported) != 1 || reported[0]["tenant"] != "acme" || reported[0][FieldErrorMessage] == nil {
+			t.Fatalf("got reports: %v, want one with the entry fields", reported)
+		}
+		if _, ok := reported[0][fieldReportError]; ok {
+			t.Errorf("got fields: %v, want them without %s", reported[0], fieldReportError)
+		}
+	})
+
+	t.Run("logged and returned", func(t *testing.T) {
+		reported = nil
+		serve(CreateLoggerMiddleware(), httptest.NewRequest(http.MethodGet, "/users/1", nil), func(c echo.Context) error {
+			err := Trace(errors.New("db down"))
+			NewEntry().WithCtx(c).WithError(err).Error("load user failed")
+			return Internal(err)
+		})
+		if len(reported) != 1 {
+			t.Errorf("got %d reports: %v, want 1", len(reported), reported)
+		}
+	})
+}
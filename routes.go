@@ -0,0 +1,52 @@
+package eal
+
+import "sync"
+
+// RouteOptions override how the access log entries of a route are written, see SetRouteOptions.
+type RouteOptions struct {
+	// Level replace the level of access log entries that would have been written with info level, for example to
+	// demote successful requests to health and metrics endpoints to debug level. Entries of failed requests keep their
+	// level. A nil Level leave the level unchanged, see Level.Ptr.
+	Level *Level
+
+	// SampleRate log every SampleRate:th successful request to the route, and override the rates of
+	// LoggerConfig.Sampling. The zero value leave the sampling unchanged, and 1 disable sampling for the route.
+	SampleRate int
+
+	// Skip disable the access log entries of successful requests to the route. Failed requests are still logged.
+	Skip bool
+
+	// Fields are added to the access log entries of the route, if the entry don't already have the field. They take
+	// precedence over the global fields, but not over fields set in the request context or on the entry.
+	Fields Fields
+}
+
+var (
+	routeOptionsMu sync.RWMutex
+	routeOptions   = map[string]RouteOptions{}
+)
+
+// SetRouteOptions set the access log options of a route, by the echo route path (the router_path field), for example
+// "/users/:id". The options apply to the access log entries written by CreateLoggerMiddleware.
+//
+//	eal.SetRouteOptions("/healthz", eal.RouteOptions{Level: eal.DebugLevel.Ptr()})
+//	eal.SetRouteOptions("/metrics", eal.RouteOptions{Skip: true})
+func SetRouteOptions(path string, opts RouteOptions) {
+	routeOptionsMu.Lock()
+	routeOptions[path] = opts
+	routeOptionsMu.Unlock()
+}
+
+// RemoveRouteOptions remove the access log options of a route, set by SetRouteOptions.
+func RemoveRouteOptions(path string) {
+	routeOptionsMu.Lock()
+	delete(routeOptions, path)
+	routeOptionsMu.Unlock()
+}
+
+func routeOptionsFor(path string) (RouteOptions, bool) {
+	routeOptionsMu.RLock()
+	defer routeOptionsMu.RUnlock()
+	ro, ok := routeOptions[path]
+	return ro, ok
+}
@@ -0,0 +1,94 @@
+package eal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetRouteOptions(t *testing.T) {
+	SetRouteOptions("/healthz", RouteOptions{Level: DebugLevel.Ptr()})
+	SetRouteOptions("/metrics", RouteOptions{Skip: true})
+	SetRouteOptions("/users", RouteOptions{SampleRate: 4})
+	t.Cleanup(func() {
+		for _, path := range []string{"/healthz", "/metrics", "/users"} {
+			RemoveRouteOptions(path)
+		}
+	})
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	t.Cleanup(func() { logrus.SetLevel(level) })
+	entries := captureLog(t)
+
+	e := echo.New()
+	e.Use(CreateLoggerMiddleware())
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/healthz", ok)
+	e.GET("/metrics", ok)
+	e.GET("/users", ok)
+	e.GET("/orders", ok)
+	e.GET("/fail", func(c echo.Context) error { return echo.ErrServiceUnavailable })
+	SetRouteOptions("/fail", RouteOptions{Level: DebugLevel.Ptr(), Skip: true})
+	t.Cleanup(func() { RemoveRouteOptions("/fail") })
+
+	for _, path := range []string{"/healthz", "/metrics", "/users", "/orders", "/fail"} {
+		for i := 0; i < 8; i++ {
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+	}
+
+	counts := map[string]int{}
+	for _, entry := range entries() {
+		path, _ := entry[FieldRouterPath].(string)
+		counts[path]++
+		want := "info"
+		switch path {
+		case "/healthz":
+			want = "debug"
+		case "/fail":
+			want = "error"
+		}
+		if entry["level"] != want {
+			t.Errorf("got level %v for %s, want %s", entry["level"], path, want)
+		}
+	}
+	for path, want := range map[string]int{"/healthz": 8, "/metrics": 0, "/users": 2, "/orders": 8, "/fail": 8} {
+		if counts[path] != want {
+			t.Errorf("got %d entries for %s, want %d", counts[path], path, want)
+		}
+	}
+}
+
+func TestRouteOptionsFields(t *testing.T) {
+	SetGlobalFields(Fields{"tenant": "global", "service": "users"})
+	t.Cleanup(func() { SetGlobalFields(nil) })
+	SetRouteOptions("/users/:id", RouteOptions{Fields: Fields{"tenant": "route", "team": "accounts", "service": "route"}})
+	t.Cleanup(func() { RemoveRouteOptions("/users/:id") })
+	entries := captureLog(t)
+
+	e := echo.New()
+	e.Use(CreateLoggerMiddleware())
+	e.GET("/users/:id", func(c echo.Context) error {
+		AddContextFields(c, Fields{"tenant": "context"})
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/orders", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	got := entries()
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for k, want := range map[string]interface{}{"tenant": "context", "team": "accounts", "service": "route"} {
+		if got[0][k] != want {
+			t.Errorf("got %s %v for /users/:id, want %v", k, got[0][k], want)
+		}
+	}
+	if _, ok := got[1]["team"]; ok || got[1]["service"] != "users" {
+		t.Errorf("got route fields in the /orders entry: %v", got[1])
+	}
+}
@@ -0,0 +1,154 @@
+package eal
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// SamplingConfig configure sampling of the access log entries of successful requests, see LoggerConfig.Sampling.
+	// Requests that fail, with an error or a 4xx/5xx status, are always logged.
+	SamplingConfig struct {
+		// Rate log every Rate:th successful (2xx/3xx) request. All requests are logged if Rate is 0 or 1.
+		Rate int
+
+		// PathRates override Rate for specific routes, keyed by the router path, for example "/users/:id".
+		PathRates map[string]int
+
+		// Coordinator share the sampling decisions between instances, so that requests with the same request ID (like
+		// retries that are load balanced to different instances) are either all logged or all dropped. The decision of
+		// the instance that handle the first request is used. If the Coordinator fail, the local decision is used.
+		//
+		// The Coordinator is called in a new goroutine after the request have been handled, so that the response isn't
+		// delayed, and the access log entry is written when the decision have been made. If more than
+		// maxPendingSamplingDecisions decisions are pending, the local decision is used. Call FlushSampling when the
+		// server is shut down, to wait for the pending access log entries.
+		Coordinator SamplingCoordinator
+	}
+
+	// SamplingCoordinator store sampling decisions that are shared between instances, see SamplingConfig.Coordinator.
+	// The ealredis module provide a SamplingCoordinator that store the decisions in Redis.
+	SamplingCoordinator interface {
+		// Decide return the decision that is stored for the key. If no decision is stored, the proposed decision is
+		// stored and returned.
+		Decide(ctx context.Context, key string, sampled bool) (bool, error)
+	}
+
+	// sampler count the successful requests per route.
+	sampler struct {
+		config   SamplingConfig
+		counters sync.Map
+		pending  chan struct{}
+	}
+
+	// pendingWrites count the access log entries that wait for a SamplingCoordinator decision, see FlushSampling.
+	pendingWrites struct {
+		mu   sync.Mutex
+		n    int
+		idle chan struct{} // closed when n reach zero
+	}
+)
+
+var samplingWrites pendingWrites
+
+// maxPendingSamplingDecisions limit the number of goroutines that wait for a SamplingCoordinator decision.
+const maxPendingSamplingDecisions = 1024
+
+func newSampler(config SamplingConfig) *sampler {
+	return &sampler{config: config, pending: make(chan struct{}, maxPendingSamplingDecisions)}
+}
+
+// sample report if the access log entry of the request should be written, and the sample rate that should be logged
+// in the sample_rate field (0 if the request isn't sampled). The SampleRate of the RouteOptions of the route take
+// precedence over the SamplingConfig. The last result report if the decision should be shared with the Coordinator,
+// see share.
+func (s *sampler) sample(path, requestID string, status int, failed bool) (bool, int, bool) {
+	if failed || status >= 400 {
+		return true, 0, false
+	}
+
+	rate := s.config.Rate
+	if r, ok := s.config.PathRates[path]; ok {
+		rate = r
+	}
+	if ro, ok := routeOptionsFor(path); ok {
+		if ro.Skip {
+			return false, 0, false
+		}
+		if ro.SampleRate > 0 {
+			rate = ro.SampleRate
+		}
+	}
+	if rate <= 1 {
+		return true, 0, false
+	}
+
+	c, _ := s.counters.LoadOrStore(path, new(atomic.Uint64))
+	n := c.(*atomic.Uint64).Add(1)
+	return (n-1)%uint64(rate) == 0, rate, s.config.Coordinator != nil && requestID != ""
+}
+
+// share propose the local decision to the Coordinator in a new goroutine, and call done with the shared decision, or
+// with the local decision if the Coordinator fail. It return false, without calling done, if too many decisions are
+// pending, in which case the local decision should be used.
+func (s *sampler) share(ctx context.Context, requestID string, sampled bool, done func(sampled bool)) bool {
+	select {
+	case s.pending <- struct{}{}:
+	default:
+		return false
+	}
+	samplingWrites.add()
+	go func() {
+		defer func() {
+			<-s.pending
+			samplingWrites.done()
+		}()
+		if shared, err := s.config.Coordinator.Decide(ctx, requestID, sampled); err == nil {
+			sampled = shared
+		}
+		done(sampled)
+	}()
+	return true
+}
+
+// FlushSampling wait until the access log entries that wait for a SamplingCoordinator decision have been written, or
+// until ctx is done. It should be called when the server is shut down, after the requests have been handled, so that
+// the last access log entries aren't lost:
+//
+//	_ = e.Shutdown(ctx)
+//	_ = eal.FlushSampling(ctx)
+func FlushSampling(ctx context.Context) error {
+	samplingWrites.mu.Lock()
+	if samplingWrites.n == 0 {
+		samplingWrites.mu.Unlock()
+		return nil
+	}
+	idle := samplingWrites.idle
+	samplingWrites.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *pendingWrites) add() {
+	p.mu.Lock()
+	if p.n == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.n++
+	p.mu.Unlock()
+}
+
+func (p *pendingWrites) done() {
+	p.mu.Lock()
+	p.n--
+	if p.n == 0 {
+		close(p.idle)
+	}
+	p.mu.Unlock()
+}
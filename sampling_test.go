@@ -0,0 +1,141 @@
+package eal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestSampling(t *testing.T) {
+	entries := captureLog(t)
+	e := echo.New()
+	e.Use(CreateLoggerMiddlewareWithConfig(LoggerConfig{Sampling: SamplingConfig{Rate: 5, PathRates: map[string]int{"/health": 10, "/orders": 1}}}))
+	e.GET("/users", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/health", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/orders", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/fail", func(c echo.Context) error { return echo.ErrNotFound })
+
+	for _, path := range []string{"/users", "/health", "/orders", "/fail"} {
+		for i := 0; i < 20; i++ {
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+	}
+
+	counts := map[string]int{}
+	for _, entry := range entries() {
+		path, _ := entry[FieldRouterPath].(string)
+		counts[path]++
+		if rate, ok := entry[FieldSampleRate]; ok && (path == "/orders" || path == "/fail") {
+			t.Errorf("got %s: %v for %s, want no sample rate", FieldSampleRate, rate, path)
+		}
+	}
+	for path, want := range map[string]int{"/users": 4, "/health": 2, "/orders": 20, "/fail": 20} {
+		if counts[path] != want {
+			t.Errorf("got %d entries for %s, want %d", counts[path], path, want)
+		}
+	}
+}
+
+// memoryCoordinator is a SamplingCoordinator that store the decisions in memory.
+type memoryCoordinator struct {
+	mu        sync.Mutex
+	decisions map[string]bool
+}
+
+func (mc *memoryCoordinator) Decide(_ context.Context, key string, sampled bool) (bool, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if d, ok := mc.decisions[key]; ok {
+		return d, nil
+	}
+	mc.decisions[key] = sampled
+	return sampled, nil
+}
+
+func TestSamplingCoordinator(t *testing.T) {
+	emitter := &recordingEmitter{}
+	coordinator := &memoryCoordinator{decisions: map[string]bool{}}
+
+	// Two instances, where the second have handled one more request
+	var instances []*echo.Echo
+	for i := 0; i < 2; i++ {
+		e := echo.New()
+		e.Use(NewLoggerMiddleware(Deps{Emitter: emitter}, LoggerConfig{Sampling: SamplingConfig{Rate: 2, Coordinator: coordinator}}))
+		e.GET("/users", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+		instances = append(instances, e)
+	}
+	warmup := httptest.NewRequest(http.MethodGet, "/users", nil)
+	warmup.Header.Set(echo.HeaderXRequestID, "warmup")
+	instances[1].ServeHTTP(httptest.NewRecorder(), warmup)
+	emitter.waitRecords(t, 1)
+
+	// The decisions are made after the responses have been sent, so wait for the entries of the first instance before
+	// the second instance handle the same requests
+	for i, e := range instances {
+		for i := 0; i < 10; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set(echo.HeaderXRequestID, fmt.Sprintf("req-%d", i))
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		emitter.waitRecords(t, 1+5*(i+1))
+	}
+
+	logged := map[string]int{}
+	for _, r := range emitter.waitRecords(t, 11) {
+		if id, _ := r.Fields[FieldRequestID].(string); id != "warmup" {
+			logged[id]++
+		}
+	}
+	if len(logged) != 5 {
+		t.Errorf("got %d sampled request IDs, want 5: %v", len(logged), logged)
+	}
+	for id, n := range logged {
+		if n != 2 {
+			t.Errorf("got %d entries for %s, want one per instance", n, id)
+		}
+	}
+}
+
+// blockingCoordinator wait for release before it return the proposed decision.
+type blockingCoordinator struct {
+	release chan struct{}
+}
+
+func (bc blockingCoordinator) Decide(_ context.Context, _ string, sampled bool) (bool, error) {
+	<-bc.release
+	return sampled, nil
+}
+
+func TestFlushSampling(t *testing.T) {
+	emitter := &recordingEmitter{}
+	coordinator := blockingCoordinator{release: make(chan struct{})}
+	e := echo.New()
+	e.Use(NewLoggerMiddleware(Deps{Emitter: emitter}, LoggerConfig{Sampling: SamplingConfig{Rate: 2, Coordinator: coordinator}}))
+	e.GET("/users", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := FlushSampling(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the flush to time out while the decision is pending", err)
+	}
+
+	close(coordinator.release)
+	if err := FlushSampling(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+	if len(emitter.records) != 1 {
+		t.Errorf("got %d records after the flush, want 1", len(emitter.records))
+	}
+}
@@ -0,0 +1,212 @@
+package eal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SelfTestMessage is the message of the synthetic log entry written by SelfTest.
+const SelfTestMessage = "eal_self_test"
+
+type (
+	// Problem is a problem with the logging configuration, found by ValidateConfig or SelfTest.
+	Problem struct {
+		// Component is the part of the configuration that have the problem, for example "LoggerConfig.LatencyBuckets"
+		// or "sink".
+		Component string
+
+		// Message describe the problem.
+		Message string
+	}
+
+	// ConfigError is returned by SelfTest when problems are found.
+	ConfigError struct {
+		Problems []Problem
+	}
+)
+
+// String return the problem formatted as "<component>: <message>".
+func (p Problem) String() string {
+	return p.Component + ": " + p.Message
+}
+
+// Error return the problems, separated by semicolons.
+func (ce *ConfigError) Error() string {
+	problems := make([]string, len(ce.Problems))
+	for i, p := range ce.Problems {
+		problems[i] = p.String()
+	}
+	return "invalid logging configuration: " + strings.Join(problems, "; ")
+}
+
+// ValidateConfig check the middleware config, and the global eal settings (route options and the Alerter), for
+// values that are invalid or that probably is a mistake. It doesn't write any log entries, see SelfTest.
+func ValidateConfig(cfg LoggerConfig) []Problem {
+	var problems []Problem
+	add := func(component, format string, args ...interface{}) {
+		problems = append(problems, Problem{Component: component, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var prev time.Duration
+	for i, b := range cfg.LatencyBuckets {
+		if b <= prev {
+			add("LoggerConfig.LatencyBuckets", "bucket %d (%s) must be positive and larger than the previous bucket", i, b)
+		}
+		prev = b
+	}
+	if cfg.LatencyUnit < LatencyMilliseconds || cfg.LatencyUnit > LatencyNanoseconds {
+		add("LoggerConfig.LatencyUnit", "%d is not a valid latency unit", cfg.LatencyUnit)
+	}
+	if cfg.AllocSampleRate < 0 || cfg.AllocSampleRate > 1 {
+		add("LoggerConfig.AllocSampleRate", "%v is not a ratio between 0 and 1", cfg.AllocSampleRate)
+	}
+	if cfg.Sampling.Rate < 0 {
+		add("LoggerConfig.Sampling.Rate", "%d is negative", cfg.Sampling.Rate)
+	}
+	for path, rate := range cfg.Sampling.PathRates {
+		if rate < 0 {
+			add("LoggerConfig.Sampling.PathRates", "the rate of %s (%d) is negative", path, rate)
+		}
+	}
+	renamed := make(map[string]string, len(cfg.FieldNames))
+	for field, name := range cfg.FieldNames {
+		if other, ok := renamed[name]; ok {
+			if other > field {
+				field, other = other, field
+			}
+			add("LoggerConfig.FieldNames", "both %s and %s are renamed to %s", other, field, name)
+		}
+		renamed[name] = field
+	}
+	problems = append(problems, validateMessageTemplate(cfg.MessageTemplate)...)
+	if cfg.DevErrorResponses && !devEnvironment.Load() {
+		add("LoggerConfig.DevErrorResponses", "is ignored, since SetDevEnvironment(true) haven't been called")
+	}
+
+	routeOptionsMu.RLock()
+	for path, ro := range routeOptions {
+		if ro.SampleRate < 0 {
+			add("RouteOptions", "the sample rate of %s (%d) is negative", path, ro.SampleRate)
+		}
+		if ro.Level != nil && *ro.Level > TraceLevel {
+			add("RouteOptions", "the level of %s (%d) is not a valid level", path, *ro.Level)
+		}
+	}
+	routeOptionsMu.RUnlock()
+
+	alerterMu.RLock()
+	a := alerter
+	alerterMu.RUnlock()
+	if a != nil {
+		if u, err := url.Parse(a.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("Alerter.WebhookURL", "%q is not an http or https URL", a.WebhookURL)
+		}
+		if a.Threshold < 0 {
+			add("Alerter.Threshold", "%d is negative", a.Threshold)
+		}
+	}
+
+	return problems
+}
+
+// validateMessageTemplate check that the braces of the template are balanced, and that the field names in the
+// template are known field names if StrictFieldNames is enabled.
+func validateMessageTemplate(tmpl string) []Problem {
+	var problems []Problem
+	for rest := tmpl; ; {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start+1:], '}')
+		if rest[start] == '}' || end < 0 || strings.ContainsRune(rest[start+1:start+1+end], '{') {
+			problems = append(problems, Problem{Component: "LoggerConfig.MessageTemplate", Message: fmt.Sprintf("unbalanced braces in %q", tmpl)})
+			break
+		}
+		field := rest[start+1 : start+1+end]
+		if field == "" {
+			problems = append(problems, Problem{Component: "LoggerConfig.MessageTemplate", Message: fmt.Sprintf("empty field name in %q", tmpl)})
+		} else if StrictFieldNames && len(UnknownFields(map[string]interface{}{field: nil})) > 0 {
+			problems = append(problems, Problem{Component: "LoggerConfig.MessageTemplate", Message: fmt.Sprintf("unknown field name %q", field)})
+		}
+		rest = rest[start+1+end+1:]
+	}
+	return problems
+}
+
+// SelfTest validate the config with ValidateConfig, and write a synthetic info entry, with the message
+// "eal_self_test", through the registered redactors, the logrus hooks (which include the Sink set by SetSink), the
+// formatter and the output of the standard logrus logger. It's intended to be called at startup, to fail fast when
+// the logging is misconfigured, for example when a log shipping endpoint is unreachable:
+//
+//	if err := eal.SelfTest(config); err != nil {
+//	  log.Fatal(err)
+//	}
+//
+// A *ConfigError that list all problems is returned if any problems are found.
+func SelfTest(cfg LoggerConfig) error {
+	problems := ValidateConfig(cfg)
+	check := func(component string, f func() error) {
+		defer func() {
+			if r := recover(); r != nil {
+				problems = append(problems, Problem{Component: component, Message: fmt.Sprintf("panic: %v", r)})
+			}
+		}()
+		if err := f(); err != nil {
+			problems = append(problems, Problem{Component: component, Message: err.Error()})
+		}
+	}
+
+	redactorsMu.RLock()
+	rs := redactors
+	redactorsMu.RUnlock()
+	redactorsOK := true
+	for i, r := range rs {
+		before := len(problems)
+		check(fmt.Sprintf("redactor %d", i), func() error {
+			r(FieldRequestID, "self-test")
+			r("msg", SelfTestMessage)
+			return nil
+		})
+		redactorsOK = redactorsOK && len(problems) == before
+	}
+
+	logger := logrus.StandardLogger()
+	entry := logrus.NewEntry(logger).WithFields(logrus.Fields{FieldRequestID: "self-test"})
+	entry.Time = time.Now()
+	entry.Level = logrus.InfoLevel
+	entry.Message = SelfTestMessage
+
+	if redactorsOK {
+		// The entry processing run the redactors, so a panicking redactor would fail all hooks that process entries
+		for _, h := range logger.Hooks[logrus.InfoLevel] {
+			component := fmt.Sprintf("hook %T", h)
+			if h == hook {
+				component = "sink"
+			}
+			check(component, func() error {
+				return h.Fire(entry)
+			})
+		}
+	}
+	var formatted []byte
+	check("formatter", func() (err error) {
+		formatted, err = logger.Formatter.Format(entry)
+		return err
+	})
+	if formatted != nil {
+		check("output", func() error {
+			_, err := logger.Out.Write(formatted)
+			return err
+		})
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
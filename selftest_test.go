@@ -0,0 +1,92 @@
+package eal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type failingSink struct{}
+
+func (failingSink) Write(Record) error { return errors.New("connection refused") }
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestValidateConfig(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  LoggerConfig
+		want []string
+	}{
+		{name: "valid", cfg: LoggerConfig{LatencyBuckets: []time.Duration{10 * time.Millisecond, time.Second}, MessageTemplate: "{method} {uri}"}},
+		{name: "unordered buckets", cfg: LoggerConfig{LatencyBuckets: []time.Duration{time.Second, 10 * time.Millisecond}}, want: []string{"LoggerConfig.LatencyBuckets"}},
+		{name: "field names", cfg: LoggerConfig{FieldNames: map[string]string{FieldStatus: "code", FieldHTTPStatus: "code"}}, want: []string{"LoggerConfig.FieldNames"}},
+		{name: "latency unit", cfg: LoggerConfig{LatencyUnit: 7}, want: []string{"LoggerConfig.LatencyUnit"}},
+		{name: "alloc sample rate", cfg: LoggerConfig{AllocSampleRate: 2}, want: []string{"LoggerConfig.AllocSampleRate"}},
+		{name: "negative sample rates", cfg: LoggerConfig{Sampling: SamplingConfig{Rate: -1, PathRates: map[string]int{"/a": -2}}}, want: []string{"LoggerConfig.Sampling.Rate", "LoggerConfig.Sampling.PathRates"}},
+		{name: "unbalanced template", cfg: LoggerConfig{MessageTemplate: "{method} {uri"}, want: []string{"LoggerConfig.MessageTemplate"}},
+		{name: "empty template field", cfg: LoggerConfig{MessageTemplate: "{} {status}"}, want: []string{"LoggerConfig.MessageTemplate"}},
+		{name: "dev responses outside dev environment", cfg: LoggerConfig{DevErrorResponses: true}, want: []string{"LoggerConfig.DevErrorResponses"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range ValidateConfig(tt.cfg) {
+				got = append(got, p.Component)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got problems: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	entries := captureLog(t)
+	if err := SelfTest(LoggerConfig{}); err != nil {
+		t.Fatalf("got error: %v, want nil", err)
+	}
+	if logged := entries(); len(logged) != 1 || logged[0]["msg"] != SelfTestMessage {
+		t.Errorf("got entries: %v, want the self test entry", logged)
+	}
+
+	RegisterRedactor(func(key string, value interface{}) interface{} { panic("bad redactor") })
+	hook.mu.Lock()
+	hook.sink = failingSink{}
+	hook.mu.Unlock()
+	logrus.SetOutput(failingWriter{})
+	t.Cleanup(func() {
+		redactorsMu.Lock()
+		redactors = redactors[:len(redactors)-1]
+		redactorsMu.Unlock()
+		hook.mu.Lock()
+		hook.sink = nil
+		hook.mu.Unlock()
+	})
+
+	var ce *ConfigError
+	if err := SelfTest(LoggerConfig{AllocSampleRate: -1}); !errors.As(err, &ce) {
+		t.Fatalf("got error: %v, want a ConfigError", err)
+	}
+	var got []string
+	for _, p := range ce.Problems {
+		got = append(got, p.String())
+	}
+	want := []string{"LoggerConfig.AllocSampleRate: -1 is not a ratio between 0 and 1", "redactor 0: panic: bad redactor", "output: disk full"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Without the panicking redactor, the sink is tested
+	redactorsMu.Lock()
+	redactors = redactors[:len(redactors)-1]
+	redactors = append(redactors, func(key string, value interface{}) interface{} { return value })
+	redactorsMu.Unlock()
+	if err := SelfTest(LoggerConfig{}); err == nil || !strings.Contains(err.Error(), "sink: connection refused") {
+		t.Errorf("got error: %v, want the sink problem", err)
+	}
+}
@@ -0,0 +1,151 @@
+package eal
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Statistics hold counters that can be used to reconcile the log entries written by this instance with the entries
+// received by a downstream consumer, see Stats.
+type Statistics struct {
+	// InstanceID is the ID logged in the instance_id field.
+	InstanceID string `json:"instance_id"`
+
+	// Seq is the sequence number of the last log entry that was written with a seq field.
+	Seq uint64 `json:"seq"`
+}
+
+// LogSequence control if all log entries should have a seq and an instance_id field. The seq field is a monotonically
+// increasing sequence number per instance, and instance_id is a random ID that is generated when the process start
+// (see SetInstanceID). This make it possible for downstream consumers of shipped logs to detect gaps and duplicates.
+// Entries that are dropped by SetLogBudget, SetErrorDedup or the level of the Sink don't get a sequence number, so a
+// gap always mean that a written entry was lost.
+var LogSequence bool
+
+// maxPendingDecisions is the maximum number of drop decisions that can wait for their entries to be formatted. The
+// decisions are forgotten if there are more, which only happen if the formatter is replaced while entries are logged.
+const maxPendingDecisions = 1024
+
+type (
+	// entryDropper is implemented by the formatters that drop log entries, see SetLogBudget and SetErrorDedup. The
+	// decision is made by the entry hook, before the entry get a sequence number, and is remembered until the entry
+	// is formatted.
+	entryDropper interface {
+		// dropEntry decide if the entry is dropped. If dropped is set, an earlier formatter have already dropped the
+		// entry, and the entry must be kept.
+		dropEntry(entry *logrus.Entry, dropped bool) bool
+	}
+
+	// dropDecisions hold the drop decisions of a formatter, until the entries are formatted.
+	dropDecisions map[*logrus.Entry]bool
+)
+
+var (
+	seq        atomic.Uint64
+	instanceMu sync.RWMutex
+	instanceID = uuid.New().String()
+
+	entryDroppers atomic.Pointer[[]entryDropper]
+)
+
+// SetInstanceID replace the random instance ID, for example with a pod or host name.
+func SetInstanceID(id string) {
+	instanceMu.Lock()
+	instanceID = id
+	instanceMu.Unlock()
+}
+
+// Stats return the current instance ID and sequence number.
+func Stats() Statistics {
+	instanceMu.RLock()
+	defer instanceMu.RUnlock()
+	return Statistics{InstanceID: instanceID, Seq: seq.Load()}
+}
+
+// processEntry is called for each log entry written through the standard logrus logger, before the entry is
+// formatted and written. Entries that will be dropped by the formatter don't get a sequence number.
+func processEntry(entry *logrus.Entry, dropped bool) {
+	addGlobalFields(entry.Data)
+	addBuildInfo(entry.Data)
+	entry.Message = redact(entry.Data, entry.Message)
+	if StrictFieldNames {
+		if unknown := UnknownFields(entry.Data); len(unknown) > 0 {
+			entry.Data[FieldUnknownFields] = unknown
+		}
+	}
+	addEventID(entry)
+	if !dropped {
+		sequenceEntry(entry.Data)
+	}
+	observeAlert(entry)
+}
+
+// sequenceEntry add the instance_id and seq fields to an entry that is written, if LogSequence is enabled.
+func sequenceEntry(data map[string]interface{}) {
+	if !LogSequence {
+		return
+	}
+	instanceMu.RLock()
+	data[FieldInstanceID] = instanceID
+	instanceMu.RUnlock()
+	data[FieldSeq] = seq.Add(1)
+}
+
+// updateEntryDroppers find the formatters that drop entries in the formatter chain of the standard logrus logger, it
+// is called when the chain is changed by SetLogBudget, SetErrorDedup or SetSplitOutput.
+func updateEntryDroppers(f logrus.Formatter) {
+	var droppers []entryDropper
+	for {
+		switch df := f.(type) {
+		case *budgetFormatter:
+			droppers = append([]entryDropper{df}, droppers...)
+			f = df.next
+			continue
+		case *dedupFormatter:
+			droppers = append([]entryDropper{df}, droppers...)
+			f = df.next
+			continue
+		case *splitOutputFormatter:
+			f = df.next
+			continue
+		}
+		break
+	}
+	entryDroppers.Store(&droppers)
+}
+
+// entryDropped ask the formatters that drop entries if the entry will be dropped. The innermost formatter decide
+// first, since it format the entry first.
+func entryDropped(entry *logrus.Entry) bool {
+	droppers := entryDroppers.Load()
+	if droppers == nil || entry.Logger != logrus.StandardLogger() {
+		return false
+	}
+	dropped := false
+	for _, d := range *droppers {
+		if d.dropEntry(entry, dropped) {
+			dropped = true
+		}
+	}
+	return dropped
+}
+
+// set remember the decision for the entry.
+func (d *dropDecisions) set(entry *logrus.Entry, drop bool) {
+	if *d == nil || len(*d) >= maxPendingDecisions {
+		*d = dropDecisions{}
+	}
+	(*d)[entry] = drop
+}
+
+// take return and forget the decision for the entry, ok is false if no decision have been made.
+func (d dropDecisions) take(entry *logrus.Entry) (drop, ok bool) {
+	drop, ok = d[entry]
+	if ok {
+		delete(d, entry)
+	}
+	return drop, ok
+}
@@ -0,0 +1,68 @@
+package eal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogSequence(t *testing.T) {
+	entries := captureLog(t)
+	LogSequence = true
+	defer func() { LogSequence = false }()
+	SetInstanceID("test-instance")
+
+	start := Stats().Seq
+	NewEntry().Info("first")
+	NewEntry().Info("second")
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	for i, e := range logged {
+		if e[FieldInstanceID] != "test-instance" {
+			t.Errorf("entry %d: got %s: %v, want: test-instance", i, FieldInstanceID, e[FieldInstanceID])
+		}
+		if e[FieldSeq] != float64(start+uint64(i)+1) {
+			t.Errorf("entry %d: got %s: %v, want: %d", i, FieldSeq, e[FieldSeq], start+uint64(i)+1)
+		}
+	}
+	if got := Stats(); got.Seq != start+2 || got.InstanceID != "test-instance" {
+		t.Errorf("got Stats(): %+v, want seq %d", got, start+2)
+	}
+}
+
+func TestLogSequenceDropped(t *testing.T) {
+	entries := captureLog(t)
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	t.Cleanup(func() { logrus.SetLevel(level) })
+	LogSequence = true
+	defer func() { LogSequence = false }()
+	SetErrorDedup(time.Minute)
+	defer SetErrorDedup(0)
+	SetLogBudget(LogBudget{BytesPerMinute: 1000})
+	defer SetLogBudget(LogBudget{})
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		NewEntry().WithError(ErrTest).Error("failed")
+		NewEntry().Debug("a debug message that is dropped when most of the budget have been used")
+	}
+	logrus.NewEntry(logrus.StandardLogger()).WithTime(start.Add(2 * time.Minute)).Info("next window")
+
+	logged := entries()
+	var repeated, summary bool
+	for i, e := range logged {
+		repeated = repeated || e[FieldRepeatCount] != nil
+		summary = summary || e["msg"] == "log_budget_exceeded"
+		if i > 0 && e[FieldSeq] != logged[i-1][FieldSeq].(float64)+1 {
+			t.Errorf("entry %d: got %s: %v after %v, want no gaps", i, FieldSeq, e[FieldSeq], logged[i-1][FieldSeq])
+		}
+	}
+	if len(logged) >= 41 || !repeated || !summary {
+		t.Errorf("got %d entries, want dropped entries, a repeat_count entry and a log_budget_exceeded entry", len(logged))
+	}
+}
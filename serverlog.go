@@ -0,0 +1,65 @@
+package eal
+
+import (
+	"log"
+	"regexp"
+	"strings"
+)
+
+// serverErrorPatterns classify the error messages written by net/http servers, the first sub-match is the remote
+// address of the client. The response_header messages are written when a handler write invalid response headers,
+// errors in request headers aren't logged, net/http answer them with a 400 response directly.
+var serverErrorPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{kind: "tls_handshake", re: regexp.MustCompile(`^http: TLS handshake error from (\S+): `)},
+	{kind: "accept", re: regexp.MustCompile(`^http: Accept error: `)},
+	{kind: "panic", re: regexp.MustCompile(`^http: panic serving (\S+): `)},
+	{kind: "superfluous_write_header", re: regexp.MustCompile(`^http: superfluous response\.WriteHeader`)},
+	{kind: "response_header", re: regexp.MustCompile(`^http: (response\.WriteHeader on hijacked connection|WriteHeader called with both Transfer-Encoding|invalid Content-Length)`)},
+}
+
+// serverErrorWriter convert each line written by the net/http server to a structured log entry.
+type serverErrorWriter struct{}
+
+// HTTPServerErrorLog return a *log.Logger that can be used as http.Server.ErrorLog, to convert errors logged by the
+// net/http server, for example TLS handshake failures and panics in handlers, to structured log entries. These errors
+// happen before echo see the request, and would otherwise only be written as plain text to STDERR.
+//
+//	s := &http.Server{Handler: e, ErrorLog: eal.HTTPServerErrorLog()}
+//
+// The entries are logged with the message "http_server_error", the original message in the error_message field and
+// a server_error_kind field (tls_handshake, accept, panic, superfluous_write_header, response_header or other). The
+// response_header kind is used for invalid response headers written by handlers. TLS handshake errors are logged with
+// warning level, since they are usually caused by misbehaving clients.
+func HTTPServerErrorLog() *log.Logger {
+	return log.New(serverErrorWriter{}, "", 0)
+}
+
+func (serverErrorWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	if msg == "" {
+		return len(p), nil
+	}
+
+	fields := Fields{FieldErrorMessage: msg, FieldServerErrorKind: "other"}
+	for _, sp := range serverErrorPatterns {
+		m := sp.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		fields[FieldServerErrorKind] = sp.kind
+		if len(m) > 1 {
+			fields[FieldRemoteAddr] = m[1]
+		}
+		break
+	}
+
+	if fields[FieldServerErrorKind] == "tls_handshake" {
+		NewEntry().WithFields(fields).Warn("http_server_error")
+	} else {
+		NewEntry().WithFields(fields).Error("http_server_error")
+	}
+	return len(p), nil
+}
@@ -0,0 +1,35 @@
+package eal
+
+import (
+	"testing"
+)
+
+func TestHTTPServerErrorLog(t *testing.T) {
+	entries := captureLog(t)
+
+	l := HTTPServerErrorLog()
+	l.Printf("http: TLS handshake error from 10.0.0.1:51234: EOF")
+	l.Printf("http: panic serving 10.0.0.2:40000: runtime error")
+	l.Printf(`http: invalid Content-Length of "abc"`)
+	l.Printf("user header missing")
+	l.Printf("something unexpected")
+
+	logged := entries()
+	if len(logged) != 5 {
+		t.Fatalf("got %d log entries, want 5", len(logged))
+	}
+	for i, want := range []struct {
+		kind, addr, level string
+	}{
+		{kind: "tls_handshake", addr: "10.0.0.1:51234", level: "warning"},
+		{kind: "panic", addr: "10.0.0.2:40000", level: "error"},
+		{kind: "response_header", level: "error"},
+		{kind: "other", level: "error"},
+		{kind: "other", level: "error"},
+	} {
+		got := logged[i]
+		if got[FieldServerErrorKind] != want.kind || got["level"] != want.level || (want.addr != "" && got[FieldRemoteAddr] != want.addr) {
+			t.Errorf("entry %d: got: %v, want kind: %s, addr: %s, level: %s", i, got, want.kind, want.addr, want.level)
+		}
+	}
+}
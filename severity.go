@@ -0,0 +1,54 @@
+package eal
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var (
+	errorLevelsMu sync.RWMutex
+	errorLevels   = map[interface{}]Level{}
+)
+
+// RegisterErrorLevel set the level of the access log entries of requests that fail with any of the errors, for
+// example to log expected failures, like validation errors, with warn or info level instead of error level. Errors
+// are matched by type if a nil pointer of the error type is provided, and otherwise by instance, in the same way as
+// RegisterErrorLogFunc:
+//
+//	eal.RegisterErrorLevel(eal.WarnLevel, (*ValidationError)(nil), sql.ErrNoRows)
+//
+// Errors can also declare their own level by implementing the LogLevel() eal.Level method.
+func RegisterErrorLevel(level Level, errList ...error) {
+	errorLevelsMu.Lock()
+	defer errorLevelsMu.Unlock()
+	for _, err := range errList {
+		t := reflect.ValueOf(err)
+		if t.Kind() == reflect.Ptr && t.IsNil() {
+			errorLevels[reflect.TypeOf(err)] = level
+		} else {
+			errorLevels[err] = level
+		}
+	}
+}
+
+// errorLevel return the level declared by the first error in the chain that implement LogLevel() Level, or that have
+// a level registered with RegisterErrorLevel.
+func errorLevel(err error) (Level, bool) {
+	errorLevelsMu.RLock()
+	defer errorLevelsMu.RUnlock()
+	for ; err != nil; err = errors.Unwrap(err) {
+		if l, ok := err.(interface{ LogLevel() Level }); ok {
+			return l.LogLevel(), true
+		}
+		if level, ok := errorLevels[reflect.TypeOf(err)]; ok {
+			return level, true
+		}
+		if reflect.TypeOf(err).Comparable() {
+			if level, ok := errorLevels[err]; ok {
+				return level, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,46 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type validationError struct{ field string }
+
+func (ve *validationError) Error() string { return "invalid " + ve.field }
+
+type leveledError struct{}
+
+func (leveledError) Error() string   { return "rate limited" }
+func (leveledError) LogLevel() Level { return DebugLevel }
+
+func TestErrorLevel(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	RegisterErrorLevel(WarnLevel, (*validationError)(nil))
+	RegisterErrorLevel(InfoLevel, errNoRows)
+	t.Cleanup(func() {
+		errorLevelsMu.Lock()
+		errorLevels = map[interface{}]Level{}
+		errorLevelsMu.Unlock()
+	})
+
+	for _, tt := range []struct {
+		name   string
+		status int
+		err    error
+		want   Level
+	}{
+		{name: "registered type", status: http.StatusInternalServerError, err: Trace(&validationError{field: "email"}), want: WarnLevel},
+		{name: "registered instance", status: http.StatusInternalServerError, err: fmt.Errorf("load user: %w", errNoRows), want: InfoLevel},
+		{name: "LogLevel method", status: http.StatusTooManyRequests, err: NewHTTPError(leveledError{}, http.StatusTooManyRequests), want: DebugLevel},
+		{name: "not registered", status: http.StatusInternalServerError, err: errors.New("db down"), want: ErrorLevel},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultAccessLevel(tt.status, tt.err, Fields{}); got != tt.want {
+				t.Errorf("got level: %s, want: %s", got, tt.want)
+			}
+		})
+	}
+}
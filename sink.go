@@ -0,0 +1,167 @@
+package eal
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Level is the severity of a log entry. The values match the logrus levels.
+type Level uint32
+
+// Log levels, from the most to the least severe.
+const (
+	PanicLevel Level = iota
+	FatalLevel
+	ErrorLevel
+	WarnLevel
+	InfoLevel
+	DebugLevel
+	TraceLevel
+)
+
+type (
+	// Record is a log entry, as passed to a Sink.
+	Record struct {
+		Time    time.Time
+		Level   Level
+		Message string
+		Fields  Fields
+		Context context.Context
+	}
+
+	// Sink is implemented by logging backends that eal can write log entries to, see SetSink. Write may be called
+	// concurrently from several goroutines.
+	Sink interface {
+		Write(r Record) error
+	}
+
+	// entryHook is added to the standard logrus logger when eal is first used, see installHook. It run the eal entry
+	// processing (sequence numbers etc.) on all log entries, and forward them to the current sink, if any.
+	entryHook struct {
+		mu      sync.RWMutex
+		sink    Sink
+		backend logrusBackend
+	}
+
+	// logrusBackend hold the output, level and formatter of the standard logrus logger while a sink is set, so that
+	// they can be restored when the sink is removed.
+	logrusBackend struct {
+		out       io.Writer
+		level     logrus.Level
+		formatter logrus.Formatter
+	}
+
+	// discardFormatter is used by the standard logrus logger while a sink is set, the entries are written by the sink
+	// so there is no need to format them.
+	discardFormatter struct{}
+
+	logrusSink struct {
+		logger *logrus.Logger
+	}
+)
+
+var (
+	hook     = &entryHook{}
+	hookOnce sync.Once
+)
+
+// installHook add the entry hook to the standard logrus logger. The hook is added the first time eal is used, i.e.
+// when an Entry is created or the logger is configured by eal, instead of when the package is imported.
+func installHook() {
+	hookOnce.Do(func() { logrus.AddHook(hook) })
+}
+
+// String return the name of the level.
+func (l Level) String() string {
+	return logrus.Level(l).String()
+}
+
+// Ptr return a pointer to a copy of the level, for the optional levels of RouteOptions and ErrorCode.
+//
+//	eal.SetRouteOptions("/healthz", eal.RouteOptions{Level: eal.DebugLevel.Ptr()})
+func (l Level) Ptr() *Level {
+	return &l
+}
+
+// SetSink make eal (Entry, the middleware and everything else that log) write all log entries to the provided Sink
+// instead of the standard logrus logger, which is the default backend. The sink can be replaced by calling SetSink
+// again, and SetSink(nil) restore the output, level and formatter that the standard logrus logger had before the
+// first sink was set.
+func SetSink(s Sink) {
+	installHook()
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	std := logrus.StandardLogger()
+	switch {
+	case s != nil && hook.sink == nil:
+		hook.backend = logrusBackend{out: std.Out, level: std.GetLevel(), formatter: std.Formatter}
+		logrus.SetOutput(io.Discard)
+		logrus.SetFormatter(discardFormatter{})
+		logrus.SetLevel(logrus.TraceLevel)
+	case s == nil && hook.sink != nil:
+		level := hook.backend.level
+		if l, ok := setLevel(); ok {
+			level = logrus.Level(l)
+		}
+		logrus.SetOutput(hook.backend.out)
+		logrus.SetFormatter(hook.backend.formatter)
+		logrus.SetLevel(level)
+		hook.backend = logrusBackend{}
+	}
+	hook.sink = s
+}
+
+// Format implements the logrus.Formatter interface.
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// NewLogrusSink return a Sink that write log entries to the provided logrus logger.
+func NewLogrusSink(logger *logrus.Logger) Sink {
+	return &logrusSink{logger: logger}
+}
+
+func (h *entryHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *entryHook) Fire(entry *logrus.Entry) error {
+	reportErr := takeReportError(entry.Data)
+	h.mu.RLock()
+	s := h.sink
+	h.mu.RUnlock()
+	if s == nil {
+		processEntry(entry, entryDropped(entry))
+		reportEntryError(entry, reportErr)
+		return nil
+	}
+	if !sinkLevelEnabled(Level(entry.Level)) {
+		return nil
+	}
+	processEntry(entry, false)
+	reportEntryError(entry, reportErr)
+
+	fields := make(Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return s.Write(Record{Time: entry.Time, Level: Level(entry.Level), Message: entry.Message, Fields: fields, Context: ctx})
+}
+
+func (s *logrusSink) Write(r Record) error {
+	level := logrus.Level(r.Level)
+	if !s.logger.IsLevelEnabled(level) {
+		return nil
+	}
+	s.logger.WithContext(r.Context).WithTime(r.Time).WithFields(logrus.Fields(r.Fields)).Log(level, r.Message)
+	return nil
+}
@@ -0,0 +1,81 @@
+package eal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type recordingSink struct {
+	records []Record
+}
+
+func (s *recordingSink) Write(r Record) error {
+	s.records = append(s.records, r)
+	return nil
+}
+
+func TestEntryHook(t *testing.T) {
+	rs := &recordingSink{}
+	logger := logrus.New()
+	logger.SetOutput(&bytes.Buffer{})
+	logger.AddHook(&entryHook{sink: rs})
+
+	logger.WithField("user_id", 42).Warn("slow request")
+
+	if len(rs.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(rs.records))
+	}
+	r := rs.records[0]
+	if r.Level != WarnLevel || r.Message != "slow request" || r.Fields["user_id"] != 42 || r.Context == nil {
+		t.Errorf("got unexpected record: %+v", r)
+	}
+}
+
+func TestLogrusSink(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetLevel(logrus.InfoLevel)
+
+	s := NewLogrusSink(logger)
+	_ = s.Write(Record{Level: DebugLevel, Message: "filtered"})
+	_ = s.Write(Record{Level: InfoLevel, Message: "written", Fields: Fields{"k": "v"}})
+
+	if got := buf.String(); strings.Contains(got, "filtered") || !strings.Contains(got, "written") || !strings.Contains(got, "k=v") {
+		t.Errorf("got output: %s, want only the info entry", got)
+	}
+}
+
+func TestSetSinkRestore(t *testing.T) {
+	var buf bytes.Buffer
+	std := logrus.StandardLogger()
+	out, formatter, level := std.Out, std.Formatter, std.GetLevel()
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	logrus.SetLevel(logrus.InfoLevel)
+	t.Cleanup(func() {
+		logrus.SetOutput(out)
+		logrus.SetFormatter(formatter)
+		logrus.SetLevel(level)
+	})
+
+	rs := &recordingSink{}
+	SetSink(rs)
+	NewEntry().Info("to sink")
+	if _, ok := std.Formatter.(discardFormatter); !ok {
+		t.Errorf("got formatter: %T, want the entries to not be formatted while a sink is set", std.Formatter)
+	}
+	SetSink(nil)
+	NewEntry().Info("to logrus")
+	NewEntry().Debug("filtered")
+
+	if len(rs.records) != 1 || rs.records[0].Message != "to sink" {
+		t.Errorf("got records: %+v, want the entry logged while the sink was set", rs.records)
+	}
+	if got := buf.String(); strings.Contains(got, "to sink") || !strings.Contains(got, "to logrus") || strings.Contains(got, "filtered") {
+		t.Errorf("got output: %s, want only the info entry logged after the sink was removed", got)
+	}
+}
@@ -0,0 +1,42 @@
+package eal
+
+import (
+	"io"
+	"net/http"
+)
+
+// countingReader count the bytes read from a request body that don't have a Content-Length.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// countRequestBody wrap the request body in a countingReader, if the request don't have a Content-Length.
+func countRequestBody(req *http.Request) *countingReader {
+	if req.ContentLength >= 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	cr := &countingReader{ReadCloser: req.Body}
+	req.Body = cr
+	return cr
+}
+
+// setSizeFields add the bytes_in and bytes_out fields. The request size is the Content-Length, or the number of bytes
+// that the handler read from the body if the request don't have a Content-Length.
+func setSizeFields(fields Fields, req *http.Request, cr *countingReader, bytesOut int64) {
+	bytesIn := req.ContentLength
+	if cr != nil {
+		bytesIn = cr.n
+	}
+	if bytesIn < 0 {
+		bytesIn = 0
+	}
+	fields[FieldBytesIn] = bytesIn
+	fields[FieldBytesOut] = bytesOut
+}
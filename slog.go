@@ -0,0 +1,66 @@
+package eal
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// slogSink write log entries to a slog.Handler.
+type slogSink struct {
+	handler slog.Handler
+}
+
+// InitSlog configure eal to emit all log entries through the provided slog.Handler instead of writing them with logrus.
+// Entry, UnwrapError and the middleware keep working unchanged, and the handler decide which levels are enabled.
+//
+//	eal.InitSlog(slog.NewJSONHandler(os.Stdout, nil))
+func InitSlog(handler slog.Handler) {
+	SetSink(NewSlogSink(handler))
+}
+
+// NewSlogSink return a Sink that write log entries to the provided slog.Handler.
+func NewSlogSink(handler slog.Handler) Sink {
+	return &slogSink{handler: handler}
+}
+
+func (s *slogSink) Write(r Record) error {
+	level := slogLevel(r.Level)
+	if !s.handler.Enabled(r.Context, level) {
+		return nil
+	}
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sr := slog.NewRecord(r.Time, level, r.Message, 0)
+	for _, k := range keys {
+		v := r.Fields[k]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		sr.AddAttrs(slog.Any(k, v))
+	}
+	return s.handler.Handle(r.Context, sr)
+}
+
+func slogLevel(l Level) slog.Level {
+	switch l {
+	case TraceLevel:
+		return slog.LevelDebug - 4
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case FatalLevel:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelError + 8
+	}
+}
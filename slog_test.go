@@ -0,0 +1,31 @@
+package eal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&bytes.Buffer{})
+	logger.AddHook(&entryHook{sink: NewSlogSink(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))})
+
+	logger.Info("filtered")
+	fields := logrus.Fields{}
+	UnwrapError(Trace(errors.New("boom")), fields)
+	logger.WithFields(fields).Error("access")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("got output: %s, want a single JSON entry: %v", buf.String(), err)
+	}
+	if got["level"] != "ERROR" || got["msg"] != "access" || got[FieldErrorMessage] != "boom" || got[FieldErrorStack] == nil {
+		t.Errorf("got unexpected entry: %v", got)
+	}
+}
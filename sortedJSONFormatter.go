@@ -0,0 +1,192 @@
+package eal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCoreFields is the fields that SortedJSONFormatter write first, in this order, when CoreFields isn't set.
+var DefaultCoreFields = []string{
+	FieldRequestID, FieldMethod, FieldURI, FieldRouterPath, FieldStatus, FieldLatencyMs, FieldErrorMessage,
+	FieldErrorType, FieldHTTPStatus,
+}
+
+// SortedJSONFormatter is a logrus.Formatter that write log entries as JSON with a deterministic key order: time, level
+// and msg first, then the core fields in the configured order, and then the remaining fields sorted by name. Equal
+// entries are encoded to equal bytes, which make it possible to diff log output in tests and to hash entries
+// downstream, and the most important fields are found at the start of each line.
+type SortedJSONFormatter struct {
+	// CoreFields is the fields that are written after time, level and msg, in order. DefaultCoreFields is used if
+	// CoreFields is nil.
+	CoreFields []string
+
+	// TimestampFormat is the format of the time field, time.RFC3339 is used if not set.
+	TimestampFormat string
+}
+
+// InitSortedJSON initialize the logrus logger to output JSON with a deterministic key order, see SortedJSONFormatter.
+func InitSortedJSON() {
+	installHook()
+	logrus.SetFormatter(&SortedJSONFormatter{})
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *SortedJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	core := f.CoreFields
+	if core == nil {
+		core = DefaultCoreFields
+	}
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		if !containsString(core, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = time.RFC3339
+	}
+	b.WriteString(`{"time":`)
+	writeJSONString(b, entry.Time.Format(timestampFormat))
+	b.WriteString(`,"level":`)
+	writeJSONString(b, entry.Level.String())
+	b.WriteString(`,"msg":`)
+	writeJSONString(b, entry.Message)
+
+	write := func(k string) error {
+		v, ok := entry.Data[k]
+		if !ok {
+			return nil
+		}
+		switch k {
+		case logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg:
+			// Clashing fields are prefixed, in the same way as by logrus.JSONFormatter
+			k = "fields." + k
+		}
+		b.WriteByte(',')
+		writeJSONString(b, k)
+		b.WriteByte(':')
+		if err := writeJSONValue(b, v); err != nil {
+			return fmt.Errorf("failed to encode field %s: %w", k, err)
+		}
+		return nil
+	}
+	for _, k := range core {
+		if err := write(k); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range keys {
+		if err := write(k); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("}\n")
+
+	return b.Bytes(), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSONValue write the value as JSON. Strings, integers and booleans are written directly, other values are
+// marshaled with encoding/json, which sort the keys of maps. Errors are written as their message.
+func writeJSONValue(b *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		writeJSONString(b, v)
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case error:
+		writeJSONString(b, v.Error())
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString write s as a JSON string. Invalid UTF-8 is replaced with U+FFFD, as by encoding/json.
+func writeJSONString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' && c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if c < utf8.RuneSelf {
+			b.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(s[start:i])
+			b.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON, but are escaped by encoding/json since they break JavaScript
+		if r == '\u2028' || r == '\u2029' {
+			b.WriteString(s[start:i])
+			b.WriteString(`\u202`)
+			b.WriteByte(hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+	b.WriteByte('"')
+}
@@ -0,0 +1,77 @@
+package eal
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testEntry() *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Time = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "access"
+	entry.Data = logrus.Fields{
+		"tenant":          "acme",
+		FieldStatus:       500,
+		FieldRequestID:    "req-1",
+		FieldLatencyMs:    int64(12),
+		FieldErrorMessage: errors.New("db \"down\"\n\tat <host>"),
+		FieldCancelCause:  map[string]interface{}{"b": 2, "a": 1},
+		"msg":             "clash",
+		"odd":             "bad \xff utf8 \u2028 \x01",
+	}
+	return entry
+}
+
+func TestSortedJSONFormatter(t *testing.T) {
+	f := &SortedJSONFormatter{}
+	got, err := f.Format(testEntry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2024-05-01T12:00:00Z","level":"error","msg":"access","request_id":"req-1","status":500,"latency_ms":12,` +
+		`"error_message":"db \"down\"\n\tat <host>","cancel_cause":{"a":1,"b":2},"fields.msg":"clash",` +
+		`"odd":"bad \ufffd utf8 \u2028 \u0001","tenant":"acme"}` + "\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The output must decode to the same strings as encoding/json would
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("got invalid JSON: %v", err)
+	}
+	if decoded["odd"] != "bad \ufffd utf8 \u2028 \x01" {
+		t.Errorf("got odd: %q", decoded["odd"])
+	}
+
+	for i := 0; i < 10; i++ {
+		again, _ := f.Format(testEntry())
+		if string(again) != string(got) {
+			t.Fatalf("got different output for equal entries:\n%s\n%s", again, got)
+		}
+	}
+}
+
+func BenchmarkSortedJSONFormatter(b *testing.B) {
+	benchmarkFormatter(b, &SortedJSONFormatter{})
+}
+
+func BenchmarkLogrusJSONFormatter(b *testing.B) {
+	benchmarkFormatter(b, &logrus.JSONFormatter{})
+}
+
+func benchmarkFormatter(b *testing.B, f logrus.Formatter) {
+	entry := testEntry()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
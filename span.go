@@ -0,0 +1,38 @@
+package eal
+
+import (
+	"context"
+)
+
+// SpanInfo hold the identifiers of the active tracing span.
+type SpanInfo struct {
+	TraceID    string
+	SpanID     string
+	TraceFlags string
+}
+
+// SpanFromContext is used by the logger middleware and Entry.WithCtx to find the active tracing span of a request.
+// When it return true, the trace_id, span_id and trace_flags fields are added to the log entry, so that log entries
+// can be correlated with traces. It's nil by default, and can be set to extract the span from OpenTelemetry:
+//
+//	eal.SpanFromContext = func(ctx context.Context) (eal.SpanInfo, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return eal.SpanInfo{TraceID: sc.TraceID().String(), SpanID: sc.SpanID().String(), TraceFlags: sc.TraceFlags().String()}, sc.IsValid()
+//	}
+var SpanFromContext func(ctx context.Context) (SpanInfo, bool)
+
+// setSpanFields add the span fields, if SpanFromContext is set and find a span in the context.
+func setSpanFields(ctx context.Context, fields map[string]interface{}) {
+	if SpanFromContext == nil || ctx == nil {
+		return
+	}
+	si, ok := SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	fields[FieldTraceID] = si.TraceID
+	fields[FieldSpanID] = si.SpanID
+	if si.TraceFlags != "" {
+		fields[FieldTraceFlags] = si.TraceFlags
+	}
+}
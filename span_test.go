@@ -0,0 +1,39 @@
+package eal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type spanKey struct{}
+
+func TestSpanFromContext(t *testing.T) {
+	entries := captureLog(t)
+	SpanFromContext = func(ctx context.Context) (SpanInfo, bool) {
+		si, ok := ctx.Value(spanKey{}).(SpanInfo)
+		return si, ok
+	}
+	defer func() { SpanFromContext = nil }()
+
+	span := SpanInfo{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", TraceFlags: "01"}
+	req := httptest.NewRequest(http.MethodGet, "/traced", nil)
+	req = req.WithContext(context.WithValue(req.Context(), spanKey{}, span))
+	serve(CreateLoggerMiddleware(), req, func(c echo.Context) error {
+		NewEntry().WithCtx(c).Info("in handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	for i, e := range logged {
+		if e[FieldTraceID] != span.TraceID || e[FieldSpanID] != span.SpanID || e[FieldTraceFlags] != span.TraceFlags {
+			t.Errorf("entry %d: got: %v, want span fields", i, e)
+		}
+	}
+}
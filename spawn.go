@@ -0,0 +1,60 @@
+package eal
+
+import (
+	"context"
+)
+
+// SpawnError is the error logged when a function started by Go return an error. It hold the stacktrace of the
+// goroutine that called Go, in addition to the stacktrace of where the error was returned.
+type SpawnError struct {
+	err        error
+	spawnStack *callStack
+}
+
+// Go calls the provided function in a new goroutine, as a background task. If the function return an error, the
+// error is wrapped by Trace in the new goroutine, and logged together with the stacktrace of the goroutine that called
+// Go in the spawn_stack field, since who started a goroutine is often the hard part when debugging async failures.
+// Log fields stored in ctx, see WithFields, are added to the log entry.
+//
+//	eal.Go(ctx, func(ctx context.Context) error {
+//	  return s.reindex(ctx, tenant)
+//	})
+func Go(ctx context.Context, f func(ctx context.Context) error) {
+	spawnStack := captureStack(1)
+	go func() {
+		if err := Trace(f(ctx)); err != nil {
+			NewEntry().
+				WithFields(FieldsFromContext(ctx)).
+				WithError(&SpawnError{err: err, spawnStack: spawnStack}).
+				Error("background task failed")
+		}
+	}()
+}
+
+// Error return the message of the wrapped error.
+func (se *SpawnError) Error() string {
+	return se.err.Error()
+}
+
+// Unwrap return the wrapped error.
+func (se *SpawnError) Unwrap() error {
+	return se.err
+}
+
+// SpawnStack return the stacktrace of the goroutine that started the background task.
+func (se *SpawnError) SpawnStack() string {
+	return se.spawnStack.String()
+}
+
+// SetLogFields is used by Entry.WithError to populate log fields.
+func (se *SpawnError) SetLogFields(logFields map[string]interface{}) {
+	spawnStack := se.spawnStack.String()
+	if spawnStack == "" {
+		return
+	}
+	if StackLogEncoding == StackCompressed {
+		logFields[FieldSpawnStack] = compressStack(spawnStack)
+		return
+	}
+	logFields[FieldSpawnStack] = spawnStack
+}
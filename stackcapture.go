@@ -0,0 +1,283 @@
+package eal
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStackGovernorCooldown is the Cooldown of a StackGovernor that don't have one set.
+const DefaultStackGovernorCooldown = 10 * time.Second
+
+// StackMaxDepth is the maximum number of frames in the stacktraces captured by Trace and Go.
+var StackMaxDepth = 64
+
+// StackSkip is the number of frames, starting with the caller of Trace (or Go), that are left out of the captured
+// stacktraces. It can be set when Trace is always called through a helper function.
+var StackSkip = 0
+
+// StackFrameFilter select the frames that are left out of the logged stacktraces, so that the stacktraces start at
+// application code, see StackFilter. The "created by" line of a goroutine is always kept.
+type StackFrameFilter struct {
+	// Eal leave out the frames of the eal package, like the logger middleware.
+	Eal bool
+
+	// Runtime leave out the frames of the Go runtime, like runtime.goexit and runtime.gopanic.
+	Runtime bool
+
+	// Prefixes leave out the frames of functions with names that start with any of the prefixes, for example
+	// "github.com/labstack/echo/v4." for the echo router and middlewares.
+	Prefixes []string
+}
+
+// StackFilter is applied to the stacktraces of Trace, Go and recovered panics. No frames are left out by default. If
+// all frames of a stacktrace match the filter, the stacktrace is logged unfiltered.
+//
+//	eal.StackFilter = eal.StackFrameFilter{Eal: true, Runtime: true, Prefixes: []string{"github.com/labstack/echo/v4."}}
+var StackFilter StackFrameFilter
+
+// StackGovernor automatically disable stack capture in Trace when errors are traced at a high rate, for example
+// during a dependency outage, where generating stacktraces for every failed request become a CPU problem of its own.
+// See SetStackGovernor.
+type StackGovernor struct {
+	// MaxPerSecond is the number of stacktraces that may be captured per second. Stack capture is disabled for the
+	// Cooldown period when the limit is passed.
+	MaxPerSecond int
+
+	// Cooldown is how long stack capture stay disabled, DefaultStackGovernorCooldown is used if not set.
+	Cooldown time.Duration
+
+	mu            sync.Mutex
+	windowStart   time.Time
+	count         int
+	disabledUntil time.Time
+	suppressedAt  uint64
+}
+
+var (
+	stackCaptureDisabled atomic.Bool
+	stacksSuppressed     atomic.Uint64
+	stackGovernor        atomic.Pointer[StackGovernor]
+)
+
+// SetStackCapture enable or disable the stacktraces captured by Trace and Go, at runtime. Errors are still wrapped
+// and logged when stack capture is disabled, but without the error_stack and error_origin fields, since the origin is
+// resolved from the captured stacktrace. Stack capture is enabled by default.
+func SetStackCapture(enabled bool) {
+	stackCaptureDisabled.Store(!enabled)
+}
+
+// StackCaptureEnabled report if stack capture is enabled, see SetStackCapture. It doesn't consider the StackGovernor.
+func StackCaptureEnabled() bool {
+	return !stackCaptureDisabled.Load()
+}
+
+// StacksSuppressed return the number of stacktraces that have not been captured because stack capture was disabled,
+// by SetStackCapture or by the StackGovernor.
+func StacksSuppressed() uint64 {
+	return stacksSuppressed.Load()
+}
+
+// SetStackGovernor set the governor that disable stack capture when errors are traced at a high rate, or remove it if
+// g is nil:
+//
+//	eal.SetStackGovernor(&eal.StackGovernor{MaxPerSecond: 200})
+//
+// A "stack_capture_suppressed" warning is logged when the governor disable stack capture, and a
+// "stack_capture_resumed" entry with the number of suppressed stacktraces in the stacks_suppressed field is logged
+// when it's enabled again.
+func SetStackGovernor(g *StackGovernor) {
+	stackGovernor.Store(g)
+}
+
+// callStack is a stacktrace captured with runtime.Callers. Only the program counters are recorded when the stack is
+// captured, the function names and file paths are resolved the first time the stacktrace is formatted, which
+// normally is when the error is logged.
+type callStack struct {
+	pcs   []uintptr
+	once  sync.Once
+	text  string
+	value interface{} // text as a log field value, set together with text
+
+	originOnce  sync.Once
+	originText  string
+	originValue interface{} // originText as a log field value, set together with originText
+}
+
+// captureStack return the stacktrace of the caller of the eal function that call captureStack, or nil if stack capture
+// is disabled. Skip is the number of eal frames between captureStack and the caller.
+func captureStack(skip int) *callStack {
+	if stackCaptureDisabled.Load() {
+		stacksSuppressed.Add(1)
+		return nil
+	}
+	if g := stackGovernor.Load(); g != nil && !g.allow(time.Now()) {
+		return nil
+	}
+
+	depth := StackMaxDepth
+	if depth <= 0 {
+		depth = 64
+	}
+	pcs := make([]uintptr, depth)
+	// Skip runtime.Callers, captureStack and the eal functions
+	n := runtime.Callers(2+skip+StackSkip, pcs)
+	return &callStack{pcs: pcs[:n]}
+}
+
+// String return the stacktrace in the format of runtime/debug.Stack, without the goroutine ID and the function
+// arguments, or an empty string if cs is nil.
+func (cs *callStack) String() string {
+	if cs == nil {
+		return ""
+	}
+	cs.once.Do(func() {
+		var sb strings.Builder
+		sb.WriteString("goroutine [running]:\n")
+		frames := runtime.CallersFrames(cs.pcs)
+		for {
+			frame, more := frames.Next()
+			if frame.Function != "" {
+				fmt.Fprintf(&sb, "%s(...)\n\t%s:%d", frame.Function, frame.File, frame.Line)
+				if frame.Entry != 0 {
+					fmt.Fprintf(&sb, " +0x%x", frame.PC-frame.Entry)
+				}
+				sb.WriteByte('\n')
+			}
+			if !more {
+				break
+			}
+		}
+		cs.text = filterStack(sb.String(), StackFilter)
+		cs.value = cs.text
+	})
+	return cs.text
+}
+
+// origin return the location of the first caller in the stacktrace that is outside of the eal package, formatted as
+// "<dir>/<file>:<line> <function>", and the location as a log field value. If all callers are within eal (or the
+// runtime), the first caller is used. The location is resolved the first time origin is called.
+func (cs *callStack) origin() (string, interface{}) {
+	if cs == nil {
+		return "", nil
+	}
+	cs.originOnce.Do(func() {
+		frames := runtime.CallersFrames(cs.pcs)
+		var first *runtime.Frame
+		for {
+			frame, more := frames.Next()
+			if first == nil {
+				f := frame
+				first = &f
+			}
+			internal := strings.HasPrefix(frame.Function, ealPackage+".") && !strings.HasSuffix(frame.File, "_test.go")
+			if !internal && !strings.HasPrefix(frame.Function, "runtime.") {
+				cs.originText = formatOrigin(frame)
+				break
+			}
+			if !more {
+				if first.Function != "" {
+					cs.originText = formatOrigin(*first)
+				}
+				break
+			}
+		}
+		cs.originValue = cs.originText
+	})
+	return cs.originText, cs.originValue
+}
+
+// filterStack remove the frames that match the filter from a stacktrace in the format of runtime/debug.Stack.
+func filterStack(stack string, filter StackFrameFilter) string {
+	if !filter.Eal && !filter.Runtime && len(filter.Prefixes) == 0 {
+		return stack
+	}
+
+	lines := strings.SplitAfter(stack, "\n")
+	var sb strings.Builder
+	kept := 0
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if i == 0 || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "created by ") || line == "" {
+			sb.WriteString(line)
+			continue
+		}
+		if filter.match(line) {
+			// Skip the location line of the frame as well
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+				i++
+			}
+			continue
+		}
+		kept++
+		sb.WriteString(line)
+	}
+	if kept == 0 {
+		return stack
+	}
+	return sb.String()
+}
+
+// match report if the function line of a frame should be left out.
+func (f StackFrameFilter) match(function string) bool {
+	if f.Eal && strings.HasPrefix(function, ealPackage+".") {
+		return true
+	}
+	if f.Runtime && (strings.HasPrefix(function, "runtime.") || strings.HasPrefix(function, "runtime/") || strings.HasPrefix(function, "panic(")) {
+		return true
+	}
+	for _, p := range f.Prefixes {
+		if strings.HasPrefix(function, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow report if a stacktrace may be captured at the time now. Stacktraces that may not be captured are counted as
+// suppressed.
+func (g *StackGovernor) allow(now time.Time) bool {
+	g.mu.Lock()
+	if now.Before(g.disabledUntil) {
+		g.mu.Unlock()
+		stacksSuppressed.Add(1)
+		return false
+	}
+
+	var resumed, suppressed bool
+	if !g.disabledUntil.IsZero() {
+		g.disabledUntil = time.Time{}
+		resumed = true
+	}
+	if now.Sub(g.windowStart) >= time.Second {
+		g.windowStart = now
+		g.count = 0
+	}
+	g.count++
+	if g.MaxPerSecond > 0 && g.count > g.MaxPerSecond {
+		cooldown := g.Cooldown
+		if cooldown <= 0 {
+			cooldown = DefaultStackGovernorCooldown
+		}
+		g.disabledUntil = now.Add(cooldown)
+		suppressed = true
+	}
+	suppressedAt := g.suppressedAt
+	if suppressed {
+		g.suppressedAt = stacksSuppressed.Load()
+	}
+	g.mu.Unlock()
+
+	if resumed {
+		NewEntry().WithFields(Fields{FieldStacksSuppressed: stacksSuppressed.Load() - suppressedAt}).Info("stack_capture_resumed")
+	}
+	if suppressed {
+		NewEntry().Warn("stack_capture_suppressed")
+		stacksSuppressed.Add(1)
+		return false
+	}
+	return true
+}
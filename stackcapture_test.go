@@ -0,0 +1,134 @@
+package eal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetStackCapture(t *testing.T) {
+	SetStackCapture(false)
+	t.Cleanup(func() { SetStackCapture(true) })
+	entries := captureLog(t)
+
+	before := StacksSuppressed()
+	err := Trace(errors.New("db down"))
+	if st, ok := GetErrorStackTrace(err); !ok || st.Stack() != "" || st.Origin() != "" {
+		t.Errorf("got error: %#v, want an ErrorStackTrace without stack and origin", err)
+	}
+	if got := StacksSuppressed() - before; got != 1 {
+		t.Errorf("got %d suppressed stacks, want 1", got)
+	}
+
+	NewEntry().WithError(err).Error("failed")
+	logged := entries()
+	if _, ok := logged[0][FieldErrorStack]; ok || logged[0][FieldErrorOrigin] != nil {
+		t.Errorf("got entry: %v, want no error_stack and error_origin", logged[0])
+	}
+}
+
+func TestStackGovernor(t *testing.T) {
+	entries := captureLog(t)
+	g := &StackGovernor{MaxPerSecond: 2, Cooldown: time.Minute}
+	start := time.Now()
+
+	for i, tt := range []struct {
+		at   time.Duration
+		want bool
+	}{
+		{at: 0, want: true},
+		{at: 100 * time.Millisecond, want: true},
+		{at: 200 * time.Millisecond, want: false}, // limit passed, disabled for a minute
+		{at: 30 * time.Second, want: false},
+		{at: 61 * time.Second, want: true},
+		{at: 62 * time.Second, want: true},
+	} {
+		if got := g.allow(start.Add(tt.at)); got != tt.want {
+			t.Errorf("got allow %d at %s: %t, want: %t", i, tt.at, got, tt.want)
+		}
+	}
+
+	logged := entries()
+	if len(logged) != 2 || logged[0]["msg"] != "stack_capture_suppressed" || logged[1]["msg"] != "stack_capture_resumed" {
+		t.Fatalf("got log entries: %v, want suppressed and resumed entries", logged)
+	}
+	if logged[1][FieldStacksSuppressed] != float64(2) {
+		t.Errorf("got %s: %v, want: 2", FieldStacksSuppressed, logged[1][FieldStacksSuppressed])
+	}
+}
+
+func TestStackDepthAndSkip(t *testing.T) {
+	defer func(depth, skip int) { StackMaxDepth, StackSkip = depth, skip }(StackMaxDepth, StackSkip)
+
+	for _, tt := range []struct {
+		name  string
+		depth int
+		skip  int
+		first string
+		lines int
+	}{
+		{name: "default", depth: 64, first: "eal.traceHelper("},
+		{name: "skip helper", depth: 64, skip: 1, first: "eal.TestStackDepthAndSkip.func"},
+		{name: "max depth", depth: 2, first: "eal.traceHelper(", lines: 1 + 2*2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			StackMaxDepth, StackSkip = tt.depth, tt.skip
+			st, _ := GetErrorStackTrace(traceHelper(errors.New("db down")))
+			lines := strings.Split(strings.TrimSuffix(st.Stack(), "\n"), "\n")
+			if len(lines) < 3 || !strings.Contains(lines[1], tt.first) || !strings.HasPrefix(lines[2], "\t") {
+				t.Fatalf("got stack:\n%s\nwant it to start with %s", st.Stack(), tt.first)
+			}
+			if tt.lines > 0 && len(lines) != tt.lines {
+				t.Errorf("got %d lines, want %d:\n%s", len(lines), tt.lines, st.Stack())
+			}
+		})
+	}
+}
+
+func BenchmarkTrace(b *testing.B) {
+	err := errors.New("db down")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Trace(err)
+	}
+}
+
+func TestFilterStack(t *testing.T) {
+	const stack = "goroutine 7 [running]:\n" +
+		"runtime/debug.Stack()\n\t/go/src/runtime/debug/stack.go:26 +0x5e\n" +
+		"panic({0x9a2b80?, 0xc000010f90?})\n\t/go/src/runtime/panic.go:770 +0x132\n" +
+		"example.com/app/users.(*Handler).Get(...)\n\t/app/users/handler.go:42\n" +
+		"github.com/modfin/eal.NewLoggerMiddleware.func1.1({0xb5c1d8, 0xc0001b4000})\n\t/eal/middleware.go:230 +0x2c4\n" +
+		"github.com/labstack/echo/v4.(*Echo).ServeHTTP(0xc000132000, {0xb57f50, 0xc0001a8000}, 0xc000196000)\n\t/echo/echo.go:663 +0x327\n" +
+		"created by net/http.(*Server).Serve in goroutine 1\n\t/go/src/net/http/server.go:3285 +0x4b4\n"
+
+	for _, tt := range []struct {
+		name   string
+		filter StackFrameFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"runtime/debug.Stack", "panic(", "users.(*Handler).Get", "eal.NewLoggerMiddleware", "echo/v4.(*Echo)", "created by"}},
+		{name: "runtime", filter: StackFrameFilter{Runtime: true}, want: []string{"users.(*Handler).Get", "eal.NewLoggerMiddleware", "echo/v4.(*Echo)", "created by"}},
+		{name: "all", filter: StackFrameFilter{Eal: true, Runtime: true, Prefixes: []string{"github.com/labstack/echo/v4."}}, want: []string{"users.(*Handler).Get", "created by"}},
+		{name: "everything matched", filter: StackFrameFilter{Prefixes: []string{""}}, want: []string{"runtime/debug.Stack", "panic(", "users.(*Handler).Get", "eal.NewLoggerMiddleware", "echo/v4.(*Echo)", "created by"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterStack(stack, tt.filter)
+			var functions []string
+			for _, line := range strings.Split(got, "\n")[1:] {
+				if line != "" && !strings.HasPrefix(line, "\t") {
+					functions = append(functions, line)
+				}
+			}
+			if len(functions) != len(tt.want) || strings.Count(got, "\n\t") != len(tt.want) {
+				t.Fatalf("got stack:\n%s\nwant frames: %v", got, tt.want)
+			}
+			for i, f := range functions {
+				if !strings.Contains(f, tt.want[i]) {
+					t.Errorf("got frame %d: %s, want: %s", i, f, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package eal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StackEncoding control how the error_stack log field is written.
+type StackEncoding int
+
+const (
+	// StackPlain log the stacktrace as-is.
+	StackPlain StackEncoding = iota
+
+	// StackCompressed log the stacktrace gzip compressed and base64 encoded, prefixed with "gzip+base64:".
+	// Use ExpandStack to restore the original stacktrace.
+	StackCompressed
+
+	// StackDeduplicated log the stacktrace together with a stack ID the first time a stacktrace is seen within
+	// StackDedupWindow, subsequent log entries with an identical stacktrace only log the stack ID.
+	StackDeduplicated
+)
+
+const (
+	compressedStackPrefix = "gzip+base64:"
+)
+
+var (
+	// StackLogEncoding select how stacktraces are logged, see StackEncoding for available options.
+	StackLogEncoding = StackPlain
+
+	// StackDedupWindow is the time window used by StackDeduplicated to decide if an already logged stacktrace should
+	// be logged again.
+	StackDedupWindow = 10 * time.Minute
+
+	stackDedupMu   sync.Mutex
+	stackDedupSeen = make(map[string]time.Time)
+)
+
+// setStackLogFields add the stacktrace to the log fields, using the encoding selected by StackLogEncoding.
+func setStackLogFields(stack string, fields map[string]interface{}) {
+	switch StackLogEncoding {
+	case StackCompressed:
+		fields[FieldErrorStack] = compressStack(stack)
+	case StackDeduplicated:
+		id := stackID(stack)
+		fields[FieldErrorStackID] = id
+		if firstStackInWindow(id, time.Now()) {
+			fields[FieldErrorStack] = stack
+		}
+	default:
+		fields[FieldErrorStack] = stack
+	}
+}
+
+// ExpandStack return the original stacktrace from an error_stack field value that was written using the
+// StackCompressed encoding. Stacktraces that isn't compressed is returned unmodified.
+func ExpandStack(s string) (string, error) {
+	if !strings.HasPrefix(s, compressedStackPrefix) {
+		return s, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, compressedStackPrefix))
+	if err != nil {
+		return "", err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	stack, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(stack), nil
+}
+
+func compressStack(stack string) string {
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	_, _ = zw.Write([]byte(stack))
+	_ = zw.Close()
+	return compressedStackPrefix + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func stackID(stack string) string {
+	sum := sha256.Sum256([]byte(stack))
+	return hex.EncodeToString(sum[:8])
+}
+
+// firstStackInWindow return true if the stack ID haven't been seen within the StackDedupWindow.
+func firstStackInWindow(id string, now time.Time) bool {
+	stackDedupMu.Lock()
+	defer stackDedupMu.Unlock()
+
+	if seen, ok := stackDedupSeen[id]; ok && now.Sub(seen) < StackDedupWindow {
+		return false
+	}
+
+	// Prune expired stack IDs, to keep the map from growing without bounds
+	for k, seen := range stackDedupSeen {
+		if now.Sub(seen) >= StackDedupWindow {
+			delete(stackDedupSeen, k)
+		}
+	}
+	stackDedupSeen[id] = now
+	return true
+}
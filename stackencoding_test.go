@@ -0,0 +1,58 @@
+package eal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStackEncoding(t *testing.T) {
+	defer func() { StackLogEncoding = StackPlain }()
+	const stack = "goroutine 1 [running]:\nmain.main()\n\t/app/main.go:42 +0x1d\n"
+
+	t.Run("compressed", func(t *testing.T) {
+		StackLogEncoding = StackCompressed
+		fields := map[string]interface{}{}
+		setStackLogFields(stack, fields)
+		s, _ := fields[FieldErrorStack].(string)
+		if s == stack {
+			t.Fatal("got uncompressed stack")
+		}
+		got, err := ExpandStack(s)
+		if err != nil {
+			t.Fatalf("ExpandStack() error: %v", err)
+		}
+		if got != stack {
+			t.Errorf("got expanded stack: %q, want: %q", got, stack)
+		}
+	})
+
+	t.Run("plain_expand", func(t *testing.T) {
+		got, err := ExpandStack(stack)
+		if err != nil || got != stack {
+			t.Errorf("got: %q, %v, want: %q, nil", got, err, stack)
+		}
+	})
+
+	t.Run("deduplicated", func(t *testing.T) {
+		StackLogEncoding = StackDeduplicated
+		first := map[string]interface{}{}
+		setStackLogFields(stack, first)
+		second := map[string]interface{}{}
+		setStackLogFields(stack, second)
+
+		if first[FieldErrorStackID] == nil || first[FieldErrorStackID] != second[FieldErrorStackID] {
+			t.Errorf("got stack IDs: %v and %v, want equal non nil IDs", first[FieldErrorStackID], second[FieldErrorStackID])
+		}
+		if first[FieldErrorStack] != stack {
+			t.Errorf("first entry: got stack: %v, want: %q", first[FieldErrorStack], stack)
+		}
+		if _, ok := second[FieldErrorStack]; ok {
+			t.Error("second entry: got stack, want only stack ID")
+		}
+
+		id, _ := first[FieldErrorStackID].(string)
+		if !firstStackInWindow(id, time.Now().Add(StackDedupWindow)) {
+			t.Error("want stack to be logged again when the dedup window have passed")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package eal
+
+import (
+	"context"
+	"time"
+)
+
+// stageTracker keep track of the current stage, and the accumulated time spent in each stage of a request.
+type stageTracker struct {
+	current   string
+	started   time.Time
+	durations map[string]time.Duration
+	order     []string
+}
+
+// Stage mark that the request handling have reached a new stage, for example "authorize" or "load_user". The
+// previous stage, if any, ends when a new stage is started. When the request is logged by the CreateLoggerMiddleware,
+// the time spent in each stage is logged in the stages_ms field, and if the handler returned an error, the last
+// stage that was reached is logged in the failed_stage field. The stages are timed with the Clock of the logger
+// middleware, see Deps.
+//
+//	eal.Stage(c.Request().Context(), "load_user")
+//
+// If the same stage name is used more than once, the time spent in the stage is accumulated. Stage do nothing if ctx
+// isn't derived from a request context set up by the logger middleware, and it's safe for concurrent use.
+func Stage(ctx context.Context, name string) {
+	rf := contextRequestFields(ctx)
+	if rf == nil || rf.clock == nil {
+		return
+	}
+	now := rf.clock.Now()
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return
+	}
+	if rf.stages == nil {
+		rf.stages = &stageTracker{durations: make(map[string]time.Duration)}
+	}
+	rf.stages.start(name, now)
+}
+
+func (st *stageTracker) start(name string, now time.Time) {
+	st.stop(now)
+	st.current = name
+	st.started = now
+	if _, ok := st.durations[name]; !ok {
+		st.order = append(st.order, name)
+		st.durations[name] = 0
+	}
+}
+
+func (st *stageTracker) stop(now time.Time) {
+	if st.current == "" {
+		return
+	}
+	st.durations[st.current] += now.Sub(st.started)
+	st.started = now
+}
+
+// setStageFields add the stage log fields, if Stage have been used during the request. The request fields must have
+// been closed, and now is when the request handling ended.
+func setStageFields(rf *requestFields, fields Fields, failed bool, now time.Time) {
+	st := rf.stages
+	if st == nil {
+		return
+	}
+
+	st.stop(now)
+	ms := make(map[string]int64, len(st.durations))
+	for _, name := range st.order {
+		ms[name] = int64(st.durations[name] / time.Millisecond)
+	}
+	fields[FieldStagesMs] = ms
+	if failed {
+		fields[FieldFailedStage] = st.current
+	}
+}
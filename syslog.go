@@ -0,0 +1,265 @@
+package eal
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SyslogFacility is the syslog facility of the messages written by the SyslogFormatter.
+type SyslogFacility int
+
+// Syslog facilities, see RFC 5424 section 6.2.1.
+const (
+	SyslogUser   SyslogFacility = 1
+	SyslogDaemon SyslogFacility = 3
+	SyslogLocal0 SyslogFacility = 16
+	SyslogLocal1 SyslogFacility = 17
+	SyslogLocal2 SyslogFacility = 18
+	SyslogLocal3 SyslogFacility = 19
+	SyslogLocal4 SyslogFacility = 20
+	SyslogLocal5 SyslogFacility = 21
+	SyslogLocal6 SyslogFacility = 22
+	SyslogLocal7 SyslogFacility = 23
+)
+
+// syslogTimeFormat is the TIMESTAMP format of RFC 5424 section 6.2.3, which allow at most six fractional digits.
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+type (
+	// SyslogConfig configure the syslog output, see InitSyslog.
+	SyslogConfig struct {
+		// Network and Addr is the address of the syslog server, for example "udp" and "syslog:514". If Network is
+		// empty, messages are written to the local syslog socket (/dev/log), that is also read by journald.
+		Network string
+		Addr    string
+
+		// Facility is the syslog facility, SyslogUser is used if not set.
+		Facility SyslogFacility
+
+		// AppName is the APP-NAME of the messages, the name of the executable is used if not set.
+		AppName string
+
+		// Hostname is the HOSTNAME of the messages, os.Hostname is used if not set.
+		Hostname string
+
+		// SDID is the SD-ID of the structured data element that hold the log fields, "eal@32473" is used if not set.
+		SDID string
+	}
+
+	// SyslogFormatter is a logrus.Formatter that format log entries as RFC 5424 syslog messages. The log fields are
+	// encoded as parameters of a structured data element, and the log level is mapped to the syslog severity.
+	SyslogFormatter struct {
+		Facility SyslogFacility
+		AppName  string
+		Hostname string
+		SDID     string
+	}
+
+	// syslogWriter write each message to the syslog server, with octet counting framing (RFC 6587) for stream
+	// connections.
+	syslogWriter struct {
+		mu      sync.Mutex
+		network string
+		addr    string
+		conn    net.Conn
+	}
+)
+
+// InitSyslog initialize the logrus logger to write RFC 5424 syslog messages to a syslog server, or to the local syslog
+// socket.
+//
+//	err := eal.InitSyslog(eal.SyslogConfig{Facility: eal.SyslogLocal0})
+func InitSyslog(config SyslogConfig) error {
+	installHook()
+	network, addr := config.Network, config.Addr
+	if network == "" {
+		network, addr = "unixgram", "/dev/log"
+	}
+	w := &syslogWriter{network: network, addr: addr}
+	if err := w.connect(); err != nil {
+		return err
+	}
+
+	appName, hostname := config.AppName, config.Hostname
+	if appName == "" {
+		appName = executableName()
+	}
+	if hostname == "" {
+		hostname = localHostname()
+	}
+	logrus.SetFormatter(&SyslogFormatter{
+		Facility: config.Facility,
+		AppName:  appName,
+		Hostname: hostname,
+		SDID:     config.SDID,
+	})
+	logrus.SetOutput(w)
+	return nil
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *SyslogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	facility := f.Facility
+	if facility == 0 {
+		facility = SyslogUser
+	}
+	hostname := f.Hostname
+	if hostname == "" {
+		hostname = localHostname()
+	}
+	appName := f.AppName
+	if appName == "" {
+		appName = executableName()
+	}
+	sdID := f.SDID
+	if sdID == "" {
+		sdID = "eal@32473"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - ",
+		int(facility)*8+syslogSeverity(entry.Level),
+		entry.Time.Format(syslogTimeFormat),
+		syslogHeaderValue(hostname, 255),
+		syslogHeaderValue(appName, 48),
+		os.Getpid(),
+	)
+
+	if len(entry.Data) == 0 {
+		b.WriteString("-")
+	} else {
+		keys := make([]string, 0, len(entry.Data))
+		for k := range entry.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("[" + sdID)
+		for _, k := range keys {
+			v := entry.Data[k]
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			fmt.Fprintf(&b, ` %s="%s"`, syslogParamName(k), syslogParamValue.Replace(fmt.Sprint(v)))
+		}
+		b.WriteString("]")
+	}
+
+	if entry.Message != "" {
+		b.WriteString(" " + entry.Message)
+	}
+	return []byte(b.String()), nil
+}
+
+// localHostname return the name of the host, from os.Hostname, which is resolved once.
+var localHostname = sync.OnceValue(func() string {
+	hostname, _ := os.Hostname()
+	return hostname
+})
+
+// executableName return the name of the executable, which is resolved once.
+var executableName = sync.OnceValue(func() string {
+	return filepath.Base(os.Args[0])
+})
+
+// syslogSeverity return the syslog severity of the log level, which is also used as the GELF level.
+func syslogSeverity(l logrus.Level) int {
+	switch l {
+	case logrus.PanicLevel:
+		return 0
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// syslogParamValue escape the characters that must be escaped in structured data parameter values.
+var syslogParamValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogParamName replace the characters that isn't allowed in structured data parameter names. Names longer than 32
+// bytes are truncated, and end with a hash of the full name so that names with the same prefix don't collide.
+func syslogParamName(name string) string {
+	full := name
+	name = strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 127 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(full))
+		name = fmt.Sprintf("%s~%08x", name[:23], h.Sum32())
+	}
+	return name
+}
+
+// syslogHeaderValue return the value as a valid header field, with at most maxLen printable ASCII characters.
+func syslogHeaderValue(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 127 {
+			return '_'
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	return value
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		if err := w.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	msg := p
+	if !strings.HasPrefix(w.network, "udp") && !strings.HasPrefix(w.network, "unix") {
+		msg = append([]byte(fmt.Sprintf("%d ", len(p))), p...)
+	}
+	if _, err := w.conn.Write(msg); err != nil {
+		// Reconnect and retry once, the syslog server may have been restarted
+		_ = w.conn.Close()
+		if err = w.connect(); err != nil {
+			return 0, err
+		}
+		if _, err = w.conn.Write(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *syslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.addr, 5*time.Second)
+	if err != nil {
+		w.conn = nil
+		return err
+	}
+	w.conn = conn
+	return nil
+}
@@ -0,0 +1,76 @@
+package eal
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSyslogFormatter(t *testing.T) {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Time = time.Date(2024, 5, 17, 12, 0, 0, 123456789, time.UTC)
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "access"
+	entry.Data = logrus.Fields{FieldStatus: 500, FieldErrorMessage: errors.New(`bad "input" [x]`), "user id": `a\b`}
+
+	b, err := (&SyslogFormatter{Facility: SyslogLocal0, AppName: "users", Hostname: "api-1"}).Format(entry)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	want := fmt.Sprintf(`<131>1 2024-05-17T12:00:00.123456Z api-1 users %d - [eal@32473 error_message="bad \"input\" [x\]" status="500" user_id="a\\b"] access`, os.Getpid())
+	if string(b) != want {
+		t.Errorf("got message:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestSyslogWriterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		received <- string(buf[:n])
+	}()
+
+	w := &syslogWriter{network: "tcp", addr: l.Addr().String()}
+	if _, err = w.Write([]byte("<14>1 - - - - - - hello")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	select {
+	case got := <-received:
+		if got != "23 <14>1 - - - - - - hello" {
+			t.Errorf("got framed message: %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+}
+
+func TestSyslogParamNameTruncated(t *testing.T) {
+	a := syslogParamName("request_header_x_forwarded_for_first")
+	b := syslogParamName("request_header_x_forwarded_for_second")
+	if len(a) != 32 || len(b) != 32 {
+		t.Errorf("got names %q and %q, want 32 bytes", a, b)
+	}
+	if a == b {
+		t.Errorf("got the same name %q for different fields", a)
+	}
+	if got := syslogParamName("user id"); got != "user_id" {
+		t.Errorf("got name %q, want user_id", got)
+	}
+}
@@ -0,0 +1,52 @@
+package eal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// messageTemplate is a parsed LoggerConfig.MessageTemplate, where every odd segment is a field name.
+type messageTemplate struct {
+	segments []string
+}
+
+// parseMessageTemplate split a template like "{method} {router_path} -> {status}" into literal text and field names.
+// An unterminated "{" is treated as literal text.
+func parseMessageTemplate(tmpl string) *messageTemplate {
+	if tmpl == "" {
+		return nil
+	}
+
+	mt := &messageTemplate{}
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		mt.segments = append(mt.segments, tmpl[:start], tmpl[start+1:start+end])
+		tmpl = tmpl[start+end+1:]
+	}
+	mt.segments = append(mt.segments, tmpl)
+	return mt
+}
+
+// render the template with values from the log fields, missing fields are rendered as "-".
+func (mt *messageTemplate) render(fields map[string]interface{}) string {
+	var b strings.Builder
+	for i, s := range mt.segments {
+		if i%2 == 0 {
+			b.WriteString(s)
+			continue
+		}
+		if v, ok := fields[s]; ok {
+			fmt.Fprint(&b, v)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package eal
+
+import (
+	"testing"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	fields := map[string]interface{}{"method": "GET", "router_path": "/users/:id", "status": 404}
+	for _, tt := range []struct {
+		tmpl string
+		want string
+	}{
+		{tmpl: "{method} {router_path} -> {status}", want: "GET /users/:id -> 404"},
+		{tmpl: "{method} {missing}", want: "GET -"},
+		{tmpl: "no fields", want: "no fields"},
+		{tmpl: "unterminated {method", want: "unterminated {method"},
+	} {
+		if got := parseMessageTemplate(tt.tmpl).render(fields); got != tt.want {
+			t.Errorf("template %q: got: %q, want: %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package eal
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"text/template"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	// execErrorPattern match the message of template.ExecError, for example:
+	// template: page:2:7: executing "page" at <.User.Name>: map has no entry for key "User"
+	execErrorPattern  = regexp.MustCompile(`^template: ([^:]+):(\d+):(?:\d+:)? executing "[^"]*" at <([^>]*)>: (.*)$`)
+	missingKeyPattern = regexp.MustCompile(`^(?:map has no entry for key "([^"]*)"|can't evaluate field (\S+) in type)`)
+)
+
+// InitTemplateErrorLogging register error log functions for html/template and text/template errors, that add the
+// template_name, template_line, template_action and template_missing_key fields, instead of only logging the error
+// message as an opaque string.
+func InitTemplateErrorLogging() {
+	// template.ExecError is returned as a value, and can't be registered by a nil pointer
+	RegisterErrorLogFuncFor(func(err template.ExecError, fields Fields) {
+		templateErrorLogger(err, fields)
+	})
+	RegisterErrorLogFunc(templateErrorLogger, (*htmltemplate.Error)(nil))
+}
+
+func templateErrorLogger(err error, fields Fields) {
+	switch e := err.(type) {
+	case template.ExecError:
+		fields[FieldTemplateName] = e.Name
+		m := execErrorPattern.FindStringSubmatch(e.Error())
+		if m == nil {
+			return
+		}
+		if line, err := strconv.Atoi(m[2]); err == nil {
+			fields[FieldTemplateLine] = line
+		}
+		fields[FieldTemplateAction] = m[3]
+		if k := missingKeyPattern.FindStringSubmatch(m[4]); k != nil {
+			fields[FieldTemplateMissingKey] = k[1] + k[2]
+		}
+	case *htmltemplate.Error:
+		fields[FieldTemplateName] = e.Name
+		if e.Line > 0 {
+			fields[FieldTemplateLine] = e.Line
+		}
+	}
+}
+
+// TemplateRenderer is an echo.Renderer that execute templates, and respond with a static error page when a template
+// fail, so that users get a branded 500 page instead of a half rendered page or a JSON error. The template error is
+// returned wrapped in an echo.HTTPError, and logged by the logger middleware with the template fields, see
+// InitTemplateErrorLogging.
+//
+//	e.Renderer = &eal.TemplateRenderer{
+//	  Templates: template.Must(template.ParseGlob("views/*.html")),
+//	  ErrorPage: errorPageHTML,
+//	}
+type TemplateRenderer struct {
+	Templates interface {
+		ExecuteTemplate(w io.Writer, name string, data interface{}) error
+	}
+
+	// ErrorPage is the HTML page that is sent with status 500 when a template fail. If it's empty, the error is handled
+	// by the echo error handler.
+	ErrorPage []byte
+}
+
+// Render implements the echo.Renderer interface.
+func (tr *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	err := tr.Templates.ExecuteTemplate(w, name, data)
+	if err == nil {
+		return nil
+	}
+
+	err = NewHTTPError(Trace(err), http.StatusInternalServerError)
+	if len(tr.ErrorPage) > 0 && !c.Response().Committed {
+		// The error page commit the response, so the echo error handler won't write another response
+		_ = c.HTMLBlob(http.StatusInternalServerError, tr.ErrorPage)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package eal
+
+import (
+	htmltemplate "html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"text/template"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	InitTemplateErrorLogging()
+	t.Cleanup(func() {
+		errorLogFunctionsMu.Lock()
+		delete(registeredErrorLogFunctions, reflect.TypeOf(template.ExecError{}))
+		delete(registeredErrorLogFunctions, reflect.TypeOf((*htmltemplate.Error)(nil)))
+		compileErrorRegistry()
+		errorLogFunctionsMu.Unlock()
+	})
+	entries := captureLog(t)
+
+	e := echo.New()
+	e.Use(CreateLoggerMiddleware())
+	e.Renderer = &TemplateRenderer{
+		Templates: htmltemplate.Must(htmltemplate.New("profile").Option("missingkey=error").Parse("<h1>Hi</h1>\n<p>{{.User.Name}}</p>")),
+		ErrorPage: []byte("<h1>Oops</h1>"),
+	}
+	e.GET("/profile", func(c echo.Context) error {
+		return c.Render(http.StatusOK, "profile", map[string]interface{}{})
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/profile", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "<h1>Oops</h1>" {
+		t.Errorf("got response: %d %s, want the error page with status 500", rec.Code, rec.Body.String())
+	}
+
+	logged := entries()
+	if len(logged) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logged))
+	}
+	for k, want := range map[string]interface{}{
+		FieldStatus:             float64(http.StatusInternalServerError),
+		FieldTemplateName:       "profile",
+		FieldTemplateLine:       float64(2),
+		FieldTemplateAction:     ".User.Name",
+		FieldTemplateMissingKey: "User",
+	} {
+		if logged[0][k] != want {
+			t.Errorf("got %s: %v, want: %v", k, logged[0][k], want)
+		}
+	}
+}
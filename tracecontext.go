@@ -0,0 +1,151 @@
+package eal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerTraceparent = "Traceparent"
+	headerTracestate  = "Tracestate"
+	headerB3          = "B3"
+	headerB3TraceID   = "X-B3-Traceid"
+	headerB3SpanID    = "X-B3-Spanid"
+	headerB3Sampled   = "X-B3-Sampled"
+	headerB3Flags     = "X-B3-Flags"
+)
+
+// randReader is the source of generated trace and span IDs, it's replaced in tests.
+var randReader io.Reader = rand.Reader
+
+// TraceContextLogFunc is a ContextLogFunc that parse W3C Trace Context (traceparent/tracestate) and B3 (single or
+// multi header) tracing headers, and log the trace_id, span_id, parent_span_id, trace_flags and trace_state fields.
+// The span ID of the tracing headers is the caller's span, so it's logged as parent_span_id, and a new span ID is
+// generated for this hop, and set in the traceparent header of the request, so that it's propagated to downstream
+// calls. If the request don't have any tracing headers, a new trace and span ID is generated and set in the
+// traceparent header on both the request and the response, in the same way as X-Request-Id is handled by
+// DefaultContextLogFunc. If the IDs can't be generated, the error is logged and the fields and headers that need a
+// generated ID are skipped, the fields of the tracing headers are still logged.
+//
+//	e.Use(eal.CreateLoggerMiddleware(eal.DefaultContextLogFunc, eal.TraceContextLogFunc))
+var TraceContextLogFunc = func(c echo.Context, fields Fields) {
+	req := c.Request()
+
+	traceID, parentID, flags, ok := parseTraceparent(req.Header.Get(headerTraceparent))
+	w3c := ok
+	if ok {
+		if ts := req.Header.Get(headerTracestate); ts != "" {
+			fields[FieldTraceState] = ts
+		}
+	} else {
+		traceID, parentID, flags, ok = parseB3(req)
+	}
+
+	spanID, err := randomHex(8)
+	if ok {
+		fields[FieldTraceID] = traceID
+		fields[FieldParentSpanID] = parentID
+		fields[FieldTraceFlags] = flags
+		if err != nil {
+			NewEntry().withError(err).Error("failed to generate span ID")
+			return
+		}
+		if w3c {
+			req.Header.Set(headerTraceparent, "00-"+traceID+"-"+spanID+"-"+flags)
+		}
+		fields[FieldSpanID] = spanID
+		return
+	}
+
+	if err == nil {
+		traceID, err = randomHex(16)
+	}
+	if err != nil {
+		NewEntry().withError(err).Error("failed to generate trace ID")
+		return
+	}
+	tp := "00-" + traceID + "-" + spanID + "-00"
+	req.Header.Set(headerTraceparent, tp)
+	c.Response().Header().Set(headerTraceparent, tp)
+	fields[FieldTraceID] = traceID
+	fields[FieldSpanID] = spanID
+	fields[FieldTraceFlags] = "00"
+}
+
+// parseTraceparent parse a W3C traceparent header: version-traceid-parentid-flags.
+func parseTraceparent(h string) (traceID, spanID, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", "", false
+	}
+	traceID, spanID, flags = strings.ToLower(parts[1]), strings.ToLower(parts[2]), strings.ToLower(parts[3])
+	if !validID(traceID, 32) || !validID(spanID, 16) || !isHex(flags) || len(flags) != 2 {
+		return "", "", "", false
+	}
+	return traceID, spanID, flags, true
+}
+
+// parseB3 parse the B3 single header (traceid-spanid-sampled-parentspanid) or the B3 multi headers. 64-bit trace IDs
+// are left-padded to 128 bits, to have the same format as W3C trace IDs.
+func parseB3(req *http.Request) (traceID, spanID, flags string, ok bool) {
+	var sampled string
+	if b3 := strings.TrimSpace(req.Header.Get(headerB3)); b3 != "" {
+		parts := strings.Split(b3, "-")
+		if len(parts) < 2 {
+			return "", "", "", false
+		}
+		traceID, spanID = strings.ToLower(parts[0]), strings.ToLower(parts[1])
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	} else {
+		traceID = strings.ToLower(req.Header.Get(headerB3TraceID))
+		spanID = strings.ToLower(req.Header.Get(headerB3SpanID))
+		sampled = req.Header.Get(headerB3Sampled)
+		if req.Header.Get(headerB3Flags) == "1" {
+			sampled = "d"
+		}
+	}
+
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !validID(traceID, 32) || !validID(spanID, 16) {
+		return "", "", "", false
+	}
+
+	flags = "00"
+	switch sampled {
+	case "1", "d", "true":
+		flags = "01"
+	}
+	return traceID, spanID, flags, true
+}
+
+// validID check that id is a hex string of length n, that isn't all zeros.
+func validID(id string, n int) bool {
+	return len(id) == n && isHex(id) && strings.Trim(id, "0") != ""
+}
+
+func isHex(s string) bool {
+	for _, ch := range s {
+		if !((ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+// randomHex return n random bytes from crypto/rand, hex encoded, or an error if the random source fail.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(randReader, b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,131 @@
+package eal
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/iotest"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTraceContextLogFunc(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		headers   map[string]string
+		wantTrace string
+		wantSpan  string
+		wantFlags string
+	}{
+		{
+			name:      "traceparent",
+			headers:   map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "tracestate": "congo=t61rcWkgMzE"},
+			wantTrace: "4bf92f3577b34da6a3ce929d0e0e4736", wantSpan: "00f067aa0ba902b7", wantFlags: "01",
+		},
+		{
+			name:      "b3_single",
+			headers:   map[string]string{"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90"},
+			wantTrace: "80f198ee56343ba864fe8b2a57d3eff7", wantSpan: "e457b5a2e4d86bd1", wantFlags: "01",
+		},
+		{
+			name:      "b3_multi_64bit",
+			headers:   map[string]string{"X-B3-TraceId": "463ac35c9f6413ad", "X-B3-SpanId": "a2fb4a1d1a96d312", "X-B3-Sampled": "0"},
+			wantTrace: "0000000000000000463ac35c9f6413ad", wantSpan: "a2fb4a1d1a96d312", wantFlags: "00",
+		},
+		{
+			name:    "invalid_traceparent",
+			headers: map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		},
+		{
+			name: "missing",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			fields := Fields{}
+			TraceContextLogFunc(c, fields)
+
+			if tt.wantTrace == "" {
+				// New IDs should have been generated
+				tp := rec.Header().Get("traceparent")
+				traceID, spanID, _, ok := parseTraceparent(tp)
+				if !ok || fields[FieldTraceID] != traceID || fields[FieldSpanID] != spanID || fields[FieldParentSpanID] != nil {
+					t.Errorf("got traceparent: %q, fields: %v, want generated IDs", tp, fields)
+				}
+				return
+			}
+			if fields[FieldTraceID] != tt.wantTrace || fields[FieldParentSpanID] != tt.wantSpan || fields[FieldTraceFlags] != tt.wantFlags {
+				t.Errorf("got: %v, want trace: %s, parent span: %s, flags: %s", fields, tt.wantTrace, tt.wantSpan, tt.wantFlags)
+			}
+			if spanID, _ := fields[FieldSpanID].(string); !validID(spanID, 16) || spanID == tt.wantSpan {
+				t.Errorf("got %s: %v, want a new span ID for this hop", FieldSpanID, fields[FieldSpanID])
+			}
+			if _, ok := tt.headers["traceparent"]; ok {
+				want := "00-" + tt.wantTrace + "-" + fields[FieldSpanID].(string) + "-" + tt.wantFlags
+				if got := req.Header.Get("traceparent"); got != want {
+					t.Errorf("got request traceparent: %q, want: %q", got, want)
+				}
+			}
+			if ts, ok := tt.headers["tracestate"]; ok && fields[FieldTraceState] != ts {
+				t.Errorf("got %s: %v, want: %s", FieldTraceState, fields[FieldTraceState], ts)
+			}
+		})
+	}
+}
+
+func TestTraceContextLogFuncRandomFailure(t *testing.T) {
+	entries := captureLog(t)
+	randReader = iotest.ErrReader(errors.New("no entropy"))
+	t.Cleanup(func() { randReader = rand.Reader })
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	for _, tt := range []struct {
+		name       string
+		header     string
+		wantFields Fields
+	}{
+		{
+			name:   "traceparent",
+			header: traceparent,
+			wantFields: Fields{
+				FieldTraceID: "4bf92f3577b34da6a3ce929d0e0e4736", FieldParentSpanID: "00f067aa0ba902b7", FieldTraceFlags: "01",
+			},
+		},
+		{
+			name:       "missing",
+			wantFields: Fields{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("traceparent", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			fields := Fields{}
+			TraceContextLogFunc(echo.New().NewContext(req, rec), fields)
+
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("got fields: %v, want: %v", fields, tt.wantFields)
+			}
+			if got := req.Header.Get("traceparent"); got != tt.header {
+				t.Errorf("got request traceparent: %q, want: %q", got, tt.header)
+			}
+			if got := rec.Header().Get("traceparent"); got != "" {
+				t.Errorf("got response traceparent: %q, want none", got)
+			}
+		})
+	}
+	if logged := entries(); len(logged) != 2 {
+		t.Errorf("got %d log entries, want 2", len(logged))
+	}
+}